@@ -24,19 +24,26 @@ const (
 	configExample = `# Weaver Configuration File
 # This file is automatically generated
 
-# Ollama API Configuration
-ollama:
-  api_url: "http://localhost:11434"
-  model: "codellama:13b-instruct"
+version: 1
+
+# AI Settings
+ai:
+  default_provider: "ollama"
   temperature: 0.7
   max_tokens: 4096
   timeout: 120s
 
+# Provider Settings
+providers:
+  ollama:
+    api_url: "http://localhost:11434"
+    model: "codellama:13b-instruct"
+
 # UI Configuration
 ui:
   theme: "dark"
   show_spinner: true
-  diff_colors:
+  colors:
     added: "green"
     removed: "red"
     modified: "yellow"
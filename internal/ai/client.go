@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"context"
+)
+
+// ClientConfig holds AI client configuration
+type ClientConfig struct {
+	Provider      string // "ollama", "openai", "anthropic"
+	APIURL        string
+	APIKey        string
+	Model         string
+	Temperature   float64
+	MaxTokens     int
+	SystemPrompt  string
+	PromptBuilder *PromptBuilder
+}
+
+// EnhancedClient wraps a provider with prompt management
+type EnhancedClient struct {
+	AIProvider
+	Model         string
+	config        ClientConfig
+	promptBuilder *PromptBuilder
+}
+
+// NewEnhancedClient creates a new enhanced AI client
+func NewEnhancedClient(config ClientConfig) (*EnhancedClient, error) {
+	// Create base provider
+	provider, err := NewProvider(Config{
+		Provider:    Provider(config.Provider),
+		APIURL:      config.APIURL,
+		APIKey:      config.APIKey,
+		Model:       config.Model,
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	promptBuilder := config.PromptBuilder
+	if promptBuilder == nil {
+		// Create prompt builder
+		promptConfig := SystemPromptConfig{
+			ModelType:     "local",
+			Language:      "", // Will be set per request
+			Framework:     "", // Will be set per request
+			ProjectType:   "", // Will be set per request
+			StyleGuide:    "", // Will be set per request
+			ContextWindow: config.MaxTokens,
+		}
+
+		if config.Provider == "openai" || config.Provider == "anthropic" {
+			promptConfig.ModelType = "cloud"
+		}
+
+		promptBuilder = NewPromptBuilder(promptConfig)
+	}
+
+	return &EnhancedClient{
+		AIProvider:    provider,
+		Model:         config.Model,
+		config:        config,
+		promptBuilder: promptBuilder,
+	}, nil
+}
+
+// GenerateWithCommand generates content using a specific command type
+func (ec *EnhancedClient) GenerateWithCommand(
+	ctx context.Context,
+	cmdType PromptType,
+	task string,
+	context map[string]interface{},
+) (string, error) {
+	// Build the complete prompt
+	prompt := ec.promptBuilder.BuildPrompt(cmdType, task, context)
+
+	// Optimize for specific model
+	prompt = ec.promptBuilder.OptimizeForModel(prompt, ec.Model)
+
+	// Generate using base provider
+	resp, err := ec.Generate(ctx, GenerateRequest{
+		Prompt:      prompt,
+		Model:       ec.Model,
+		Temperature: ec.config.Temperature,
+		MaxTokens:   ec.config.MaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// UpdateProjectContext updates the prompt builder's configuration
+func (ec *EnhancedClient) UpdateProjectContext(
+	language, framework, projectType, styleGuide string,
+) {
+	ec.promptBuilder.config.Language = language
+	ec.promptBuilder.config.Framework = framework
+	ec.promptBuilder.config.ProjectType = projectType
+	ec.promptBuilder.config.StyleGuide = styleGuide
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProviderFactory creates a provider from its configuration
+type ProviderFactory func(config Config) AIProvider
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[Provider]ProviderFactory)
+)
+
+// RegisterProvider makes a provider available to NewProvider.
+// Provider packages call it from their init function.
+func RegisterProvider(name Provider, factory ProviderFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = factory
+}
+
+// NewProvider creates the provider named in config
+func NewProvider(config Config) (AIProvider, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[config.Provider]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, &ProviderError{
+			Provider: config.Provider,
+			Code:     "UNKNOWN_PROVIDER",
+			Message:  fmt.Sprintf("provider %q is not available", config.Provider),
+		}
+	}
+	return factory(config), nil
+}
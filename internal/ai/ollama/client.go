@@ -20,6 +20,12 @@ type Client struct {
 	config     ai.Config
 }
 
+func init() {
+	ai.RegisterProvider(ai.ProviderOllama, func(config ai.Config) ai.AIProvider {
+		return NewClient(config)
+	})
+}
+
 // NewClient creates a new Ollama client
 func NewClient(config ai.Config) *Client {
 	if config.APIURL == "" {
@@ -210,6 +216,69 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.GenerateRequest) (<-
 	return ch, nil
 }
 
+// Embed computes embeddings with an embedding model such as nomic-embed-text
+func (c *Client) Embed(ctx context.Context, req ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	body, err := json.Marshal(EmbedRequest{Model: req.Model, Input: req.Input})
+	if err != nil {
+		return nil, &ai.ProviderError{
+			Provider: ai.ProviderOllama,
+			Code:     "MARSHAL_ERROR",
+			Message:  "Failed to marshal request",
+			Err:      err,
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, &ai.ProviderError{
+			Provider: ai.ProviderOllama,
+			Code:     "REQUEST_ERROR",
+			Message:  "Failed to create request",
+			Err:      err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &ai.ProviderError{
+			Provider: ai.ProviderOllama,
+			Code:     "NETWORK_ERROR",
+			Message:  "Failed to send request",
+			Err:      err,
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &ai.ProviderError{
+			Provider: ai.ProviderOllama,
+			Code:     fmt.Sprintf("HTTP_%d", resp.StatusCode),
+			Message:  fmt.Sprintf("API error: %s", string(body)),
+		}
+	}
+
+	var ollamaResp EmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, &ai.ProviderError{
+			Provider: ai.ProviderOllama,
+			Code:     "PARSE_ERROR",
+			Message:  "Failed to parse response",
+			Err:      err,
+		}
+	}
+	if len(ollamaResp.Embeddings) != len(req.Input) {
+		return nil, &ai.ProviderError{
+			Provider: ai.ProviderOllama,
+			Code:     "PARSE_ERROR",
+			Message:  fmt.Sprintf("expected %d embeddings, got %d", len(req.Input), len(ollamaResp.Embeddings)),
+		}
+	}
+
+	return &ai.EmbedResponse{Embeddings: ollamaResp.Embeddings, Model: ollamaResp.Model}, nil
+}
+
 // ListModels returns available models
 func (c *Client) ListModels(ctx context.Context) ([]ai.Model, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
@@ -12,6 +12,18 @@ type GenerateRequest struct {
 	Context []int   `json:"context,omitempty"`
 }
 
+// EmbedRequest represents an Ollama embedding request
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbedResponse represents an Ollama embedding response
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
 // Options for generation
 type Options struct {
 	Temperature   float64 `json:"temperature,omitempty"`
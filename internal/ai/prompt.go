@@ -128,6 +128,8 @@ func (pb *PromptBuilder) BuildPrompt(cmdType PromptType, task string, context ma
 		taskPrompt = pb.buildAskPrompt(task, context)
 	case PromptTypeAnalyzeImpact:
 		taskPrompt = pb.buildAnalyzeImpactPrompt(task, context)
+	case PromptTypePlanFeature:
+		taskPrompt = pb.buildPlanFeaturePrompt(task, context)
 	default:
 		taskPrompt = fmt.Sprintf("Task: %s", task)
 	}
@@ -220,15 +222,16 @@ Output Format:
 4. Estimated time for fixes`
 }
 
-// buildAskPrompt constructs prompt for natural language queries
+// buildAskPrompt constructs prompt for natural language queries. Code
+// retrieved for the question is passed as context["snippets"].
 func (pb *PromptBuilder) buildAskPrompt(query string, context map[string]interface{}) string {
-	return fmt.Sprintf(`## Command: ASK - Natural Language Query
+	prompt := fmt.Sprintf(`## Command: ASK - Natural Language Query
 
 Question: %s
 
 Process:
 1. Understand the question intent
-2. Search through codebase
+2. Read the code excerpts below
 3. Find relevant files and functions
 4. Provide specific locations
 
@@ -239,12 +242,24 @@ Output Format:
 - Brief explanation
 - Related files
 
+Cite every location as path:line or path:start-end, using the paths and
+line numbers shown in the excerpts. Only answer from the excerpts; if they
+do not contain the answer, say so instead of guessing.
+
 Include actionable suggestions when appropriate.`, query)
+
+	if snippets, ok := context["snippets"].(string); ok && snippets != "" {
+		prompt += "\n\nCode excerpts:\n" + snippets
+	}
+
+	return prompt
 }
 
-// buildAnalyzeImpactPrompt constructs prompt for impact analysis
+// buildAnalyzeImpactPrompt constructs prompt for impact analysis. The files
+// computed from the import graph are passed as context["affected"], and the
+// changed code and its references as context["snippets"].
 func (pb *PromptBuilder) buildAnalyzeImpactPrompt(scenario string, context map[string]interface{}) string {
-	return fmt.Sprintf(`## Command: ANALYZE-IMPACT - Change Impact Analysis
+	prompt := fmt.Sprintf(`## Command: ANALYZE-IMPACT - Change Impact Analysis
 
 Scenario: %s
 
@@ -267,6 +282,72 @@ Affected Areas:
 Risk Assessment: [Low|Medium|High]
 Estimated Effort: [Hours|Days|Weeks]
 Recommended Approach: Step-by-step plan`, scenario)
+
+	if affected, ok := context["affected"].(string); ok && affected != "" {
+		prompt += "\n\nAffected files, computed from the project's import graph and symbol references. " +
+			"Only discuss these files; do not name files that are not listed.\n" + affected
+	}
+	if snippets, ok := context["snippets"].(string); ok && snippets != "" {
+		prompt += "\n\nChanged code and where it is used:\n" + snippets
+	}
+
+	return prompt
+}
+
+// buildPlanFeaturePrompt constructs prompt for feature planning. The plan
+// is requested as JSON so it can be saved and tracked; context["estimate"]
+// asks for hours, context["project_summary"] and context["snippets"]
+// describe the project.
+func (pb *PromptBuilder) buildPlanFeaturePrompt(feature string, context map[string]interface{}) string {
+	estimate, _ := context["estimate"].(bool)
+
+	prompt := fmt.Sprintf(`## Command: PLAN-FEATURE - Implementation Plan
+
+Feature: %s
+
+Plan the implementation for this project:
+1. Break the work into small, ordered steps that each leave the project working
+2. For every step, list the files to create, modify or delete, using paths from this project
+3. List the tests to add or update
+4. List the risks: breaking changes, migrations, security, performance`, feature)
+
+	if estimate {
+		prompt += "\n5. Estimate each step in hours, and the whole plan with your confidence"
+	}
+
+	prompt += `
+
+Respond with JSON only, in this format:
+{
+  "summary": "One paragraph describing the approach",
+  "steps": [
+    {
+      "title": "Short imperative title",
+      "description": "What to do and why",
+      "files": [{"path": "relative/path.ext", "action": "create|modify|delete"}]`
+	if estimate {
+		prompt += `,
+      "hours": 2`
+	}
+	prompt += `
+    }
+  ],
+  "tests": ["Test to add, with the file it goes in"],
+  "risks": ["Risk and how to mitigate it"]`
+	if estimate {
+		prompt += `,
+  "estimate": {"hours": 16, "confidence": "low|medium|high", "notes": "What the estimate assumes"}`
+	}
+	prompt += "\n}"
+
+	if summary, ok := context["project_summary"].(string); ok && summary != "" {
+		prompt += "\n\nProject:\n" + summary
+	}
+	if snippets, ok := context["snippets"].(string); ok && snippets != "" {
+		prompt += "\n\nExisting code related to the feature:\n" + snippets
+	}
+
+	return prompt
 }
 
 // buildContextPrompt builds the context section of the prompt
@@ -361,84 +442,3 @@ func (pb *PromptBuilder) OptimizeForModel(prompt string, modelName string) strin
 	
 	return prompt
 }
-
-// internal/ai/client.go additions
-package ai
-
-import (
-	"context"
-	"fmt"
-)
-
-// ClientConfig holds AI client configuration
-type ClientConfig struct {
-	Provider      string // "ollama", "openai", "anthropic"
-	Model         string
-	Temperature   float64
-	MaxTokens     int
-	SystemPrompt  string
-	PromptBuilder *PromptBuilder
-}
-
-// EnhancedClient wraps the basic AI client with prompt management
-type EnhancedClient struct {
-	*Client
-	promptBuilder *PromptBuilder
-}
-
-// NewEnhancedClient creates a new enhanced AI client
-func NewEnhancedClient(config ClientConfig) (*EnhancedClient, error) {
-	// Create base client
-	baseClient, err := NewClient(config.Provider, config.Model, config.Temperature)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Create prompt builder
-	promptConfig := SystemPromptConfig{
-		ModelType:     "local",
-		Language:      "", // Will be set per request
-		Framework:     "", // Will be set per request
-		ProjectType:   "", // Will be set per request
-		StyleGuide:    "", // Will be set per request
-		ContextWindow: config.MaxTokens,
-	}
-	
-	if config.Provider == "openai" || config.Provider == "anthropic" {
-		promptConfig.ModelType = "cloud"
-	}
-	
-	promptBuilder := NewPromptBuilder(promptConfig)
-	
-	return &EnhancedClient{
-		Client:        baseClient,
-		promptBuilder: promptBuilder,
-	}, nil
-}
-
-// GenerateWithCommand generates content using a specific command type
-func (ec *EnhancedClient) GenerateWithCommand(
-	ctx context.Context,
-	cmdType PromptType,
-	task string,
-	context map[string]interface{},
-) (string, error) {
-	// Build the complete prompt
-	prompt := ec.promptBuilder.BuildPrompt(cmdType, task, context)
-	
-	// Optimize for specific model
-	prompt = ec.promptBuilder.OptimizeForModel(prompt, ec.Model)
-	
-	// Generate using base client
-	return ec.Generate(ctx, prompt)
-}
-
-// UpdateProjectContext updates the prompt builder's configuration
-func (ec *EnhancedClient) UpdateProjectContext(
-	language, framework, projectType, styleGuide string,
-) {
-	ec.promptBuilder.config.Language = language
-	ec.promptBuilder.config.Framework = framework
-	ec.promptBuilder.config.ProjectType = projectType
-	ec.promptBuilder.config.StyleGuide = styleGuide
-}
\ No newline at end of file
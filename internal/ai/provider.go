@@ -71,6 +71,23 @@ type AIProvider interface {
 	GetName() Provider
 }
 
+// EmbedRequest asks for vector embeddings of several inputs
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbedResponse holds one embedding per input, in input order
+type EmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Model      string      `json:"model"`
+}
+
+// Embedder is implemented by providers that can compute embeddings
+type Embedder interface {
+	Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error)
+}
+
 // StreamChunk represents a chunk in streaming response
 type StreamChunk struct {
 	Content string `json:"content"`
@@ -0,0 +1,188 @@
+// Package analyzer provides static checks used by heal-project
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/snowsoft/codeweaver/internal/walker"
+)
+
+// Issue represents a detected project issue
+type Issue struct {
+	Type        string
+	Severity    string // critical, medium, low
+	File        string
+	Line        int
+	Description string
+	Solution    string
+	Command     string // Weaver command to fix
+}
+
+// ProjectAnalyzer runs pattern-based checks over a project
+type ProjectAnalyzer struct {
+	root string
+}
+
+// NewProjectAnalyzer creates an analyzer rooted at path
+func NewProjectAnalyzer(root string) *ProjectAnalyzer {
+	return &ProjectAnalyzer{root: root}
+}
+
+// maxFileLines is the size above which a file is reported as too large
+const maxFileLines = 1000
+
+// GetSourceFiles returns the project's source files relative to the root.
+// Ignored, binary and generated files are left out.
+func (pa *ProjectAnalyzer) GetSourceFiles() ([]string, error) {
+	files, err := walker.Files(pa.root, walker.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source files: %w", err)
+	}
+
+	var sources []string
+	for _, file := range files {
+		if utils.DetectLanguage(file) != "unknown" {
+			sources = append(sources, file)
+		}
+	}
+	return sources, nil
+}
+
+var (
+	todoPattern  = regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK)\b`)
+	debugPattern = regexp.MustCompile(`\b(console\.log|var_dump|print_r|dd)\(`)
+)
+
+// DetectCommonIssues reports maintainability problems such as oversized files,
+// leftover debug statements and unresolved TODO markers
+func (pa *ProjectAnalyzer) DetectCommonIssues(files []string) []Issue {
+	var issues []Issue
+
+	for _, file := range files {
+		lines, err := pa.readLines(file)
+		if err != nil {
+			continue
+		}
+
+		if len(lines) > maxFileLines {
+			issues = append(issues, Issue{
+				Type:        "style",
+				Severity:    "medium",
+				File:        file,
+				Description: fmt.Sprintf("File has %d lines", len(lines)),
+				Solution:    "Split the file into smaller, focused units",
+			})
+		}
+
+		todos := 0
+		for i, line := range lines {
+			if todoPattern.MatchString(line) {
+				todos++
+			}
+			if debugPattern.MatchString(line) {
+				issues = append(issues, Issue{
+					Type:        "style",
+					Severity:    "low",
+					File:        file,
+					Line:        i + 1,
+					Description: "Debug statement left in code",
+					Solution:    "Remove the statement or use a logger",
+				})
+			}
+		}
+
+		if todos > 0 {
+			issues = append(issues, Issue{
+				Type:        "maintenance",
+				Severity:    "low",
+				File:        file,
+				Description: fmt.Sprintf("%d unresolved TODO/FIXME markers", todos),
+				Solution:    "Resolve the markers or track them as issues",
+			})
+		}
+	}
+
+	return issues
+}
+
+var securityPatterns = []struct {
+	pattern     *regexp.Regexp
+	severity    string
+	description string
+	solution    string
+}{
+	{
+		pattern:     regexp.MustCompile(`-----BEGIN (RSA |EC |OPENSSH |DSA )?PRIVATE KEY-----`),
+		severity:    "critical",
+		description: "Private key committed to the repository",
+		solution:    "Remove the key, rotate it and load it from a secret store",
+	},
+	{
+		pattern:     regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+		severity:    "critical",
+		description: "AWS access key in source",
+		solution:    "Remove the key, rotate it and read it from the environment",
+	},
+	{
+		pattern:     regexp.MustCompile(`(?i)\b(password|passwd|secret|api_?key|access_?token)\b\s*[:=]+\s*["'][^"']{4,}["']`),
+		severity:    "critical",
+		description: "Hardcoded credential",
+		solution:    "Read the value from configuration or the environment",
+	},
+	{
+		pattern:     regexp.MustCompile(`\beval\s*\(`),
+		severity:    "medium",
+		description: "Use of eval",
+		solution:    "Replace eval with explicit parsing or dispatch",
+	},
+}
+
+// SecurityScan looks for hardcoded secrets and dangerous calls
+func (pa *ProjectAnalyzer) SecurityScan(files []string) []Issue {
+	var issues []Issue
+
+	for _, file := range files {
+		lines, err := pa.readLines(file)
+		if err != nil {
+			continue
+		}
+
+		for i, line := range lines {
+			for _, check := range securityPatterns {
+				if check.pattern.MatchString(line) {
+					issues = append(issues, Issue{
+						Type:        "security",
+						Severity:    check.severity,
+						File:        file,
+						Line:        i + 1,
+						Description: check.description,
+						Solution:    check.solution,
+					})
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+func (pa *ProjectAnalyzer) readLines(file string) ([]string, error) {
+	f, err := os.Open(filepath.Join(pa.root, file))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,441 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ai"
+	projectcontext "github.com/snowsoft/codeweaver/internal/cli/context"
+	"github.com/snowsoft/codeweaver/internal/graph"
+	"github.com/snowsoft/codeweaver/internal/index"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	impactTargets   []string
+	impactDepth     int
+	impactJSON      bool
+	impactFilesOnly bool
+	impactBudget    int
+)
+
+// maxImpactRows is how many affected files are listed in the prompt and
+// the terminal; the JSON output has all of them
+const maxImpactRows = 60
+
+// AnalyzeImpactCmd estimates the impact of a change from the files it reaches
+var AnalyzeImpactCmd = &cobra.Command{
+	Use:   "analyze-impact <change>",
+	Short: "Analyze which files a change affects and how risky it is",
+	Long: `Analyze-impact works out which files a change reaches: the files and symbols
+it touches, the files that refer to those symbols and everything that imports
+them, directly or indirectly. That list, taken from the project's import graph,
+is sent to the AI for a risk and effort assessment.
+
+Targets are files, directories or symbols (Config.Load, path/to/file.go#Load).
+Without --target, files and symbols named in the change description are used,
+or else the code most related to it.`,
+	Example: `  weaver analyze-impact "make Load return an error" --target Config.Load
+  weaver analyze-impact "switch the HTTP client to retries" --target internal/ai/ollama
+  weaver analyze-impact "rename gatherContext to collectContext" --files-only
+  weaver analyze-impact "drop PHP 7 support" --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAnalyzeImpact,
+}
+
+func init() {
+	AnalyzeImpactCmd.Flags().StringSliceVarP(&impactTargets, "target", "t", nil, "Changed files, directories or symbols (file.go#Symbol)")
+	AnalyzeImpactCmd.Flags().IntVar(&impactDepth, "depth", 0, "Follow importers at most this many levels (0 for no limit)")
+	AnalyzeImpactCmd.Flags().BoolVar(&impactJSON, "json", false, "Print the affected files and assessment as JSON")
+	AnalyzeImpactCmd.Flags().BoolVar(&impactFilesOnly, "files-only", false, "Only list the affected files, without the AI assessment")
+	AnalyzeImpactCmd.Flags().IntVar(&impactBudget, "budget", 4000, "Token budget for the code sent to the AI")
+}
+
+// impactReport is the --json output
+type impactReport struct {
+	Change     string           `json:"change"`
+	Targets    []string         `json:"targets"`
+	Files      []graph.Affected `json:"files"`
+	Assessment string           `json:"assessment,omitempty"`
+	Unknown    []string         `json:"unknown_files,omitempty"` // files the assessment names that do not exist
+}
+
+func runAnalyzeImpact(cmd *cobra.Command, args []string) error {
+	change := args[0]
+	if impactJSON {
+		pterm.DisableOutput()
+		defer pterm.EnableOutput()
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root := index.FindRoot(cwd)
+	ctx := context.Background()
+
+	spinner, _ := pterm.DefaultSpinner.Start("Building the import graph...")
+	g, err := graph.Build(root)
+	if err != nil {
+		spinner.Fail(err.Error())
+		return fmt.Errorf("failed to build import graph: %w", err)
+	}
+
+	var targets []graph.Target
+	if len(impactTargets) > 0 {
+		targets, err = impactTargetsFromFlags(g, root, cwd, impactTargets)
+	} else {
+		targets = impactTargetsFromText(g, root, cwd, change)
+		if len(targets) == 0 {
+			spinner.UpdateText("Searching for the code the change is about...")
+			targets, err = impactTargetsFromSearch(ctx, g, root, change)
+		}
+	}
+	if err != nil {
+		spinner.Fail(err.Error())
+		return err
+	}
+	if len(targets) == 0 {
+		spinner.Fail("Nothing to analyze")
+		return fmt.Errorf("no files or symbols related to %q found; name them with --target", change)
+	}
+
+	spinner.UpdateText("Following imports and references...")
+	affected, err := g.Impact(targets, impactDepth)
+	if err != nil {
+		spinner.Fail(err.Error())
+		return err
+	}
+
+	report := impactReport{Change: change, Files: affected}
+	for _, t := range targets {
+		report.Targets = append(report.Targets, t.String())
+	}
+
+	if impactFilesOnly {
+		spinner.Success(fmt.Sprintf("%d files affected", len(affected)))
+	} else {
+		client, settings, err := newAIClient()
+		if err != nil {
+			spinner.Fail(fmt.Sprintf("Failed to initialize AI client: %v", err))
+			return err
+		}
+
+		spinner.UpdateText("Assessing risk and effort...")
+		promptConfig := ai.SystemPromptConfig{ModelType: "local", ContextWindow: settings.MaxTokens}
+		if settings.Provider != ai.ProviderOllama {
+			promptConfig.ModelType = "cloud"
+		}
+		builder := ai.NewPromptBuilder(promptConfig)
+		prompt := builder.BuildPrompt(ai.PromptTypeAnalyzeImpact, change, map[string]interface{}{
+			"project_path": root,
+			"affected":     affectedList(affected),
+			"snippets":     impactExcerpts(g, targets, affected, impactBudget),
+		})
+		prompt = builder.OptimizeForModel(prompt, settings.Model)
+
+		resp, err := client.Generate(ctx, ai.GenerateRequest{
+			Prompt:      prompt,
+			Model:       settings.Model,
+			Temperature: settings.Temperature,
+			MaxTokens:   settings.MaxTokens,
+		})
+		if err != nil {
+			spinner.Fail(fmt.Sprintf("Failed to analyze impact: %v", err))
+			return err
+		}
+		spinner.Success("Analysis complete")
+
+		report.Assessment = strings.TrimSpace(resp.Content)
+		report.Unknown = unknownFiles(g, root, cwd, report.Assessment)
+	}
+
+	if impactJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	displayImpact(report)
+	return nil
+}
+
+// impactTargetsFromFlags resolves --target values: a file or directory,
+// relative to the working directory or the root, optionally followed by
+// #Symbol, or a bare symbol name searched for in the whole project
+func impactTargetsFromFlags(g *graph.Graph, root, cwd string, values []string) ([]graph.Target, error) {
+	var targets []graph.Target
+	for _, value := range values {
+		p, symbol, hasSymbol := strings.Cut(value, "#")
+		rel := indexPaths(root, cwd, []string{p})[0]
+
+		if g.Contains(rel) {
+			targets = append(targets, graph.Target{Path: rel, Symbol: symbol})
+			continue
+		}
+		if hasSymbol {
+			return nil, fmt.Errorf("%s is not a source file or package in %s", p, root)
+		}
+
+		found := g.FindSymbol(value)
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no file, directory or symbol named %s in %s", value, root)
+		}
+		if len(found) > 1 {
+			pterm.Warning.Printf("%s is declared in %d files; analyzing all of them\n", value, len(found))
+		}
+		targets = append(targets, found...)
+	}
+	return targets, nil
+}
+
+var (
+	// mentionedPath matches things that look like file paths
+	mentionedPath = regexp.MustCompile(`[\w./-]*[\w-]\.[A-Za-z]\w*`)
+	// mentionedSymbol matches identifiers that are unlikely to be plain
+	// words: dotted, camelCase, PascalCase with several words, snake_case
+	mentionedSymbol = regexp.MustCompile(`\b[A-Za-z_]\w*\.[A-Za-z_]\w*\b|\b[a-z]+[A-Z]\w*\b|\b[A-Z][a-z0-9]+[A-Z]\w*\b|\b[a-z]+_[a-z_]+\b`)
+)
+
+// impactTargetsFromText picks the files and symbols a change description
+// names. Symbols declared in more than three files are too ambiguous to use.
+func impactTargetsFromText(g *graph.Graph, root, cwd, text string) []graph.Target {
+	var targets []graph.Target
+	seen := make(map[string]bool)
+	add := func(t graph.Target) {
+		if !seen[t.String()] {
+			seen[t.String()] = true
+			targets = append(targets, t)
+		}
+	}
+
+	for _, m := range mentionedPath.FindAllString(text, -1) {
+		if rel := indexPaths(root, cwd, []string{m})[0]; g.Contains(rel) {
+			add(graph.Target{Path: rel})
+		}
+	}
+	for _, word := range strings.Fields(text) {
+		word = strings.Trim(word, "`'\".,;:()")
+		if strings.Contains(word, "/") {
+			if rel := indexPaths(root, cwd, []string{word})[0]; g.Contains(rel) {
+				add(graph.Target{Path: rel})
+			}
+		}
+	}
+
+	for _, name := range mentionedSymbol.FindAllString(text, -1) {
+		if utils.DetectLanguage(name) != "unknown" {
+			continue // a file name
+		}
+		found := g.FindSymbol(name)
+		// pkg.Func in Go: the function, declared in a directory named pkg
+		if qualifier, member, ok := strings.Cut(name, "."); ok && len(found) == 0 {
+			for _, t := range g.FindSymbol(member) {
+				if path.Base(path.Dir(t.Path)) == qualifier {
+					found = append(found, t)
+				}
+			}
+		}
+		if len(found) > 0 && len(found) <= 3 {
+			for _, t := range found {
+				add(t)
+			}
+		}
+	}
+	return targets
+}
+
+// impactTargetsFromSearch falls back to the files whose code is most
+// related to the change description
+func impactTargetsFromSearch(ctx context.Context, g *graph.Graph, root, change string) ([]graph.Target, error) {
+	results, _, err := retrieve(ctx, root, change, index.QueryOptions{TopK: 10})
+	if err != nil {
+		return nil, err
+	}
+	var targets []graph.Target
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if _, ok := g.Files[r.Path]; ok && !seen[r.Path] && len(targets) < 5 {
+			seen[r.Path] = true
+			targets = append(targets, graph.Target{Path: r.Path})
+		}
+	}
+	if len(targets) > 0 {
+		pterm.Info.Printf("No files or symbols named in the change; starting from the %d files most related to it\n", len(targets))
+	}
+	return targets, nil
+}
+
+// distanceLabel describes how a file is affected
+func distanceLabel(distance int) string {
+	switch distance {
+	case 0:
+		return "changed"
+	case 1:
+		return "direct"
+	}
+	return fmt.Sprintf("indirect (%d)", distance)
+}
+
+// affectedList renders the affected files for the prompt
+func affectedList(affected []graph.Affected) string {
+	var b strings.Builder
+	for i, a := range affected {
+		if i == maxImpactRows {
+			fmt.Fprintf(&b, "... and %d more files that import these indirectly\n", len(affected)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s [%s] %s", a.Path, distanceLabel(a.Distance), a.Reason)
+		if a.Test {
+			b.WriteString(" (test)")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// impactExcerpts shows the changed symbols and the lines that use them,
+// within the token budget
+func impactExcerpts(g *graph.Graph, targets []graph.Target, affected []graph.Affected, budget int) string {
+	var b strings.Builder
+	fits := func(s string) bool {
+		if projectcontext.EstimateTokens(b.String()+s) > budget {
+			return false
+		}
+		b.WriteString(s)
+		return true
+	}
+
+	for _, t := range targets {
+		if t.Symbol == "" {
+			continue
+		}
+		for _, rel := range g.FindSymbol(t.Symbol) {
+			if rel.Path != t.Path && !strings.HasPrefix(rel.Path, t.Path+"/") {
+				continue
+			}
+			for _, s := range g.Symbols(rel.Path) {
+				if s.Name == rel.Symbol {
+					fits(fmt.Sprintf("\n%s:%d (%s %s):\n%s\n", rel.Path, s.StartLine, s.Kind, s.Name, s.Signature))
+				}
+			}
+		}
+	}
+
+	lines := make(map[string][]string)
+	for _, a := range affected {
+		if len(a.Lines) == 0 {
+			continue
+		}
+		if _, ok := lines[a.Path]; !ok {
+			content, err := os.ReadFile(filepath.Join(g.Root, filepath.FromSlash(a.Path)))
+			if err != nil {
+				continue
+			}
+			lines[a.Path] = strings.Split(string(content), "\n")
+		}
+		var refs strings.Builder
+		for i, n := range a.Lines {
+			if i == 5 {
+				fmt.Fprintf(&refs, "  ... %d more\n", len(a.Lines)-i)
+				break
+			}
+			if n <= len(lines[a.Path]) {
+				fmt.Fprintf(&refs, "%s:%d: %s\n", a.Path, n, strings.TrimSpace(lines[a.Path][n-1]))
+			}
+		}
+		if !fits(refs.String()) {
+			break
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// unknownFiles returns the file paths the assessment names that exist
+// neither in the project nor on disk
+func unknownFiles(g *graph.Graph, root, cwd, text string) []string {
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, m := range mentionedPath.FindAllString(text, -1) {
+		m = strings.Trim(m, "./")
+		if seen[m] || utils.DetectLanguage(m) == "unknown" {
+			continue
+		}
+		seen[m] = true
+		if _, ok := g.Files[m]; ok {
+			continue
+		}
+		if fileExists(filepath.Join(root, filepath.FromSlash(m))) || fileExists(filepath.Join(cwd, filepath.FromSlash(m))) {
+			continue
+		}
+		suffix := false
+		for rel := range g.Files {
+			if strings.HasSuffix(rel, "/"+m) {
+				suffix = true
+				break
+			}
+		}
+		if !suffix {
+			unknown = append(unknown, m)
+		}
+	}
+	return unknown
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
+
+// displayImpact prints the affected files and the assessment
+func displayImpact(report impactReport) {
+	counts := make(map[string]int)
+	tests := 0
+	for _, a := range report.Files {
+		switch {
+		case a.Distance == 0:
+			counts["changed"]++
+		case a.Distance == 1:
+			counts["direct"]++
+		default:
+			counts["indirect"]++
+		}
+		if a.Test {
+			tests++
+		}
+	}
+
+	pterm.DefaultSection.Printf("Affected files (%d)\n", len(report.Files))
+	pterm.Info.Printf("Targets: %s\n", strings.Join(report.Targets, ", "))
+	data := pterm.TableData{{"Impact", "File", "Reason"}}
+	for i, a := range report.Files {
+		if i == maxImpactRows {
+			break
+		}
+		file := a.Path
+		if a.Test {
+			file += " (test)"
+		}
+		data = append(data, []string{distanceLabel(a.Distance), file, a.Reason})
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+	if len(report.Files) > maxImpactRows {
+		pterm.Info.Printf("... and %d more; use --json for the full list\n", len(report.Files)-maxImpactRows)
+	}
+	pterm.Info.Printf("%d changed, %d direct, %d indirect, %d tests\n", counts["changed"], counts["direct"], counts["indirect"], tests)
+
+	if report.Assessment != "" {
+		pterm.DefaultSection.Println("Assessment")
+		fmt.Println(report.Assessment)
+	}
+	if len(report.Unknown) > 0 {
+		pterm.Warning.Printf("The assessment names files that do not exist: %s\n", strings.Join(report.Unknown, ", "))
+	}
+}
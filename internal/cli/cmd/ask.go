@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ai"
+	projectcontext "github.com/snowsoft/codeweaver/internal/cli/context"
+	"github.com/snowsoft/codeweaver/internal/index"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	askJSON   bool
+	askTopK   int
+	askPaths  []string
+	askLangs  []string
+	askBudget int
+)
+
+// AskCmd answers questions about the codebase
+var AskCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Ask a question about the codebase",
+	Long: `Ask finds the code most relevant to a question and has the AI answer from
+it, citing file:line locations. Relevant code is retrieved from the semantic
+index when one exists (see 'weaver index'), otherwise by keyword search.
+Every citation in the answer is checked against the files on disk.`,
+	Example: `  weaver ask "where is the config file loaded?"
+  weaver ask "how are backups named" --path internal/cli
+  weaver ask "which providers support streaming" --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAsk,
+}
+
+func init() {
+	AskCmd.Flags().BoolVar(&askJSON, "json", false, "Print the answer, sources and citations as JSON")
+	AskCmd.Flags().IntVarP(&askTopK, "top", "k", 8, "Number of code excerpts to retrieve")
+	AskCmd.Flags().StringSliceVar(&askPaths, "path", nil, "Only search these directories, files or globs")
+	AskCmd.Flags().StringSliceVar(&askLangs, "lang", nil, "Only search these languages")
+	AskCmd.Flags().IntVar(&askBudget, "budget", 6000, "Token budget for the code excerpts sent to the AI")
+}
+
+// askSource is a code excerpt the answer was based on
+type askSource struct {
+	Path      string  `json:"path"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Symbol    string  `json:"symbol,omitempty"`
+	Score     float64 `json:"score"`
+}
+
+// askCitation is a file:line reference found in the answer
+type askCitation struct {
+	Text    string `json:"text"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line,omitempty"`
+	Valid   bool   `json:"valid"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// askResult is the --json output
+type askResult struct {
+	Question  string        `json:"question"`
+	Answer    string        `json:"answer"`
+	Retrieval string        `json:"retrieval"` // "index" or "lexical"
+	Sources   []askSource   `json:"sources"`
+	Citations []askCitation `json:"citations"`
+}
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	question := args[0]
+	if askJSON {
+		// Keep stdout machine-readable
+		pterm.DisableOutput()
+		defer pterm.EnableOutput()
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root := index.FindRoot(cwd)
+	ctx := context.Background()
+
+	spinner, _ := pterm.DefaultSpinner.Start("Searching the codebase...")
+	results, retrieval, err := retrieve(ctx, root, question, index.QueryOptions{
+		TopK:      askTopK,
+		Paths:     indexPaths(root, cwd, askPaths),
+		Languages: askLangs,
+	})
+	if err != nil {
+		spinner.Fail(err.Error())
+		return err
+	}
+	if len(results) == 0 {
+		spinner.Fail("No related code found")
+		return fmt.Errorf("no code related to %q found in %s", question, root)
+	}
+
+	snippets, sources := askExcerpts(root, results, askBudget)
+
+	client, settings, err := newAIClient()
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to initialize AI client: %v", err))
+		return err
+	}
+
+	spinner.UpdateText("Thinking...")
+	promptConfig := ai.SystemPromptConfig{ModelType: "local", ContextWindow: settings.MaxTokens}
+	if settings.Provider != ai.ProviderOllama {
+		promptConfig.ModelType = "cloud"
+	}
+	builder := ai.NewPromptBuilder(promptConfig)
+	prompt := builder.BuildPrompt(ai.PromptTypeAsk, question, map[string]interface{}{
+		"project_path": root,
+		"snippets":     snippets,
+	})
+	prompt = builder.OptimizeForModel(prompt, settings.Model)
+
+	resp, err := client.Generate(ctx, ai.GenerateRequest{
+		Prompt:      prompt,
+		Model:       settings.Model,
+		Temperature: settings.Temperature,
+		MaxTokens:   settings.MaxTokens,
+	})
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to answer: %v", err))
+		return err
+	}
+	spinner.Success("Done")
+
+	answer := strings.TrimSpace(resp.Content)
+	result := askResult{
+		Question:  question,
+		Answer:    answer,
+		Retrieval: retrieval,
+		Sources:   sources,
+		Citations: checkCitations(answer, root, cwd, sources),
+	}
+
+	if askJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	displayAnswer(result)
+	return nil
+}
+
+// retrieve finds the chunks most related to question, from the index when
+// there is one and keyword search otherwise
+func retrieve(ctx context.Context, root, question string, opts index.QueryOptions) ([]index.Result, string, error) {
+	ix, err := index.Open(root)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if ix.Files() > 0 {
+		embedder, err := newEmbedder()
+		if err == nil {
+			var results []index.Result
+			results, err = ix.Query(ctx, embedder, question, opts)
+			if err == nil {
+				return results, "index", nil
+			}
+		}
+		pterm.Warning.Printf("Index search failed, falling back to keyword search: %v\n", err)
+	} else {
+		pterm.Info.Println("No index found, using keyword search (run 'weaver index' for better results)")
+	}
+
+	results, err := index.Lexical(root, question, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search %s: %w", root, err)
+	}
+	return results, "lexical", nil
+}
+
+// askExcerpts reads the current lines of each result from disk, numbered so
+// the AI can cite them, until the token budget is used up
+func askExcerpts(root string, results []index.Result, budget int) (string, []askSource) {
+	var b strings.Builder
+	var sources []askSource
+	lines := make(map[string][]string)
+
+	for _, r := range results {
+		fileLines, ok := lines[r.Path]
+		if !ok {
+			content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(r.Path)))
+			if err != nil {
+				continue
+			}
+			fileLines = strings.Split(string(content), "\n")
+			lines[r.Path] = fileLines
+		}
+
+		start, end := r.StartLine, r.EndLine
+		if end > len(fileLines) {
+			end = len(fileLines)
+		}
+		if start < 1 || start > end {
+			continue
+		}
+
+		var excerpt strings.Builder
+		fmt.Fprintf(&excerpt, "\n%s (lines %d-%d):\n```%s\n", r.Path, start, end, r.Language)
+		for n := start; n <= end; n++ {
+			fmt.Fprintf(&excerpt, "%d| %s\n", n, fileLines[n-1])
+		}
+		excerpt.WriteString("```\n")
+
+		if projectcontext.EstimateTokens(b.String()+excerpt.String()) > budget && len(sources) > 0 {
+			break
+		}
+		b.WriteString(excerpt.String())
+		sources = append(sources, askSource{Path: r.Path, StartLine: start, EndLine: end, Symbol: r.Symbol, Score: r.Score})
+	}
+
+	return b.String(), sources
+}
+
+// citation matches path:line and path:start-end references
+var citation = regexp.MustCompile(`([\w./-]*[\w-]\.[A-Za-z]\w*):(\d+)(?:[-–](\d+))?`)
+
+// checkCitations finds the file:line references in answer and checks that
+// each file exists and has those lines
+func checkCitations(answer, root, cwd string, sources []askSource) []askCitation {
+	var citations []askCitation
+	seen := make(map[string]bool)
+
+	for _, m := range citation.FindAllStringSubmatch(answer, -1) {
+		if seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+
+		c := askCitation{Text: m[0], Path: m[1]}
+		c.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			c.EndLine, _ = strconv.Atoi(m[3])
+		}
+
+		file, rel, ok := resolveCitation(m[1], root, cwd, sources)
+		if !ok {
+			// Things like "example.com:443" are not code references
+			if utils.DetectLanguage(m[1]) == "unknown" {
+				continue
+			}
+			c.Problem = "file not found"
+			citations = append(citations, c)
+			continue
+		}
+		c.Path = rel
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			c.Problem = err.Error()
+			citations = append(citations, c)
+			continue
+		}
+		count := len(strings.Split(strings.TrimRight(string(content), "\n"), "\n"))
+		last := c.Line
+		if c.EndLine != 0 {
+			last = c.EndLine
+		}
+		switch {
+		case c.Line < 1 || last < c.Line:
+			c.Problem = "invalid line range"
+		case last > count:
+			c.Problem = fmt.Sprintf("file has only %d lines", count)
+		default:
+			c.Valid = true
+		}
+		citations = append(citations, c)
+	}
+
+	return citations
+}
+
+// resolveCitation finds the file a cited path refers to: relative to the
+// project root, the working directory, or the end of a retrieved path
+func resolveCitation(cited, root, cwd string, sources []askSource) (string, string, bool) {
+	for _, base := range []string{root, cwd} {
+		file := filepath.Join(base, filepath.FromSlash(cited))
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				rel = cited
+			}
+			return file, filepath.ToSlash(rel), true
+		}
+	}
+	for _, s := range sources {
+		if strings.HasSuffix(s.Path, "/"+cited) {
+			return filepath.Join(root, filepath.FromSlash(s.Path)), s.Path, true
+		}
+	}
+	return "", "", false
+}
+
+// displayAnswer prints the answer with its sources and citation check
+func displayAnswer(result askResult) {
+	pterm.DefaultSection.Println("Answer")
+	fmt.Println(result.Answer)
+
+	pterm.DefaultSection.Println("Sources")
+	data := pterm.TableData{{"Location", "Symbol"}}
+	for _, s := range result.Sources {
+		data = append(data, []string{fmt.Sprintf("%s:%d-%d", s.Path, s.StartLine, s.EndLine), s.Symbol})
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+	pterm.Info.Printf("Retrieved by %s search\n", result.Retrieval)
+
+	var invalid []askCitation
+	for _, c := range result.Citations {
+		if !c.Valid {
+			invalid = append(invalid, c)
+		}
+	}
+	switch {
+	case len(result.Citations) == 0:
+		pterm.Warning.Println("The answer cites no file:line locations")
+	case len(invalid) == 0:
+		pterm.Success.Printf("All %d citations point to existing lines\n", len(result.Citations))
+	default:
+		pterm.Warning.Printf("%d of %d citations could not be verified:\n", len(invalid), len(result.Citations))
+		for _, c := range invalid {
+			pterm.Printf("  %s: %s\n", c.Text, c.Problem)
+		}
+	}
+}
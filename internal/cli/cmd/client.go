@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ai"
+	_ "github.com/snowsoft/codeweaver/internal/ai/ollama" // register the Ollama provider
+	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/extract"
+)
+
+// aiSettings resolves the provider settings for the current run from the
+// shared configuration. Global flags are already merged into it by viper.
+//
+// Values set under ai.* (which the global flags write to) take precedence over
+// the provider section, which in turn takes precedence over the defaults of
+// ai.temperature and ai.max_tokens. The exception is ai.default_model from a
+// config file, which only names the model of a provider that has none.
+// The API key may be a file: or cmd: secret reference, which is resolved here.
+func aiSettings() (ai.Config, error) {
+	cfg := config.Get()
+
+	name := cfg.AI.DefaultProvider
+	if name == "" {
+		name = string(ai.ProviderOllama)
+	}
+	providerConfig := cfg.Providers[name]
+
+	settings := ai.Config{
+		Provider:    ai.Provider(name),
+		APIKey:      providerConfig.APIKey,
+		APIURL:      providerConfig.APIURL,
+		Model:       providerConfig.Model,
+		Temperature: cfg.AI.Temperature,
+		MaxTokens:   cfg.AI.MaxTokens,
+		Timeout:     providerConfig.Timeout,
+	}
+
+	// --model and its environment variable always apply; a default_model
+	// from a config file only when the provider has no model of its own
+	if source := config.SourceOf("ai.default_model"); cfg.AI.DefaultModel != "" &&
+		(source == config.SourceFlag || source == config.SourceEnv || settings.Model == "") {
+		settings.Model = cfg.AI.DefaultModel
+	}
+	if !isSet("ai.temperature") && isSet("providers."+name+".temperature") {
+		settings.Temperature = providerConfig.Temperature
+	}
+	if !isSet("ai.max_tokens") && isSet("providers."+name+".max_tokens") {
+		settings.MaxTokens = providerConfig.MaxTokens
+	}
+	if cfg.AI.Timeout != 0 {
+		settings.Timeout = cfg.AI.Timeout
+	}
+
+	// Resolve aliases such as "fast" or "quality"
+	if alias, ok := cfg.Models[settings.Model]; ok {
+		settings.Model = alias
+	}
+
+	apiKey, err := config.ResolveSecret(fmt.Sprintf("providers.%s.api_key", name), settings.APIKey)
+	if err != nil {
+		return settings, err
+	}
+	settings.APIKey = apiKey
+
+	return settings, nil
+}
+
+// isSet reports whether key was set rather than left at its default, so
+// that an explicit zero is kept
+func isSet(key string) bool {
+	return config.SourceOf(key) != config.SourceDefault
+}
+
+// newAIClient creates the configured AI provider
+func newAIClient() (ai.AIProvider, ai.Config, error) {
+	settings, err := aiSettings()
+	if err != nil {
+		return nil, settings, err
+	}
+	client, err := ai.NewProvider(settings)
+	if err != nil {
+		return nil, settings, err
+	}
+	return client, settings, nil
+}
+
+// generateCode sends prompt to the provider and returns its response
+func generateCode(ctx context.Context, client ai.AIProvider, settings ai.Config, prompt string) (string, error) {
+	resp, err := client.Generate(ctx, ai.GenerateRequest{
+		Prompt:      prompt,
+		Model:       settings.Model,
+		Temperature: settings.Temperature,
+		MaxTokens:   settings.MaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+// extractCode returns the code for fileName in a model response, noting
+// the other files the response holds, which are not written
+func extractCode(response, fileName string) string {
+	var others []string
+	for _, f := range extract.Files(response) {
+		if !extract.Matches(f.Path, fileName) {
+			others = append(others, f.Path)
+		}
+	}
+	if len(others) > 0 {
+		pterm.Info.Printf("The response also contains %s; only %s is used\n", strings.Join(others, ", "), fileName)
+	}
+	return extract.Code(response, fileName)
+}
@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigCmd groups the configuration subcommands
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the Weaver configuration",
+	Long:  `Inspect and maintain the Weaver configuration file.`,
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite an old-layout config file in the current schema",
+	Long: `Rewrite the config file in the current schema version.
+
+The original file is kept next to it as a timestamped .bak file. Keys that have
+no place in the current schema are reported and only remain in the backup.
+
+Examples:
+  weaver config migrate
+  weaver config migrate --config ./weaver.yaml`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Long: `Print the effective value of a setting, after defaults, the config file,
+environment variables and flags have been applied.
+
+Examples:
+  weaver config get providers.ollama.model
+  weaver config get providers.ollama`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the config file",
+	Long: `Set a value in the config file. Comments and key order are kept.
+
+The value is read as YAML, so numbers and booleans keep their type. With
+--project the value is written to the project's .weaver.yml instead, which is
+created in the current directory if none is found.
+
+Examples:
+  weaver config set providers.ollama.model codellama:7b
+  weaver config set ai.timeout 300s
+  weaver config set --project languages.javascript.test_framework vitest`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a value from the config file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List effective settings and where they come from",
+	Long: `List every effective setting together with its source: default, file,
+project (.weaver.yml), env (WEAVER_* variables) or flag.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigList,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $EDITOR",
+	Long: `Open the config file in $EDITOR. The edited file is validated before it is
+saved; an invalid file can be edited again or discarded.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a config file against the schema",
+	Long: `Check a config file against the schema. Without an argument the user
+config file and the project's .weaver.yml, if any, are checked.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigValidate,
+}
+
+var projectConfig bool
+
+func init() {
+	for _, c := range []*cobra.Command{configSetCmd, configUnsetCmd, configEditCmd} {
+		c.Flags().BoolVar(&projectConfig, "project", false, "Use the project's "+config.ProjectFileName+" instead of the user config")
+	}
+
+	ConfigCmd.AddCommand(configGetCmd)
+	ConfigCmd.AddCommand(configSetCmd)
+	ConfigCmd.AddCommand(configUnsetCmd)
+	ConfigCmd.AddCommand(configListCmd)
+	ConfigCmd.AddCommand(configEditCmd)
+	ConfigCmd.AddCommand(configValidateCmd)
+	ConfigCmd.AddCommand(configMigrateCmd)
+}
+
+// configPath returns the config file in use
+func configPath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	return config.DefaultPath()
+}
+
+// configKey resolves a key given in an older layout to its current name
+func configKey(key string) string {
+	current := config.LegacyKey(key)
+	if current != key {
+		pterm.Info.Printf("%s is now %s\n", key, current)
+	}
+	return current
+}
+
+// projectConfigPath returns the project's .weaver.yml, or where a new one
+// would be created
+func projectConfigPath() (string, error) {
+	if project := config.Project(); project != nil {
+		return project.Path, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if path, ok := config.FindProjectFile(dir); ok {
+		return path, nil
+	}
+	return filepath.Join(dir, config.ProjectFileName), nil
+}
+
+// validateConfig checks data against the rules for the file at path
+func validateConfig(path string, data []byte) []error {
+	if filepath.Base(path) == config.ProjectFileName {
+		return config.ValidateProject(data)
+	}
+	return config.Validate(data)
+}
+
+// openConfigDocument reads the config file for editing. Files in an older
+// layout have to be migrated first, otherwise old and new keys would mix.
+func openConfigDocument() (*config.Document, error) {
+	if projectConfig {
+		path, err := projectConfigPath()
+		if err != nil {
+			return nil, err
+		}
+		return config.ReadDocument(path)
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	if legacy := config.Legacy(); legacy != nil && legacy.Path == path {
+		return nil, fmt.Errorf("%s uses an old config layout; run 'weaver config migrate' first", path)
+	}
+	return config.ReadDocument(path)
+}
+
+// saveConfigDocument validates doc and writes it back
+func saveConfigDocument(doc *config.Document) error {
+	data, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+	if errs := validateConfig(doc.Path(), data); len(errs) > 0 {
+		printValidationErrors(errs)
+		return fmt.Errorf("%s was not changed", doc.Path())
+	}
+	return doc.Save()
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	key := configKey(args[0])
+	if !viper.IsSet(key) {
+		return fmt.Errorf("%s is not set", key)
+	}
+
+	switch value := viper.Get(key).(type) {
+	case map[string]interface{}:
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+	default:
+		fmt.Println(config.ExpandSetting(key, fmt.Sprint(value)))
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key := configKey(args[0])
+
+	doc, err := openConfigDocument()
+	if err != nil {
+		return err
+	}
+	if err := doc.Set(key, args[1]); err != nil {
+		return err
+	}
+	if err := saveConfigDocument(doc); err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Set %s = %s\n", key, args[1])
+	if source := config.SourceOf(key); source == config.SourceEnv || source == config.SourceFlag {
+		pterm.Warning.Printf("%s is currently overridden by %s\n", key, source)
+	}
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	key := configKey(args[0])
+
+	doc, err := openConfigDocument()
+	if err != nil {
+		return err
+	}
+	if !doc.Unset(key) {
+		return fmt.Errorf("%s is not set in %s", key, doc.Path())
+	}
+	if err := saveConfigDocument(doc); err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Removed %s\n", key)
+	return nil
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	var keys []string
+	for _, key := range viper.AllKeys() {
+		// Old-layout keys are listed under their current name
+		if config.LegacyKey(key) == key {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	data := pterm.TableData{{"Key", "Value", "Source"}}
+	for _, key := range keys {
+		value := config.ExpandSetting(key, fmt.Sprint(viper.Get(key)))
+		if strings.HasSuffix(key, "api_key") && value != "" {
+			value = "********"
+		}
+		data = append(data, []string{key, value, string(config.SourceOf(key))})
+	}
+
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	doc, err := openConfigDocument()
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(doc.Path())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content := string(original)
+	for {
+		edited, err := ui.OpenEditor(content)
+		if err != nil {
+			return err
+		}
+		if edited == string(original) {
+			pterm.Info.Println("No changes made")
+			return nil
+		}
+
+		errs := validateConfig(doc.Path(), []byte(edited))
+		if len(errs) == 0 {
+			info, statErr := os.Stat(doc.Path())
+			mode := os.FileMode(0644)
+			if statErr == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.WriteFile(doc.Path(), []byte(edited), mode); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			pterm.Success.Printf("Saved %s\n", doc.Path())
+			return nil
+		}
+
+		printValidationErrors(errs)
+		if !ui.ConfirmAction("Edit again?") {
+			return fmt.Errorf("%s was not changed", doc.Path())
+		}
+		content = edited
+	}
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	var paths []string
+	if len(args) > 0 {
+		paths = append(paths, args[0])
+	} else {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		if project := config.Project(); project != nil {
+			paths = append(paths, project.Path)
+		}
+	}
+
+	var invalid []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		if errs := validateConfig(path, data); len(errs) > 0 {
+			pterm.Error.Printf("%s:\n", path)
+			for _, err := range errs {
+				fmt.Printf("  • %v\n", err)
+			}
+			invalid = append(invalid, path)
+			continue
+		}
+		pterm.Success.Printf("%s is valid\n", path)
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s is not valid", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+func printValidationErrors(errs []error) {
+	pterm.Error.Println("Invalid configuration:")
+	for _, err := range errs {
+		fmt.Printf("  • %v\n", err)
+	}
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	result, err := config.MigrateFile(path)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		pterm.Info.Printf("%s already uses config version %d\n", path, config.CurrentVersion)
+		return nil
+	}
+
+	pterm.Success.Printf("Migrated %s to config version %d\n", result.Path, config.CurrentVersion)
+	pterm.Info.Printf("Backup saved to %s\n", result.BackupPath)
+
+	if len(result.Unmapped) > 0 {
+		pterm.Warning.Println("These keys could not be mapped and were left out:")
+		for _, key := range result.Unmapped {
+			fmt.Printf("  • %s\n", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	projectcontext "github.com/snowsoft/codeweaver/internal/cli/context"
+	"github.com/snowsoft/codeweaver/internal/config"
+)
+
+// contextBudget is the token budget shared by everything added to the
+// prompt: files from --context-dir, @references and git context
+var contextBudget int
+
+
+// withDiff and withHistory add git context for the target
+var (
+	withDiff    bool
+	withHistory bool
+)
+
+// gatherContext collects the files under --context-dir that relate to
+// target and reports which were chosen and why. It returns nil when no
+// context directory was given.
+func gatherContext(target string, budget *tokenBudget) (*projectcontext.Result, error) {
+	if contextDir == "" {
+		return nil, nil
+	}
+	remaining := budget.remaining()
+	if remaining == 0 {
+		pterm.Warning.Println("The context budget is used up; no files from --context-dir are included (raise it with --context-budget)")
+		return nil, nil
+	}
+
+	result, err := projectcontext.Gather(target, projectcontext.Options{
+		Dir:       contextDir,
+		Depth:     config.ForPath(target).Defaults.ContextDepth,
+		MaxTokens: remaining,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather context: %w", err)
+	}
+	budget.used += result.Tokens
+
+	printContextReport(result, budget)
+	return result, nil
+}
+
+// printContextReport lists the files included in the context
+func printContextReport(result *projectcontext.Result, budget *tokenBudget) {
+	if len(result.Files) == 0 {
+		pterm.Info.Printf("No related files found in %s\n", contextDir)
+		return
+	}
+
+	pterm.DefaultSection.Println("Context")
+	data := pterm.TableData{{"File", "Included", "Tokens", "Why"}}
+	for _, file := range result.Files {
+		data = append(data, []string{file.Path, file.Mode, fmt.Sprint(file.Tokens), strings.Join(file.Reasons, ", ")})
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+
+	pterm.Info.Printf("Context uses ~%d tokens; %s\n", result.Tokens, budget)
+	if result.Skipped > 0 {
+		pterm.Warning.Printf("%d more related files did not fit the budget (raise it with --context-budget)\n", result.Skipped)
+	}
+}
+
+// attachReferences resolves the @file, @symbol and @dir references in task
+// and reports what was attached. The references get what is left of the
+// context budget.
+func attachReferences(task string, budget *tokenBudget) (*projectcontext.Attachments, error) {
+	if len(projectcontext.ParseReferences(task)) == 0 {
+		return nil, nil
+	}
+	remaining := budget.remaining()
+	if remaining == 0 {
+		pterm.Warning.Println("The context budget is used up; the @references in the task are not attached (raise it with --context-budget)")
+		return nil, nil
+	}
+
+	attachments, err := projectcontext.ResolveReferences(task, projectcontext.ReferenceOptions{
+		Dir:       ".",
+		MaxTokens: remaining,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve references: %w", err)
+	}
+	budget.used += attachments.Tokens
+	if len(attachments.References) > 0 {
+		printAttachments(attachments, budget)
+	}
+	return attachments, nil
+}
+
+// printAttachments lists the references attached to the prompt and the ones
+// that could not be
+func printAttachments(attachments *projectcontext.Attachments, budget *tokenBudget) {
+	if attached := attachments.Attached(); len(attached) > 0 {
+		pterm.DefaultSection.Println("Attached references")
+		data := pterm.TableData{{"Reference", "Resolved to", "Included", "Tokens"}}
+		for _, ref := range attached {
+			included := ref.Mode
+			if ref.Note != "" {
+				included += ", " + ref.Note
+			}
+			data = append(data, []string{ref.Text, ref.Location(), included, fmt.Sprint(ref.Tokens)})
+		}
+		pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+		pterm.Info.Printf("References use ~%d tokens; %s\n", attachments.Tokens, budget)
+	}
+
+	for _, ref := range attachments.References {
+		if ref.Err != nil {
+			pterm.Warning.Printf("%s was not attached: %v\n", ref.Text, ref.Err)
+		}
+	}
+}
+
+// gatherGitContext reads the uncommitted diff and the history of target
+// from git, as requested by --with-diff and --with-history, within what is
+// left of the context budget. Outside a repository it warns and returns nil.
+func gatherGitContext(target string, budget *tokenBudget) (*projectcontext.GitContext, error) {
+	if !withDiff && !withHistory {
+		return nil, nil
+	}
+	remaining := budget.remaining()
+	if remaining == 0 {
+		pterm.Warning.Println("The context budget is used up; --with-diff and --with-history add nothing (raise it with --context-budget)")
+		return nil, nil
+	}
+
+	g, err := projectcontext.Git(target, projectcontext.GitOptions{
+		Diff:      withDiff,
+		History:   withHistory,
+		MaxTokens: remaining,
+	})
+	if errors.Is(err, projectcontext.ErrNotRepository) {
+		pterm.Warning.Printf("%s is not in a git repository; --with-diff and --with-history are ignored\n", target)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git context: %w", err)
+	}
+	budget.used += g.Tokens
+
+	printGitContext(g, budget)
+	return g, nil
+}
+
+// printGitContext summarizes the git context included in the prompt
+func printGitContext(g *projectcontext.GitContext, budget *tokenBudget) {
+	pterm.DefaultSection.Println("Git context")
+	var data pterm.TableData
+	if withHistory {
+		data = append(data, []string{"Recent commits", fmt.Sprint(len(g.Commits))})
+		var files []string
+		for _, c := range g.CoChanged {
+			files = append(files, fmt.Sprintf("%s (%d)", c.Path, c.Count))
+		}
+		if len(files) == 0 {
+			files = []string{"none"}
+		}
+		data = append(data, []string{"Changed together with", strings.Join(files, "\n")})
+	}
+	if withDiff {
+		diff := "none"
+		switch {
+		case g.Diff == "" && g.DiffTruncated:
+			diff = fmt.Sprintf("%d files, left out (no budget left)", g.DiffFiles)
+		case g.Diff != "":
+			diff = fmt.Sprintf("%d files, %d lines", g.DiffFiles, strings.Count(g.Diff, "\n")+1)
+			if g.DiffTruncated {
+				diff += " (shortened)"
+			}
+		}
+		data = append(data, []string{"Uncommitted changes", diff})
+	}
+	pterm.DefaultTable.WithData(data).Render()
+	pterm.Info.Printf("Git context uses ~%d tokens; %s\n", g.Tokens, budget)
+}
+
+// tokenBudget is the context budget of one run, shared by the sources
+// added to its prompt
+type tokenBudget struct {
+	total int
+	used  int // taken by the sources gathered so far
+}
+
+// newTokenBudget returns the budget given by --context-budget, or the
+// default one, with nothing used yet
+func newTokenBudget() *tokenBudget {
+	total := contextBudget
+	if total <= 0 {
+		total = projectcontext.DefaultBudget
+	}
+	return &tokenBudget{total: total}
+}
+
+// remaining returns what is left of the budget, or 0 when it is used up
+func (b *tokenBudget) remaining() int {
+	return max(b.total-b.used, 0)
+}
+
+// String describes what is left of the budget
+func (b *tokenBudget) String() string {
+	return fmt.Sprintf("%d of the %d-token budget left", b.remaining(), b.total)
+}
@@ -3,18 +3,19 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/snowsoft/codeweaver/internal/ai"
-	"github.com/snowsoft/codeweaver/internal/ai/ollama"
+	"github.com/snowsoft/codeweaver/internal/extract"
+	"github.com/snowsoft/codeweaver/internal/stage"
+	"github.com/snowsoft/codeweaver/internal/textfile"
 )
 
 // CreateCmd represents the create command
@@ -58,9 +59,6 @@ var (
 )
 
 func init() {
-	CreateCmd.Flags().StringVar(&provider, "provider", "ollama", "AI provider")
-	CreateCmd.Flags().StringVar(&model, "model", "", "Specific model to use")
-	CreateCmd.Flags().Float64Var(&temperature, "temperature", 0.7, "Generation temperature")
 	CreateCmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "Skip confirmation prompts")
 	CreateCmd.Flags().IntVarP(&parallel, "parallel", "p", 3, "Number of files to generate in parallel")
 	CreateCmd.Flags().BoolVar(&noStream, "no-stream", false, "Disable streaming output")
@@ -75,26 +73,17 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	// Create AI client
 	spinner, _ := pterm.DefaultSpinner.Start("Analyzing your request...")
 	
-	config := ai.Config{
-		Provider:    ai.ProviderOllama,
-		APIURL:      "http://localhost:11434",
-		Model:       model,
-		Temperature: 0.3, // Lower temperature for planning
-		MaxTokens:   4000,
-		Timeout:     120 * time.Second,
-	}
-	
-	if model == "" {
-		config.Model = "codellama:13b-instruct"
+	client, config, err := newAIClient()
+	if err != nil {
+		spinner.Fail("Failed to create AI client")
+		return err
 	}
-	
-	client := ollama.NewClient(config)
 	ctx := context.Background()
 	
 	// Check connection
 	if err := client.HealthCheck(ctx); err != nil {
-		spinner.Fail("Failed to connect to Ollama")
-		return fmt.Errorf("ollama connection failed: %w", err)
+		spinner.Fail(fmt.Sprintf("Failed to connect to %s", config.Provider))
+		return fmt.Errorf("%s connection failed: %w", config.Provider, err)
 	}
 	
 	spinner.UpdateText("Planning project structure...")
@@ -105,7 +94,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	req := ai.GenerateRequest{
 		Prompt:      planPrompt,
 		Model:       config.Model,
-		Temperature: 0.3,
+		Temperature: 0.3, // Lower temperature for planning
 		MaxTokens:   2000,
 	}
 	
@@ -115,23 +104,18 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("planning failed: %w", err)
 	}
 	
-	// Parse the plan
+	// Parse the plan, which may be wrapped in prose or a fenced block
 	var plan ProjectPlan
-	if err := json.Unmarshal([]byte(planResp.Content), &plan); err != nil {
-		// Try to extract JSON from the response
-		jsonStart := strings.Index(planResp.Content, "{")
-		jsonEnd := strings.LastIndex(planResp.Content, "}")
-		if jsonStart >= 0 && jsonEnd > jsonStart {
-			jsonStr := planResp.Content[jsonStart : jsonEnd+1]
-			if err := json.Unmarshal([]byte(jsonStr), &plan); err != nil {
-				spinner.Fail("Failed to parse project plan")
-				return fmt.Errorf("could not parse AI response: %w", err)
-			}
-		} else {
-			spinner.Fail("Failed to parse project plan")
-			return fmt.Errorf("could not parse AI response: %w", err)
+	err = fmt.Errorf("no JSON object found")
+	for _, object := range extract.Objects(planResp.Content) {
+		if err = json.Unmarshal([]byte(object), &plan); err == nil {
+			break
 		}
 	}
+	if err != nil {
+		spinner.Fail("Failed to parse project plan")
+		return fmt.Errorf("could not parse AI response: %w", err)
+	}
 	
 	spinner.Success("Project plan created!")
 	
@@ -153,29 +137,112 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 	}
 	
-	// Create each file with parallel processing
-	pterm.DefaultSection.Println("Creating Files")
+	// Generate the files into a staging area, so that nothing is written
+	// unless the whole project can be
+	staged, err := stage.New(".")
+	if err != nil {
+		return err
+	}
+	defer staged.Discard()
 	
+	pterm.DefaultSection.Println("Generating Files")
+	pending := plan.Files
+	for {
+		failures := generateFiles(client, config, plan, pending, staged)
+		
+		pterm.DefaultSection.Println("Summary")
+		pterm.Success.Printf("Generated %d/%d files\n", staged.Len(), len(plan.Files))
+		if len(failures) == 0 {
+			break
+		}
+		
+		pterm.Warning.Println("\nFailed files:")
+		pending = nil
+		for _, fail := range failures {
+			pterm.Error.Printf("  • %s: %v\n", fail.Path, fail.Error)
+			for _, file := range plan.Files {
+				if file.Path == fail.Path {
+					pending = append(pending, file)
+				}
+			}
+		}
+		if skipConfirm {
+			return fmt.Errorf("%d of %d files could not be generated; no files were written", len(failures), len(plan.Files))
+		}
+		
+		choice := ""
+		writeOption := fmt.Sprintf("Write the %s generated so far", countFiles(staged.Len()))
+		survey.AskOne(&survey.Select{
+			Message: "Some files could not be generated. What would you like to do?",
+			Options: []string{"Retry the failed files", writeOption, "Cancel"},
+			Default: "Retry the failed files",
+		}, &choice)
+		
+		if choice == writeOption {
+			break
+		}
+		if choice != "Retry the failed files" {
+			pterm.Info.Println("Operation cancelled. No files were written.")
+			return nil
+		}
+	}
+	
+	if staged.Len() == 0 {
+		pterm.Warning.Println("No files were generated.")
+		return nil
+	}
+	if err := staged.Commit(recordChanges()); err != nil {
+		var recordErr *stage.RecordError
+		if !errors.As(err, &recordErr) {
+			return fmt.Errorf("failed to write the project, no files were changed: %w", err)
+		}
+		pterm.Warning.Println(recordErr)
+	}
+	pterm.Success.Printf("Created %s\n", countFiles(staged.Len()))
+	
+	// Show setup commands if any
+	if len(plan.Commands) > 0 {
+		pterm.DefaultSection.Println("Next Steps")
+		pterm.Info.Println("Run these commands to set up your project:")
+		for _, cmd := range plan.Commands {
+			fmt.Printf("  %s\n", cmd)
+		}
+	}
+	
+	return nil
+}
+
+// countFiles returns "1 file" or "n files"
+func countFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
+
+// generateFiles generates files in parallel into staged and returns the
+// files that failed
+func generateFiles(client ai.AIProvider, config ai.Config, plan ProjectPlan, files []FileToCreate, staged *stage.Stage) []FileResult {
 	// Progress bar
 	progressbar, _ := pterm.DefaultProgressbar.
-		WithTotal(len(plan.Files)).
-		WithTitle("Creating files").
+		WithTotal(len(files)).
+		WithTitle("Generating files").
 		WithShowElapsedTime().
 		Start()
 	
 	// Create channels for work distribution
-	jobs := make(chan FileToCreate, len(plan.Files))
-	results := make(chan FileResult, len(plan.Files))
+	jobs := make(chan FileToCreate, len(files))
+	results := make(chan FileResult, len(files))
 	
 	// Start workers
 	var wg sync.WaitGroup
 	for w := 0; w < parallel; w++ {
 		wg.Add(1)
-		go fileWorker(w, client, config, plan, jobs, results, &wg, progressbar)
+		go fileWorker(w, client, config, plan, staged, jobs, results, &wg, progressbar)
 	}
 	
 	// Send jobs
-	for _, file := range plan.Files {
+	for _, file := range files {
 		jobs <- file
 	}
 	close(jobs)
@@ -187,44 +254,18 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	}()
 	
 	// Collect results
-	successCount := 0
 	var failures []FileResult
-	
 	for result := range results {
-		if result.Success {
-			successCount++
-		} else {
+		if !result.Success {
 			failures = append(failures, result)
 		}
 	}
 	
 	progressbar.Stop()
-	
-	// Summary
-	pterm.DefaultSection.Println("Summary")
-	pterm.Success.Printf("Successfully created %d/%d files\n", successCount, len(plan.Files))
-	
-	// Show failures if any
-	if len(failures) > 0 {
-		pterm.Warning.Println("\nFailed files:")
-		for _, fail := range failures {
-			pterm.Error.Printf("  • %s: %v\n", fail.Path, fail.Error)
-		}
-	}
-	
-	// Show setup commands if any
-	if len(plan.Commands) > 0 {
-		pterm.DefaultSection.Println("Next Steps")
-		pterm.Info.Println("Run these commands to set up your project:")
-		for _, cmd := range plan.Commands {
-			fmt.Printf("  %s\n", cmd)
-		}
-	}
-	
-	return nil
+	return failures
 }
 
-func fileWorker(id int, client *ollama.Client, config ai.Config, plan ProjectPlan,
+func fileWorker(id int, client ai.AIProvider, config ai.Config, plan ProjectPlan, staged *stage.Stage,
 	jobs <-chan FileToCreate, results chan<- FileResult, wg *sync.WaitGroup, 
 	progressbar *pterm.ProgressbarPrinter) {
 	
@@ -233,7 +274,7 @@ func fileWorker(id int, client *ollama.Client, config ai.Config, plan ProjectPla
 	
 	for file := range jobs {
 		// Update progress bar title
-		progressbar.UpdateTitle(fmt.Sprintf("Worker %d: Creating %s", id+1, file.Path))
+		progressbar.UpdateTitle(fmt.Sprintf("Worker %d: Generating %s", id+1, file.Path))
 		
 		// Generate file content
 		filePrompt := buildFilePrompt(file, plan)
@@ -241,7 +282,7 @@ func fileWorker(id int, client *ollama.Client, config ai.Config, plan ProjectPla
 		req := ai.GenerateRequest{
 			Prompt:      filePrompt,
 			Model:       config.Model,
-			Temperature: temperature,
+			Temperature: config.Temperature,
 			MaxTokens:   3000,
 		}
 		
@@ -253,7 +294,8 @@ func fileWorker(id int, client *ollama.Client, config ai.Config, plan ProjectPla
 			// Show which file is being streamed
 			pterm.FgCyan.Printf("\n[Streaming] %s:\n", file.Path)
 			
-			streamCh, err := client.GenerateStream(ctx, req)
+			var streamCh <-chan ai.StreamChunk
+			streamCh, err = client.GenerateStream(ctx, req)
 			if err == nil {
 				var fullContent strings.Builder
 				for chunk := range streamCh {
@@ -287,14 +329,15 @@ func fileWorker(id int, client *ollama.Client, config ai.Config, plan ProjectPla
 			continue
 		}
 		
-		// Create directory if needed
-		dir := filepath.Dir(file.Path)
-		if dir != "." && dir != "" {
-			os.MkdirAll(dir, 0755)
-		}
+		content = extract.Code(content, file.Path)
 		
-		// Write file
-		if err := os.WriteFile(file.Path, []byte(content), 0644); err != nil {
+		// Stage the file in the style of any file it replaces; it is
+		// written with the others at the end
+		style, err := textfile.StyleOf(file.Path, textfile.DefaultMode)
+		if err == nil {
+			err = staged.Add(file.Path, textfile.Encode(content, style), style.Mode)
+		}
+		if err != nil {
 			results <- FileResult{
 				Path:    file.Path,
 				Success: false,
@@ -8,7 +8,6 @@ import (
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/snowsoft/codeweaver/internal/ai"
-	"github.com/snowsoft/codeweaver/internal/ai/ollama"
 )
 
 // DoctorCmd checks system configuration
@@ -25,14 +24,19 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	// Check Ollama connection
 	spinner, _ := pterm.DefaultSpinner.Start("Checking Ollama connection...")
 	
-	// Create Ollama client with default config
-	config := ai.Config{
-		Provider: ai.ProviderOllama,
-		APIURL:   "http://localhost:11434",
-		Timeout:  10 * time.Second,
+	// Create client from the shared configuration
+	config, err := aiSettings()
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("AI provider: FAILED - %v", err))
+		return nil
 	}
+	config.Timeout = 10 * time.Second
 	
-	client := ollama.NewClient(config)
+	client, err := ai.NewProvider(config)
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("AI provider: FAILED - %v", err))
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/diff"
+	"github.com/snowsoft/codeweaver/internal/ui"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/snowsoft/codeweaver/internal/config"
+)
+
+var documentStyle string
+
+// DocumentCmd represents the document command
+var DocumentCmd = &cobra.Command{
+	Use:   "document <file_name>",
+	Short: "Add documentation to code",
+	Long: `Analyze code and add appropriate documentation comments.
+    
+The tool will detect the programming language and add documentation
+in the appropriate style (JSDoc, PHPDoc, GoDoc, etc.).`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDocument,
+}
+
+func init() {
+	DocumentCmd.Flags().StringVarP(&documentStyle, "style", "s", "", "Documentation style (jsdoc, phpdoc, godoc, etc.)")
+	DocumentCmd.Flags().BoolVar(&wholeFile, "whole-file", false, "Ask for the whole documented file instead of edits")
+}
+
+func runDocument(cmd *cobra.Command, args []string) error {
+	fileName := args[0]
+
+	// Check if file exists
+	if !utils.FileExists(fileName) {
+		return fmt.Errorf("file %s does not exist", fileName)
+	}
+
+	// Create spinner
+	spinner, err := ui.StartSpinner("Analyzing code structure...")
+	if err != nil {
+		// Continue without spinner
+		ui.PrintWarning("Failed to start spinner")
+	}
+
+	// Read original file
+	originalCode, err := readFile(fileName)
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to read file: %v", err))
+		return err
+	}
+
+	// Detect language
+	language := utils.DetectLanguage(fileName)
+
+	// Determine documentation style
+	if documentStyle == "" {
+		documentStyle = getDefaultDocStyle(fileName, language)
+	}
+
+	spinner.UpdateText("Generating documentation...")
+
+	// Initialize AI client
+	client, settings, err := newAIClient()
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to initialize AI client: %v", err))
+		return err
+	}
+
+	// Build prompt
+	prompt := buildDocumentPrompt(language, documentStyle, originalCode)
+
+	// Generate documented code
+	documentedCode, err := generateCode(cmd.Context(), client, settings, prompt)
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to generate documentation: %v", err))
+		return err
+	}
+
+	spinner.Success("Documentation generated successfully!")
+
+	if wholeFile {
+		documentedCode = extractCode(documentedCode, fileName)
+	} else {
+		documentedCode, err = applyEdits(originalCode, documentedCode)
+		if err != nil {
+			return err
+		}
+	}
+	documentedCode, check, repairs := checkAndRepair(cmd.Context(), client, settings, fileName, documentedCode)
+	documentedCode = formatCode(cmd.Context(), fileName, originalCode, documentedCode)
+
+	// Show diff
+	diffViewer := diff.NewViewer()
+	diffOutput := diffViewer.GenerateDiff(originalCode, documentedCode, fileName)
+
+	pterm.DefaultHeader.Println("Proposed Documentation")
+	fmt.Println(diffOutput)
+	printCheck(check, repairs)
+
+	// Interactive confirmation
+	action := ui.AskForAction()
+
+	switch action {
+	case ui.ActionAccept:
+		// Apply changes
+		if err := writeFile(fileName, documentedCode, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		pterm.Success.Printf("Documentation added to %s\n", fileName)
+
+	case ui.ActionReview:
+		review := ui.ReviewHunks(fileName, originalCode, documentedCode)
+		if !review.Changed() {
+			pterm.Warning.Println("No changes accepted. File unchanged.")
+			break
+		}
+		if err := writeFile(fileName, review.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		pterm.Success.Printf("Accepted documentation added to %s\n", fileName)
+
+	case ui.ActionDecline:
+		pterm.Warning.Println("Documentation cancelled.")
+
+	case ui.ActionEdit:
+		// Open editor for manual editing
+		editedCode, err := ui.OpenEditor(documentedCode)
+		if err != nil {
+			return fmt.Errorf("failed to open editor: %w", err)
+		}
+
+		if ui.ConfirmAction("Apply edited documentation?") {
+			if err := writeFile(fileName, editedCode, 0644); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+			pterm.Success.Printf("Edited documentation applied to %s\n", fileName)
+		}
+	}
+
+	return nil
+}
+
+func buildDocumentPrompt(language, style, code string) string {
+	styleGuide := getStyleGuide(style)
+
+	prompt := fmt.Sprintf(`You are an expert %s developer. Add comprehensive documentation to the following code.
+
+Documentation style: %s
+%s
+
+Current code:
+%s
+
+Requirements:
+1. Document all public functions, methods, and classes
+2. Include parameter descriptions and return value documentation
+3. Add examples where appropriate
+4. Document complex logic with inline comments
+5. Follow the %s documentation conventions exactly
+6. Do not change the code logic, only add documentation
+
+%s`,
+		language, style, styleGuide, code, style,
+		editFormat("Generate the complete file with documentation added, without any explanations or markdown formatting."))
+
+	return prompt
+}
+
+func getDefaultDocStyle(fileName, language string) string {
+	// Check config first, including the project's per-path overrides
+	if cfg := config.ForPath(fileName); cfg != nil && cfg.Languages != nil {
+		if langConfig, ok := cfg.Languages[strings.ToLower(language)]; ok {
+			if langConfig.DocStyle != "" {
+				return langConfig.DocStyle
+			}
+		}
+	}
+
+	// Fallback to defaults
+	switch strings.ToLower(language) {
+	case "javascript", "typescript":
+		return "jsdoc"
+	case "php":
+		return "phpdoc"
+	case "go":
+		return "godoc"
+	case "python":
+		return "google"
+	case "java":
+		return "javadoc"
+	case "c#", "csharp":
+		return "xmldoc"
+	default:
+		return "standard"
+	}
+}
+
+func getStyleGuide(style string) string {
+	switch strings.ToLower(style) {
+	case "jsdoc":
+		return `
+JSDoc style example:
+/**
+ * Description of the function.
+ * @param {string} param1 - Description of param1
+ * @param {number} param2 - Description of param2
+ * @returns {boolean} Description of return value
+ * @example
+ * functionName("test", 123);
+ */`
+	case "phpdoc":
+		return `
+PHPDoc style example:
+/**
+ * Description of the function.
+ *
+ * @param string $param1 Description of param1
+ * @param int $param2 Description of param2
+ * @return bool Description of return value
+ * @throws Exception When something goes wrong
+ */`
+	case "godoc":
+		return `
+GoDoc style example:
+// FunctionName does something important.
+// It takes param1 and param2 and returns a boolean value.
+//
+// Example:
+//
+//	result := FunctionName("test", 123)
+//	if result {
+//		// handle success
+//	}`
+	case "google":
+		return `
+Google Python style example:
+"""Brief description of the function.
+
+Longer description if needed.
+
+Args:
+    param1 (str): Description of param1.
+    param2 (int): Description of param2.
+
+Returns:
+    bool: Description of return value.
+
+Raises:
+    ValueError: When invalid input is provided.
+
+Example:
+    >>> function_name("test", 123)
+    True
+"""`
+	default:
+		return "Use appropriate documentation style for the language."
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/patch"
+)
+
+// wholeFile makes refactor and document ask the model for the whole file
+// instead of search/replace edits
+var wholeFile bool
+
+// maxFailedLines is how much of an edit that failed to apply is shown
+const maxFailedLines = 5
+
+// editFormat returns the answer format to append to a prompt that asks for
+// changes to an existing file; whole is the format used with --whole-file
+func editFormat(whole string) string {
+	if wholeFile {
+		return whole
+	}
+	return patch.Instructions
+}
+
+// applyEdits applies the edits in a model response to original and reports
+// how they went. Edits that could not be located are listed; the result
+// holds the others. It fails when the response has no edits or none of them
+// could be applied.
+func applyEdits(original, response string) (string, error) {
+	edits, err := patch.Parse(response)
+	if err != nil {
+		return "", fmt.Errorf("%w (retry, or use --whole-file to ask for the whole file)", err)
+	}
+
+	result := patch.Apply(original, edits)
+	fuzzy := 0
+	for _, a := range result.Applied {
+		if a.Match == patch.MatchFuzzy {
+			fuzzy++
+		}
+	}
+
+	summary := fmt.Sprintf("Applied %d of %d edits", len(result.Applied), len(edits))
+	if fuzzy > 0 {
+		summary += fmt.Sprintf(" (%d located approximately, check them in the diff)", fuzzy)
+	}
+	if len(result.Failed) == 0 {
+		pterm.Success.Println(summary)
+	} else {
+		pterm.Warning.Println(summary)
+		for _, f := range result.Failed {
+			pterm.Warning.Printf("Edit %d was not applied: %s\n", f.Edit+1, f.Reason)
+			lines := strings.Split(edits[f.Edit].Search, "\n")
+			if len(lines) > maxFailedLines {
+				lines = append(lines[:maxFailedLines], "...")
+			}
+			for _, line := range lines {
+				pterm.FgGray.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	if len(result.Applied) == 0 {
+		return "", fmt.Errorf("none of the %d edits could be applied", len(edits))
+	}
+	return result.Content, nil
+}
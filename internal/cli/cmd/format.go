@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/format"
+	"github.com/snowsoft/codeweaver/internal/utils"
+)
+
+// formatCode runs the formatter for fileName on code: the one set under
+// languages.<language>.formatter, or else the project's own. original is
+// the file's current content, or "" for a new file. When the formatter
+// would change original too, the file does not follow it, and code is left
+// as it is so that the diff shows only the requested changes.
+func formatCode(ctx context.Context, fileName, original, code string) string {
+	language := strings.ToLower(utils.DetectLanguage(fileName))
+	formatter := format.ForFile(fileName, config.ForPath(fileName).Languages[language].Formatter)
+	if formatter == nil {
+		return code
+	}
+
+	if original != "" {
+		if formatted, err := formatter.Format(ctx, fileName, original); err != nil || formatted != original {
+			pterm.Info.Printf("Not formatting: %s is not formatted with %s\n", fileName, formatter.Name)
+			return code
+		}
+	}
+
+	formatted, err := formatter.Format(ctx, fileName, code)
+	if err != nil {
+		pterm.Warning.Printf("%s could not format the code: %v\n", formatter.Name, err)
+		return code
+	}
+	if formatted != code {
+		pterm.Info.Printf("Formatted with %s\n", formatter.Name)
+	}
+	return formatted
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/graph"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat     string
+	graphPackages   bool
+	graphDependents string
+	graphTransitive bool
+	graphOutput     string
+)
+
+// GraphCmd shows how a project's files and packages import each other
+var GraphCmd = &cobra.Command{
+	Use:   "graph [directory]",
+	Short: "Show the project's import graph",
+	Long: `Graph reads the imports of Go, JavaScript, TypeScript, Python and PHP files
+and resolves them to files of the project. It summarizes the graph, lists the
+files that depend on a file or package, or exports the graph as DOT or JSON.`,
+	Example: `  # Summary: sizes, import cycles and the most imported files
+  weaver graph
+
+  # Which files import this file, directly or indirectly?
+  weaver graph --dependents internal/config/config.go --transitive
+
+  # Package graph as an image
+  weaver graph --packages --format dot | dot -Tsvg > graph.svg
+
+  # Every file with its resolved imports
+  weaver graph --format json -o graph.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraph,
+}
+
+func init() {
+	GraphCmd.Flags().StringVarP(&graphFormat, "format", "f", "text", "Output format (text, dot, json)")
+	GraphCmd.Flags().BoolVar(&graphPackages, "packages", false, "Show imports between package directories instead of files")
+	GraphCmd.Flags().StringVar(&graphDependents, "dependents", "", "List the files that import this file or directory")
+	GraphCmd.Flags().BoolVar(&graphTransitive, "transitive", false, "With --dependents, include indirect dependents")
+	GraphCmd.Flags().StringVarP(&graphOutput, "output", "o", "", "Write DOT or JSON output to a file")
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	g, err := graph.Build(absRoot)
+	if err != nil {
+		return fmt.Errorf("failed to build import graph: %w", err)
+	}
+	if len(g.Files) == 0 {
+		return fmt.Errorf("no Go, JavaScript, TypeScript, Python or PHP files found in %s", root)
+	}
+
+	level := graph.LevelFile
+	if graphPackages {
+		level = graph.LevelPackage
+	}
+
+	if graphDependents != "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return showDependents(g, indexPaths(absRoot, cwd, []string{graphDependents})[0])
+	}
+
+	switch graphFormat {
+	case "text":
+		showGraphSummary(g)
+		return nil
+	case "dot", "json":
+		var w io.Writer = os.Stdout
+		if graphOutput != "" {
+			f, err := os.Create(graphOutput)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", graphOutput, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if graphFormat == "dot" {
+			err = g.WriteDOT(w, level)
+		} else {
+			err = g.WriteJSON(w, level)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write graph: %w", err)
+		}
+		if graphOutput != "" {
+			pterm.Success.Printf("Import graph written to %s\n", graphOutput)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q (use text, dot or json)", graphFormat)
+	}
+}
+
+// showDependents lists the files that import target
+func showDependents(g *graph.Graph, target string) error {
+	if !g.Contains(target) {
+		return fmt.Errorf("%s is not a source file or package in %s", target, g.Root)
+	}
+
+	dependents := g.Dependents(target)
+	kind := "Direct dependents"
+	if graphTransitive {
+		dependents = g.TransitiveDependents(target)
+		kind = "Dependents"
+	}
+
+	if graphFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"target":     target,
+			"transitive": graphTransitive,
+			"dependents": dependents,
+		})
+	}
+
+	if len(dependents) == 0 {
+		pterm.Info.Printf("Nothing in the project imports %s\n", target)
+		return nil
+	}
+	pterm.DefaultSection.Printf("%s of %s (%d)\n", kind, target, len(dependents))
+	for _, rel := range dependents {
+		fmt.Println("  " + rel)
+	}
+	return nil
+}
+
+// showGraphSummary prints the size of the graph, its import cycles and the
+// files most others depend on
+func showGraphSummary(g *graph.Graph) {
+	edges, external := 0, 0
+	for _, rel := range g.Paths() {
+		edges += len(g.Dependencies(rel))
+		for _, imp := range g.Files[rel].Imports {
+			if len(imp.Targets) == 0 {
+				external++
+			}
+		}
+	}
+
+	pterm.DefaultSection.Println("Import graph")
+	pterm.DefaultTable.WithData(pterm.TableData{
+		{"Files", fmt.Sprint(len(g.Files))},
+		{"Packages", fmt.Sprint(len(g.Packages()))},
+		{"Internal imports", fmt.Sprint(edges)},
+		{"External imports", fmt.Sprint(external)},
+	}).Render()
+
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		pterm.DefaultSection.Println("Import cycles")
+		for _, cycle := range cycles {
+			pterm.Warning.Println(strings.Join(cycle, " <-> "))
+		}
+	}
+
+	if top := g.MostImported(10); len(top) > 0 {
+		pterm.DefaultSection.Println("Most imported files")
+		data := pterm.TableData{{"File", "Direct", "Total"}}
+		for _, rel := range top {
+			data = append(data, []string{
+				rel,
+				fmt.Sprint(len(g.Dependents(rel))),
+				fmt.Sprint(len(g.TransitiveDependents(rel))),
+			})
+		}
+		pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+	}
+}
@@ -1,4 +1,3 @@
-// cmd/heal_project.go
 package cmd
 
 import (
@@ -13,7 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/snowsoft/codeweaver/internal/ai"
 	"github.com/snowsoft/codeweaver/internal/analyzer"
-	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/graph"
 	"github.com/snowsoft/codeweaver/internal/ui"
 )
 
@@ -22,7 +21,8 @@ var (
 	severity string
 )
 
-var healProjectCmd = &cobra.Command{
+// HealProjectCmd analyzes a project and suggests fixes
+var HealProjectCmd = &cobra.Command{
 	Use:   "heal-project [path]",
 	Short: "Analyze and fix project issues automatically",
 	Long: `Heal-project performs a comprehensive analysis of your codebase to detect:
@@ -42,26 +42,16 @@ var healProjectCmd = &cobra.Command{
 
   # Only show critical issues
   weaver heal-project --severity critical`,
-	Run: runHealProject,
+	RunE: runHealProject,
 }
 
 func init() {
-	rootCmd.AddCommand(healProjectCmd)
-	
-	healProjectCmd.Flags().BoolVar(&autoFix, "auto-fix", false, "Automatically fix detected issues")
-	healProjectCmd.Flags().StringVar(&severity, "severity", "all", "Filter by severity: all, critical, medium, low")
+	HealProjectCmd.Flags().BoolVar(&autoFix, "auto-fix", false, "Automatically fix detected issues")
+	HealProjectCmd.Flags().StringVar(&severity, "severity", "all", "Filter by severity: all, critical, medium, low")
 }
 
 // Issue represents a detected project issue
-type Issue struct {
-	Type        string
-	Severity    string // critical, medium, low
-	File        string
-	Line        int
-	Description string
-	Solution    string
-	Command     string // Weaver command to fix
-}
+type Issue = analyzer.Issue
 
 // ProjectReport contains all detected issues
 type ProjectReport struct {
@@ -74,7 +64,7 @@ type ProjectReport struct {
 	FixCommands    []string
 }
 
-func runHealProject(cmd *cobra.Command, args []string) {
+func runHealProject(cmd *cobra.Command, args []string) error {
 	// Determine project path
 	projectPath := "."
 	if len(args) > 0 {
@@ -83,8 +73,7 @@ func runHealProject(cmd *cobra.Command, args []string) {
 	
 	absPath, err := filepath.Abs(projectPath)
 	if err != nil {
-		ui.ErrorMsg("Failed to resolve project path", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to resolve project path: %w", err)
 	}
 	
 	// Start analysis
@@ -94,37 +83,44 @@ func runHealProject(cmd *cobra.Command, args []string) {
 	startTime := time.Now()
 	
 	// Create enhanced AI client
-	cfg := config.Load()
+	settings, err := aiSettings()
+	if err != nil {
+		spinner.Fail("Failed to initialize AI client")
+		return err
+	}
 	aiConfig := ai.ClientConfig{
-		Provider:    "ollama",
-		Model:       cfg.Ollama.Model,
-		Temperature: cfg.Ollama.Temperature,
+		Provider:    string(settings.Provider),
+		APIURL:      settings.APIURL,
+		APIKey:      settings.APIKey,
+		Model:       settings.Model,
+		Temperature: settings.Temperature,
 		MaxTokens:   8192,
 	}
 	
 	enhancedClient, err := ai.NewEnhancedClient(aiConfig)
 	if err != nil {
-		spinner.Stop()
-		ui.ErrorMsg("Failed to initialize AI client", err)
-		os.Exit(1)
+		spinner.Fail("Failed to initialize AI client")
+		return err
 	}
 	
+	// Import graph; analysis goes on without it if the project cannot be walked
+	importGraph, _ := graph.Build(absPath)
+	
 	// Detect project type and update context
-	projectType, language, framework := detectProjectType(absPath)
+	projectType, language, framework := detectProjectType(absPath, importGraph)
 	enhancedClient.UpdateProjectContext(language, framework, projectType, "")
 	
 	// Create project analyzer
 	projectAnalyzer := analyzer.NewProjectAnalyzer(absPath)
 	
 	// Perform analysis
-	issues, err := analyzeProject(projectAnalyzer, enhancedClient, absPath)
+	issues, err := analyzeProject(projectAnalyzer, enhancedClient, absPath, importGraph)
 	if err != nil {
-		spinner.Stop()
-		ui.ErrorMsg("Failed to analyze project", err)
-		os.Exit(1)
+		spinner.Fail("Failed to analyze project")
+		return err
 	}
 	
-	spinner.Stop()
+	spinner.Success("Analysis complete")
 	
 	// Generate report
 	report := generateReport(absPath, issues, time.Since(startTime))
@@ -134,13 +130,15 @@ func runHealProject(cmd *cobra.Command, args []string) {
 	
 	// Handle auto-fix if requested
 	if autoFix && len(report.FixCommands) > 0 {
-		if ui.Confirm("\nApply all fixes automatically?") {
+		if ui.ConfirmAction("Apply all fixes automatically?") {
 			applyFixes(report.FixCommands)
 		}
 	}
+	
+	return nil
 }
 
-func detectProjectType(projectPath string) (projectType, language, framework string) {
+func detectProjectType(projectPath string, g *graph.Graph) (projectType, language, framework string) {
 	// Check for various project indicators
 	files, _ := os.ReadDir(projectPath)
 	
@@ -179,10 +177,27 @@ func detectProjectType(projectPath string) (projectType, language, framework str
 		}
 	}
 	
+	// No manifest at the top level: go by the language of most source files
+	if g != nil {
+		counts := make(map[string]int)
+		for _, f := range g.Files {
+			counts[f.Language]++
+		}
+		best := ""
+		for lang, n := range counts {
+			if best == "" || n > counts[best] || (n == counts[best] && lang < best) {
+				best = lang
+			}
+		}
+		if best != "" {
+			return "unknown", best, ""
+		}
+	}
+	
 	return "unknown", "unknown", ""
 }
 
-func analyzeProject(analyzer *analyzer.ProjectAnalyzer, client *ai.EnhancedClient, projectPath string) ([]Issue, error) {
+func analyzeProject(analyzer *analyzer.ProjectAnalyzer, client *ai.EnhancedClient, projectPath string, g *graph.Graph) ([]Issue, error) {
 	ctx := context.Background()
 	issues := []Issue{}
 	
@@ -202,6 +217,11 @@ func analyzeProject(analyzer *analyzer.ProjectAnalyzer, client *ai.EnhancedClien
 	// Use AI to analyze project structure
 	analysisPrompt := fmt.Sprintf(`Analyze this project structure and identify potential issues.
 Project has %d files. Sample files: %v`, len(files), files[:min(10, len(files))])
+	if g != nil && len(g.Files) > 0 {
+		summary := graphSummary(g)
+		aiContext["import_graph"] = summary
+		analysisPrompt += "\n\n" + summary
+	}
 	
 	aiResponse, err := client.GenerateWithCommand(
 		ctx,
@@ -224,9 +244,50 @@ Project has %d files. Sample files: %v`, len(files), files[:min(10, len(files))]
 	securityIssues := analyzer.SecurityScan(files)
 	issues = append(issues, securityIssues...)
 	
+	// Packages that import each other
+	if g != nil {
+		issues = append(issues, cycleIssues(g)...)
+	}
+	
 	return issues, nil
 }
 
+// graphSummary describes how the project's code connects, for the AI prompt
+func graphSummary(g *graph.Graph) string {
+	edges := 0
+	for _, rel := range g.Paths() {
+		edges += len(g.Dependencies(rel))
+	}
+	
+	var b strings.Builder
+	fmt.Fprintf(&b, "Import graph: %d source files in %d packages, %d internal imports.", len(g.Files), len(g.Packages()), edges)
+	if top := g.MostImported(5); len(top) > 0 {
+		b.WriteString("\nMost imported files:")
+		for _, rel := range top {
+			fmt.Fprintf(&b, "\n- %s (%d dependents)", rel, len(g.TransitiveDependents(rel)))
+		}
+	}
+	for _, cycle := range g.Cycles() {
+		fmt.Fprintf(&b, "\nImport cycle between packages: %s", strings.Join(cycle, ", "))
+	}
+	return b.String()
+}
+
+// cycleIssues reports every group of packages that import each other
+func cycleIssues(g *graph.Graph) []Issue {
+	var issues []Issue
+	for _, cycle := range g.Cycles() {
+		issues = append(issues, Issue{
+			Type:        "architecture",
+			Severity:    "medium",
+			File:        cycle[0],
+			Description: fmt.Sprintf("Import cycle between packages: %s", strings.Join(cycle, " <-> ")),
+			Solution:    "Move the shared code into a package both can import, or invert one dependency",
+		})
+	}
+	return issues
+}
+
 func parseAIResponse(response string) []Issue {
 	issues := []Issue{}
 	
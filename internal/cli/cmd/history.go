@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+// maxCommandWidth is how much of a command line the history table shows
+const maxCommandWidth = 60
+
+var historyLimit int
+
+// HistoryCmd lists the operations in the change journal
+var HistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the changes weaver made to files",
+	Long: `List the operations recorded in the change journal, newest first: the
+command that ran and the files it added (A), modified (M) or removed (D).
+
+Every file weaver writes is recorded while defaults.auto_backup is on; the
+journal is kept in defaults.backup_dir at the project root. Revert an
+operation with 'weaver undo <id>'.`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func init() {
+	HistoryCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of operations to show (0 for all)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	j, err := openJournal()
+	if err != nil {
+		return err
+	}
+	ops, err := j.List()
+	if err != nil {
+		return fmt.Errorf("failed to read the journal: %w", err)
+	}
+	if len(ops) == 0 {
+		pterm.Info.Println("No changes recorded yet")
+		return nil
+	}
+
+	undoneBy := undoneOperations(ops)
+	if historyLimit > 0 && len(ops) > historyLimit {
+		ops = ops[:historyLimit]
+	}
+
+	data := pterm.TableData{{"ID", "Date", "Command", "Files", "Status"}}
+	for _, op := range ops {
+		var files []string
+		for _, c := range op.Files {
+			files = append(files, c.Kind()+" "+c.Path)
+		}
+		status := ""
+		if by, ok := undoneBy[op.ID]; ok {
+			status = fmt.Sprintf("undone by %d", by)
+		} else if op.Undoes != 0 {
+			status = fmt.Sprintf("undoes %d", op.Undoes)
+		}
+		data = append(data, []string{
+			strconv.Itoa(op.ID),
+			op.Time.Format("2006-01-02 15:04"),
+			truncate(op.Command, maxCommandWidth),
+			strings.Join(files, "\n"),
+			status,
+		})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// undoneOperations maps the operations that were undone to the operation
+// that undid them. Undoing an undo redoes the operation it had undone.
+func undoneOperations(ops []*journal.Operation) map[int]int {
+	byID := make(map[int]*journal.Operation, len(ops))
+	for _, op := range ops {
+		byID[op.ID] = op
+	}
+
+	undoneBy := make(map[int]int)
+	for i := len(ops) - 1; i >= 0; i-- { // oldest first
+		op := ops[i]
+		if op.Undoes == 0 {
+			continue
+		}
+		undoneBy[op.Undoes] = op.ID
+		if target, ok := byID[op.Undoes]; ok && target.Undoes != 0 {
+			delete(undoneBy, target.Undoes)
+		}
+	}
+	return undoneBy
+}
+
+// truncate shortens s to width runes, marking the cut with "…"
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
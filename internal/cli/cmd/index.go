@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ai"
+	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/index"
+	"github.com/spf13/cobra"
+)
+
+var (
+	embeddingModel string
+	rebuildIndex   bool
+	searchTopK     int
+	searchPaths    []string
+	searchLangs    []string
+)
+
+// IndexCmd builds the semantic index used by ask and index search
+var IndexCmd = &cobra.Command{
+	Use:   "index [directory]",
+	Short: "Build or update the semantic code index",
+	Long: `Index splits the project's source files into chunks at function and type
+boundaries, embeds them with an embedding model and stores the result in
+.weaver/index. Only files that changed since the last run are embedded again.`,
+	Example: `  # Index the current project
+  weaver index
+
+  # Rebuild from scratch with another embedding model
+  weaver index --rebuild --embedding-model mxbai-embed-large
+
+  # Search the index
+  weaver index search "where are config files loaded"
+  weaver index search "retry logic" --path internal/ai --lang go -k 5`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runIndex,
+}
+
+var indexSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the index for code related to a query",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runIndexSearch,
+}
+
+func init() {
+	IndexCmd.Flags().StringVar(&embeddingModel, "embedding-model", "", "Embedding model (default from index.embedding_model)")
+	IndexCmd.Flags().BoolVar(&rebuildIndex, "rebuild", false, "Discard the existing index and embed every file again")
+
+	indexSearchCmd.Flags().IntVarP(&searchTopK, "top", "k", 10, "Number of results")
+	indexSearchCmd.Flags().StringSliceVar(&searchPaths, "path", nil, "Only search these directories, files or globs")
+	indexSearchCmd.Flags().StringSliceVar(&searchLangs, "lang", nil, "Only search these languages")
+
+	IndexCmd.AddCommand(indexSearchCmd)
+}
+
+// newEmbedder returns the configured provider if it can compute embeddings
+func newEmbedder() (ai.Embedder, error) {
+	client, settings, err := newAIClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI client: %w", err)
+	}
+	embedder, ok := client.(ai.Embedder)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support embeddings", settings.Provider)
+	}
+	return embedder, nil
+}
+
+func runIndex(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+
+	ix, err := index.Open(root)
+	if err != nil {
+		return err
+	}
+	embedder, err := newEmbedder()
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Get()
+	model := cfg.Index.EmbeddingModel
+	if embeddingModel != "" {
+		model = embeddingModel
+	}
+	if ix.Model() != "" && ix.Model() != model && !rebuildIndex {
+		pterm.Warning.Printf("Embedding model changed from %s to %s, rebuilding the index\n", ix.Model(), model)
+	}
+
+	// Stop cleanly on Ctrl+C; finished files are kept for the next run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var bar *pterm.ProgressbarPrinter
+	stats, err := ix.Update(ctx, embedder, index.UpdateOptions{
+		Model:      model,
+		ChunkLines: cfg.Index.ChunkLines,
+		Rebuild:    rebuildIndex,
+		Progress: func(done, total int, file string) {
+			if bar == nil {
+				bar, _ = pterm.DefaultProgressbar.WithTotal(total).WithTitle("Indexing").Start()
+			}
+			bar.UpdateTitle(file)
+			bar.Increment()
+		},
+	})
+	if bar != nil {
+		bar.Stop()
+	}
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Indexed %d files in %s\n", ix.Files(), ix.Root())
+	pterm.Info.Printf("%d added, %d updated, %d removed, %d unchanged (%d chunks embedded with %s)\n",
+		stats.Added, stats.Updated, stats.Removed, stats.Unchanged, stats.Chunks, model)
+	return nil
+}
+
+// indexPaths makes --path values given relative to cwd relative to the
+// index root. Globs are left as they are.
+func indexPaths(root, cwd string, paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if !strings.ContainsAny(p, "*?[") {
+			abs := p
+			if !filepath.IsAbs(abs) {
+				abs = filepath.Join(cwd, p)
+			}
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				p = filepath.ToSlash(rel)
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func runIndexSearch(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	ix, err := index.Open(index.FindRoot(cwd))
+	if err != nil {
+		return err
+	}
+	embedder, err := newEmbedder()
+	if err != nil {
+		return err
+	}
+
+	results, err := ix.Query(context.Background(), embedder, args[0], index.QueryOptions{
+		TopK:      searchTopK,
+		Paths:     indexPaths(ix.Root(), cwd, searchPaths),
+		Languages: searchLangs,
+	})
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		pterm.Info.Println("No matches")
+		return nil
+	}
+
+	data := pterm.TableData{{"Score", "Location", "Symbol"}}
+	for _, r := range results {
+		data = append(data, []string{
+			fmt.Sprintf("%.3f", r.Score),
+			fmt.Sprintf("%s:%d-%d", r.Path, r.StartLine, r.EndLine),
+			r.Symbol,
+		})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
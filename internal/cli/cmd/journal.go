@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/journal"
+	"github.com/snowsoft/codeweaver/internal/textfile"
+)
+
+// defaultBackupDir holds the journal when defaults.backup_dir is not set
+const defaultBackupDir = ".weaver_backups"
+
+var (
+	// changes records the files the running command writes; it stays nil
+	// when defaults.auto_backup is off
+	changes     *journal.Operation
+	changesOnce sync.Once
+)
+
+// writeFile writes content to path in the style of the file it replaces:
+// its mode, encoding, byte order mark, line endings and final newline. A
+// new file gets perm, LF line endings and a final newline. The change is
+// recorded in the journal so that weaver undo can revert it.
+func writeFile(path, content string, perm os.FileMode) error {
+	style, err := textfile.StyleOf(path, perm)
+	if err != nil {
+		return fmt.Errorf("refusing to overwrite %s: %w", path, err)
+	}
+	return writeBytes(path, textfile.Encode(content, style), style.Mode)
+}
+
+// writeBytes writes content to path as is, recording the change in the
+// journal
+func writeBytes(path string, content []byte, perm os.FileMode) error {
+	return recordChanges().WriteFile(path, content, perm)
+}
+
+// readFile reads a file that is going to be rewritten, as UTF-8 text with
+// LF line endings; writeFile puts it back in its own style
+func readFile(path string) (string, error) {
+	text, style, err := textfile.Read(path)
+	if err != nil {
+		return "", err
+	}
+	if style.Encoding != textfile.UTF8 {
+		pterm.Info.Printf("%s is %s text; it is converted to UTF-8 for the model and written back as %s\n",
+			path, style.Encoding, style.Encoding)
+	}
+	return text, nil
+}
+
+// recordChanges returns the journal operation of the running command, or
+// nil when changes are not recorded
+func recordChanges() *journal.Operation {
+	changesOnce.Do(func() {
+		if !config.Get().Defaults.AutoBackup {
+			return
+		}
+		j, err := openJournal()
+		if err != nil {
+			pterm.Warning.Printf("Changes will not be recorded: %v\n", err)
+			return
+		}
+		changes = j.Begin(commandLine())
+	})
+	return changes
+}
+
+// ReportChanges tells how to revert the files the command wrote
+func ReportChanges() {
+	if changes != nil && changes.ID > 0 {
+		pterm.Info.Printf("Changes recorded as operation %d; revert them with 'weaver undo %d'\n", changes.ID, changes.ID)
+	}
+}
+
+// openJournal opens the change journal of the current project
+func openJournal() (*journal.Journal, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	dir := config.Get().Defaults.BackupDir
+	if dir == "" {
+		dir = defaultBackupDir
+	}
+	return journal.Open(journal.FindRoot(cwd, dir), dir), nil
+}
+
+// commandLine returns the command being run, quoting arguments as needed
+func commandLine() string {
+	args := []string{"weaver"}
+	for _, arg := range os.Args[1:] {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
@@ -6,22 +6,19 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/snowsoft/codeweaver/internal/ai"
-	"github.com/snowsoft/codeweaver/internal/ai/ollama"
+	"github.com/snowsoft/codeweaver/internal/ui"
+	"github.com/snowsoft/codeweaver/internal/validate"
 )
 
 var (
 	task        string
 	contextFile string
 	contextDir  string
-	provider    string
-	model       string
-	temperature float64
 	maxTokens   int
 	stream      bool
 )
@@ -36,19 +33,18 @@ The AI will create code that matches your requirements and follows best practice
 Examples:
   weaver new hello.py --task "Create a hello world script"
   weaver new api.go --task "Create REST API with user CRUD operations"
-  weaver new Button.tsx --task "Create React button component" --context-file theme.ts`,
+  weaver new Button.tsx --task "Create React button component" --context-file theme.ts
+  weaver new user_test.go --task "Test @symbol:UserService.Create using the helpers in @dir:internal/testutil"`,
 	Args: cobra.ExactArgs(1),
 	RunE: runNew,
 }
 
 func init() {
-	NewCmd.Flags().StringVarP(&task, "task", "t", "", "Task description (required)")
+	NewCmd.Flags().StringVarP(&task, "task", "t", "", "Task description (required); @file, @file#L10-40, @symbol:Name and @dir:path attach code")
 	NewCmd.Flags().StringVar(&contextFile, "context-file", "", "Reference file for context")
 	NewCmd.Flags().StringVar(&contextDir, "context-dir", "", "Reference directory for context")
-	NewCmd.Flags().StringVar(&provider, "provider", "ollama", "AI provider (ollama, claude, openai, gemini)")
-	NewCmd.Flags().StringVar(&model, "model", "", "Specific model to use")
-	NewCmd.Flags().Float64Var(&temperature, "temperature", 0.7, "Generation temperature (0.0-1.0)")
-	NewCmd.Flags().IntVar(&maxTokens, "max-tokens", 2000, "Maximum tokens to generate")
+	NewCmd.Flags().IntVar(&contextBudget, "context-budget", 0, "Token budget shared by files from --context-dir and @references in the task")
+	NewCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Maximum tokens to generate (default from config)")
 	NewCmd.Flags().BoolVar(&stream, "stream", true, "Stream output as it's generated")
 	
 	NewCmd.MarkFlagRequired("task")
@@ -58,7 +54,12 @@ func runNew(cmd *cobra.Command, args []string) error {
 	filename := args[0]
 	
 	// Check if file already exists
-	if _, err := os.Stat(filename); err == nil {
+	existing, err := readFile(filename)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	if exists {
 		pterm.Warning.Printf("File %s already exists!\n", filename)
 		
 		overwrite := false
@@ -89,26 +90,39 @@ func runNew(cmd *cobra.Command, args []string) error {
 			pterm.Info.Printf("Using context from: %s\n", contextFile)
 		}
 	}
+	budget := newTokenBudget()
+	projectContext, err := gatherContext(filename, budget)
+	if err != nil {
+		return err
+	}
+	if prompt := projectContext.Prompt(); prompt != "" {
+		contextContent = append(contextContent, prompt)
+	}
+	references, err := attachReferences(task, budget)
+	if err != nil {
+		return err
+	}
+	if prompt := references.Prompt(); prompt != "" {
+		contextContent = append(contextContent, prompt)
+	}
 	
 	// Create AI client
 	spinner, _ := pterm.DefaultSpinner.Start("Connecting to AI provider...")
 	
-	config := ai.Config{
-		Provider:    ai.ProviderOllama,
-		APIURL:      "http://localhost:11434",
-		Model:       model,
-		Temperature: temperature,
-		MaxTokens:   maxTokens,
-		Timeout:     120 * time.Second,
+	client, config, err := newAIClient()
+	if err != nil {
+		spinner.Fail("Failed to create AI client")
+		return err
+	}
+	if maxTokens > 0 {
+		config.MaxTokens = maxTokens
 	}
-	
-	client := ollama.NewClient(config)
 	
 	// Check connection
 	ctx := context.Background()
 	if err := client.HealthCheck(ctx); err != nil {
-		spinner.Fail("Failed to connect to Ollama")
-		return fmt.Errorf("ollama connection failed: %w", err)
+		spinner.Fail(fmt.Sprintf("Failed to connect to %s", config.Provider))
+		return fmt.Errorf("%s connection failed: %w", config.Provider, err)
 	}
 	
 	// Build prompt
@@ -117,10 +131,9 @@ func runNew(cmd *cobra.Command, args []string) error {
 	// Generate code
 	req := ai.GenerateRequest{
 		Prompt:      promptText,
-		Model:       model,
-		Temperature: temperature,
-		MaxTokens:   maxTokens,
-		Context:     contextContent,
+		Model:       config.Model,
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
 	}
 	
 	var resp *ai.GenerateResponse
@@ -150,7 +163,7 @@ func runNew(cmd *cobra.Command, args []string) error {
 		resp = &ai.GenerateResponse{
 			Content:  generatedContent,
 			Model:    req.Model,
-			Provider: ai.ProviderOllama,
+			Provider: config.Provider,
 		}
 	} else {
 		// Non-streaming generation
@@ -167,51 +180,88 @@ func runNew(cmd *cobra.Command, args []string) error {
 		generatedContent = resp.Content
 	}
 	
-	// Display generated code (if not streaming)
-	if !stream {
+	// Check the syntax, sending errors back to the model, and format the result
+	streamed := extractCode(generatedContent, filename)
+	generatedContent, check, repairs := checkAndRepair(ctx, client, config, filename, streamed)
+	generatedContent = formatCode(ctx, filename, existing, generatedContent)
+	
+	// Display generated code (if not streaming, or if it changed since)
+	if !stream || generatedContent != streamed {
 		pterm.DefaultSection.Println("Generated Code")
 		pterm.DefaultBox.Println(generatedContent)
 	}
+	printCheck(check, repairs)
 	
-	// Ask for confirmation
-	fmt.Println() // Add spacing
-	saveChoice := ""
-	prompt2 := &survey.Select{
-		Message: "What would you like to do?",
-		Options: []string{"Save", "Edit", "Regenerate", "Cancel"},
-		Default: "Save",
+	// Edits are diffed against the file being overwritten, or else against
+	// the generated code
+	original := generatedContent
+	if exists {
+		original = existing
 	}
-	survey.AskOne(prompt2, &saveChoice)
 	
-	switch saveChoice {
-	case "Save":
-		// Create directory if needed
-		dir := filepath.Dir(filename)
-		if dir != "." && dir != "" {
-			os.MkdirAll(dir, 0755)
+	for {
+		// Ask for confirmation
+		fmt.Println() // Add spacing
+		saveChoice := ""
+		options := []string{"Save", "Edit", "Regenerate", "Cancel"}
+		if exists {
+			options = []string{"Save", "Review each change", "Edit", "Regenerate", "Cancel"}
 		}
-		
-		// Save file
-		err := os.WriteFile(filename, []byte(generatedContent), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to save file: %w", err)
+		prompt2 := &survey.Select{
+			Message: "What would you like to do?",
+			Options: options,
+			Default: "Save",
 		}
-		pterm.Success.Printf("File saved: %s\n", filename)
+		survey.AskOne(prompt2, &saveChoice)
 		
-	case "Edit":
-		// TODO: Implement editor integration
-		pterm.Info.Println("Edit functionality coming soon!")
-		pterm.Info.Println("For now, the generated code has been displayed above.")
-		
-	case "Regenerate":
-		// Recursively call the command
-		return runNew(cmd, args)
+		switch saveChoice {
+		case "Save":
+			// Create directory if needed
+			dir := filepath.Dir(filename)
+			if dir != "." && dir != "" {
+				os.MkdirAll(dir, 0755)
+			}
+			
+			// Save file
+			err := writeFile(filename, generatedContent, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+			pterm.Success.Printf("File saved: %s\n", filename)
+			
+		case "Review each change":
+			review := ui.ReviewHunks(filename, existing, generatedContent)
+			if !review.Changed() {
+				pterm.Info.Println("No changes accepted. File unchanged.")
+				break
+			}
+			if err := writeFile(filename, review.Content, 0644); err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+			pterm.Success.Printf("File saved: %s\n", filename)
+			
+		case "Edit":
+			// Edit the generated code, then show what changed and ask again
+			edited, err := ui.OpenEditorFor(filename, generatedContent)
+			if err != nil {
+				pterm.Warning.Printf("Could not edit the code: %v\n", err)
+				continue
+			}
+			generatedContent = edited
+			showDiff(original, generatedContent)
+			printCheck(validate.Check(ctx, filename, generatedContent), 0)
+			continue
+			
+		case "Regenerate":
+			// Recursively call the command
+			return runNew(cmd, args)
+			
+		case "Cancel":
+			pterm.Info.Println("Operation cancelled.")
+		}
 		
-	case "Cancel":
-		pterm.Info.Println("Operation cancelled.")
+		return nil
 	}
-	
-	return nil
 }
 
 func buildPrompt(filename, task string, context []string) string {
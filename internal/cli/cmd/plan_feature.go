@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ai"
+	"github.com/snowsoft/codeweaver/internal/graph"
+	"github.com/snowsoft/codeweaver/internal/index"
+	"github.com/snowsoft/codeweaver/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+var (
+	planEstimate bool
+	planTopK     int
+	planBudget   int
+	planJSON     bool
+)
+
+// PlanFeatureCmd turns a feature description into a saved implementation plan
+var PlanFeatureCmd = &cobra.Command{
+	Use:   "plan-feature <description>",
+	Short: "Plan the implementation of a feature step by step",
+	Long: `Plan-feature turns a feature description into a structured plan: ordered
+steps with the files each one creates or modifies, the tests to add, risks and,
+with --estimate, the effort in hours. The plan is grounded in the project's
+type, import graph and the code most related to the feature.
+
+Plans are saved as Markdown and JSON under .weaver/plans. Track progress with
+'weaver plan-feature step <plan> <n>'.`,
+	Example: `  weaver plan-feature "add OAuth login with GitHub" --estimate
+  weaver plan-feature list
+  weaver plan-feature show oauth
+  weaver plan-feature step oauth 2 done`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanFeature,
+}
+
+var planListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved plans and their progress",
+	Args:  cobra.NoArgs,
+	RunE:  runPlanList,
+}
+
+var planShowCmd = &cobra.Command{
+	Use:   "show <plan>",
+	Short: "Show a saved plan",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPlanShow,
+}
+
+var planStepCmd = &cobra.Command{
+	Use:   "step <plan> <step> [done|skipped|pending]",
+	Short: "Mark a step of a plan as done, skipped or pending",
+	Args:  cobra.RangeArgs(2, 3),
+	RunE:  runPlanStep,
+}
+
+func init() {
+	PlanFeatureCmd.Flags().BoolVar(&planEstimate, "estimate", false, "Estimate each step and the whole plan in hours")
+	PlanFeatureCmd.Flags().IntVarP(&planTopK, "top", "k", 6, "Number of related code excerpts to include")
+	PlanFeatureCmd.Flags().IntVar(&planBudget, "budget", 4000, "Token budget for the code excerpts sent to the AI")
+	PlanFeatureCmd.Flags().BoolVar(&planJSON, "json", false, "Print the plan as JSON")
+
+	planShowCmd.Flags().BoolVar(&planJSON, "json", false, "Print the plan as JSON")
+
+	PlanFeatureCmd.AddCommand(planListCmd, planShowCmd, planStepCmd)
+}
+
+func runPlanFeature(cmd *cobra.Command, args []string) error {
+	feature := args[0]
+	if planJSON {
+		pterm.DisableOutput()
+		defer pterm.EnableOutput()
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root := index.FindRoot(cwd)
+	ctx := context.Background()
+
+	spinner, _ := pterm.DefaultSpinner.Start("Reading the project...")
+	g, _ := graph.Build(root)
+	projectType, language, framework := detectProjectType(root, g)
+	summary := fmt.Sprintf("Type: %s, language: %s", projectType, language)
+	if framework != "" {
+		summary += ", framework: " + framework
+	}
+	if g != nil && len(g.Files) > 0 {
+		summary += "\n" + graphSummary(g)
+	}
+
+	var snippets string
+	results, _, err := retrieve(ctx, root, feature, index.QueryOptions{TopK: planTopK})
+	if err != nil {
+		pterm.Warning.Printf("Could not search the codebase: %v\n", err)
+	} else {
+		snippets, _ = askExcerpts(root, results, planBudget)
+	}
+
+	client, settings, err := newAIClient()
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to initialize AI client: %v", err))
+		return err
+	}
+
+	spinner.UpdateText("Planning...")
+	promptConfig := ai.SystemPromptConfig{ModelType: "local", ContextWindow: settings.MaxTokens}
+	if settings.Provider != ai.ProviderOllama {
+		promptConfig.ModelType = "cloud"
+	}
+	builder := ai.NewPromptBuilder(promptConfig)
+	prompt := builder.BuildPrompt(ai.PromptTypePlanFeature, feature, map[string]interface{}{
+		"project_path":    root,
+		"estimate":        planEstimate,
+		"project_summary": summary,
+		"snippets":        snippets,
+	})
+	prompt = builder.OptimizeForModel(prompt, settings.Model)
+
+	resp, err := client.Generate(ctx, ai.GenerateRequest{
+		Prompt:      prompt,
+		Model:       settings.Model,
+		Temperature: settings.Temperature,
+		MaxTokens:   settings.MaxTokens,
+	})
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to plan: %v", err))
+		return err
+	}
+
+	p, err := plan.Parse(resp.Content)
+	if err != nil {
+		spinner.Fail("The AI did not return a usable plan")
+		pterm.Println(resp.Content)
+		return err
+	}
+	spinner.Success("Plan ready")
+
+	now := time.Now()
+	p.ID = plan.NewID(root, feature, now)
+	p.Feature = feature
+	p.CreatedAt = now
+	p.CheckFiles(root)
+	if !planEstimate {
+		p.Estimate = nil
+		for i := range p.Steps {
+			p.Steps[i].Hours = 0
+		}
+	}
+
+	file, err := plan.Save(root, p)
+	if err != nil {
+		return err
+	}
+
+	if planJSON {
+		return printPlanJSON(p)
+	}
+	displayPlan(p)
+	if rel, err := filepath.Rel(cwd, file); err == nil {
+		file = rel
+	}
+	pterm.Success.Printf("Plan saved to %s (and .json)\n", file)
+	pterm.Info.Printf("Mark steps as done with: weaver plan-feature step %s <n>\n", p.ID)
+	return nil
+}
+
+func printPlanJSON(p *plan.Plan) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// displayPlan prints a plan's steps, tests, risks and estimate
+func displayPlan(p *plan.Plan) {
+	pterm.DefaultSection.Printf("Plan: %s\n", p.Feature)
+	if p.Summary != "" {
+		fmt.Println(p.Summary)
+		fmt.Println()
+	}
+
+	header := []string{"#", "Step", "Files", "Status"}
+	if p.Estimate != nil {
+		header = append(header, "Hours")
+	}
+	data := pterm.TableData{header}
+	for _, s := range p.Steps {
+		var files []string
+		for _, f := range s.Files {
+			files = append(files, fmt.Sprintf("%s %s", f.Action, f.Path))
+		}
+		row := []string{strconv.Itoa(s.Number), s.Title, strings.Join(files, "\n"), s.Status}
+		if p.Estimate != nil {
+			row = append(row, fmt.Sprintf("%g", s.Hours))
+		}
+		data = append(data, row)
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+
+	for _, list := range []struct {
+		title string
+		items plan.Items
+	}{{"Tests", p.Tests}, {"Risks", p.Risks}} {
+		if len(list.items) == 0 {
+			continue
+		}
+		pterm.DefaultSection.Println(list.title)
+		for _, item := range list.items {
+			fmt.Println("  • " + item)
+		}
+	}
+
+	if p.Estimate != nil {
+		pterm.DefaultSection.Println("Estimate")
+		estimate := fmt.Sprintf("~%g hours", p.Estimate.Hours)
+		if p.Estimate.Confidence != "" {
+			estimate += fmt.Sprintf(" (%s confidence)", p.Estimate.Confidence)
+		}
+		fmt.Println(estimate)
+		if p.Estimate.Notes != "" {
+			fmt.Println(p.Estimate.Notes)
+		}
+	}
+	pterm.Info.Printf("%d of %d steps finished\n", p.Progress(), len(p.Steps))
+}
+
+func planRoot() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return index.FindRoot(cwd), nil
+}
+
+func runPlanList(cmd *cobra.Command, args []string) error {
+	root, err := planRoot()
+	if err != nil {
+		return err
+	}
+	plans, err := plan.List(root)
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		pterm.Info.Println("No plans yet; create one with 'weaver plan-feature \"<feature>\"'")
+		return nil
+	}
+
+	data := pterm.TableData{{"Plan", "Feature", "Progress", "Created"}}
+	for _, p := range plans {
+		data = append(data, []string{
+			p.ID,
+			p.Feature,
+			fmt.Sprintf("%d/%d", p.Progress(), len(p.Steps)),
+			p.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+func runPlanShow(cmd *cobra.Command, args []string) error {
+	root, err := planRoot()
+	if err != nil {
+		return err
+	}
+	p, err := plan.Load(root, args[0])
+	if err != nil {
+		return err
+	}
+	if planJSON {
+		return printPlanJSON(p)
+	}
+	displayPlan(p)
+	return nil
+}
+
+func runPlanStep(cmd *cobra.Command, args []string) error {
+	root, err := planRoot()
+	if err != nil {
+		return err
+	}
+	p, err := plan.Load(root, args[0])
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("step must be a number: %s", args[1])
+	}
+	status := plan.StatusDone
+	if len(args) > 2 {
+		status = args[2]
+	}
+	if err := p.SetStatus(n, status); err != nil {
+		return err
+	}
+	if _, err := plan.Save(root, p); err != nil {
+		return err
+	}
+
+	pterm.Success.Printf("Step %d (%s) is %s; %d of %d steps finished\n", n, p.Steps[n-1].Title, status, p.Progress(), len(p.Steps))
+	for _, s := range p.Steps {
+		if s.Status == plan.StatusPending {
+			pterm.Info.Printf("Next: %d. %s\n", s.Number, s.Title)
+			break
+		}
+	}
+	return nil
+}
@@ -3,16 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
-	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pterm/pterm"
-	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/spf13/cobra"
 	"github.com/snowsoft/codeweaver/internal/ai"
-	"github.com/snowsoft/codeweaver/internal/ai/ollama"
+	"github.com/snowsoft/codeweaver/internal/diff"
+	"github.com/snowsoft/codeweaver/internal/ui"
+	"github.com/snowsoft/codeweaver/internal/validate"
 )
 
 // RefactorCmd represents the refactor command
@@ -25,18 +24,21 @@ and follow current best practices while maintaining functionality.
 Examples:
   weaver refactor old_code.js --task "Convert to ES6+ syntax"
   weaver refactor api.py --task "Add type hints and error handling"
-  weaver refactor legacy.php --task "Update to PSR-12 standards"`,
+  weaver refactor legacy.php --task "Update to PSR-12 standards"
+  weaver refactor handler.go --task "Use the validation from @internal/api/validate.go#L20-60"
+  weaver refactor service.go --task "Finish the started migration" --with-diff --with-history`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRefactor,
 }
 
 func init() {
-	RefactorCmd.Flags().StringVarP(&task, "task", "t", "", "Refactoring task description (required)")
+	RefactorCmd.Flags().StringVarP(&task, "task", "t", "", "Refactoring task description (required); @file, @file#L10-40, @symbol:Name and @dir:path attach code")
 	RefactorCmd.Flags().StringVar(&contextDir, "context-dir", "", "Project directory for context")
-	RefactorCmd.Flags().StringVar(&provider, "provider", "ollama", "AI provider")
-	RefactorCmd.Flags().StringVar(&model, "model", "", "Specific model to use")
-	RefactorCmd.Flags().Float64Var(&temperature, "temperature", 0.3, "Generation temperature (0.0-1.0)")
-	RefactorCmd.Flags().IntVar(&maxTokens, "max-tokens", 2000, "Maximum tokens to generate")
+	RefactorCmd.Flags().IntVar(&contextBudget, "context-budget", 0, "Token budget shared by files from --context-dir, @references in the task and git context")
+	RefactorCmd.Flags().BoolVar(&withDiff, "with-diff", false, "Include the uncommitted git changes")
+	RefactorCmd.Flags().BoolVar(&withHistory, "with-history", false, "Include the file's recent commits and the files often changed with it")
+	RefactorCmd.Flags().BoolVar(&wholeFile, "whole-file", false, "Ask for the whole refactored file instead of edits")
+	RefactorCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Maximum tokens to generate (default from config)")
 	
 	RefactorCmd.MarkFlagRequired("task")
 }
@@ -45,7 +47,7 @@ func runRefactor(cmd *cobra.Command, args []string) error {
 	filename := args[0]
 	
 	// Check if file exists
-	originalContent, err := os.ReadFile(filename)
+	originalContent, err := readFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to read file %s: %w", filename, err)
 	}
@@ -53,45 +55,51 @@ func runRefactor(cmd *cobra.Command, args []string) error {
 	pterm.DefaultHeader.Printf("Refactoring: %s\n", filename)
 	pterm.Info.Printf("Task: %s\n", task)
 	
-	// Create backup
-	backupDir := ".weaver_backups"
-	os.MkdirAll(backupDir, 0755)
-	backupFile := fmt.Sprintf("%s/%s.%d.bak", backupDir, filename, time.Now().Unix())
-	os.WriteFile(backupFile, originalContent, 0644)
-	pterm.Info.Printf("Backup created: %s\n", backupFile)
+	// Gather related files
+	budget := newTokenBudget()
+	projectContext, err := gatherContext(filename, budget)
+	if err != nil {
+		return err
+	}
+	references, err := attachReferences(task, budget)
+	if err != nil {
+		return err
+	}
+	gitContext, err := gatherGitContext(filename, budget)
+	if err != nil {
+		return err
+	}
 	
 	// Create AI client
 	spinner, _ := pterm.DefaultSpinner.Start("Connecting to AI provider...")
 	
-	config := ai.Config{
-		Provider:    ai.ProviderOllama,
-		APIURL:      "http://localhost:11434",
-		Model:       model,
-		Temperature: temperature, // Lower temperature for refactoring
-		MaxTokens:   maxTokens,
-		Timeout:     120 * time.Second,
+	client, config, err := newAIClient()
+	if err != nil {
+		spinner.Fail("Failed to create AI client")
+		return err
+	}
+	if maxTokens > 0 {
+		config.MaxTokens = maxTokens
 	}
-	
-	client := ollama.NewClient(config)
 	
 	// Check connection
 	ctx := context.Background()
 	if err := client.HealthCheck(ctx); err != nil {
-		spinner.Fail("Failed to connect to Ollama")
-		return fmt.Errorf("ollama connection failed: %w", err)
+		spinner.Fail(fmt.Sprintf("Failed to connect to %s", config.Provider))
+		return fmt.Errorf("%s connection failed: %w", config.Provider, err)
 	}
 	
 	spinner.UpdateText("Analyzing and refactoring code...")
 	
 	// Build refactoring prompt
-	prompt := buildRefactorPrompt(filename, task, string(originalContent))
+	prompt := buildRefactorPrompt(filename, task, originalContent, strings.TrimSpace(projectContext.Prompt()+"\n"+references.Prompt()+"\n"+gitContext.Prompt()))
 	
 	// Generate refactored code
 	req := ai.GenerateRequest{
 		Prompt:      prompt,
-		Model:       model,
-		Temperature: temperature,
-		MaxTokens:   maxTokens,
+		Model:       config.Model,
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
 	}
 	
 	resp, err := client.Generate(ctx, req)
@@ -102,47 +110,84 @@ func runRefactor(cmd *cobra.Command, args []string) error {
 	
 	spinner.Success("Code refactored successfully!")
 	
-	// Show diff
-	showDiff(string(originalContent), resp.Content)
-	
-	// Ask for confirmation
-	saveChoice := ""
-	prompt2 := &survey.Select{
-		Message: "What would you like to do?",
-		Options: []string{"Accept changes", "Decline changes", "Edit manually", "Regenerate"},
-		Default: "Accept changes",
-	}
-	survey.AskOne(prompt2, &saveChoice)
-	
-	switch saveChoice {
-	case "Accept changes":
-		err = os.WriteFile(filename, []byte(resp.Content), 0644)
+	var refactored string
+	if wholeFile {
+		refactored = extractCode(resp.Content, filename)
+	} else {
+		refactored, err = applyEdits(originalContent, resp.Content)
 		if err != nil {
-			return fmt.Errorf("failed to save file: %w", err)
+			return err
 		}
-		pterm.Success.Printf("File updated: %s\n", filename)
-		pterm.Info.Printf("Original backed up to: %s\n", backupFile)
+	}
+	refactored, check, repairs := checkAndRepair(ctx, client, config, filename, refactored)
+	refactored = formatCode(ctx, filename, originalContent, refactored)
+	
+	for {
+		// Show diff
+		showDiff(originalContent, refactored)
+		printCheck(check, repairs)
 		
-	case "Decline changes":
-		pterm.Info.Println("Changes declined. File unchanged.")
-		os.Remove(backupFile)
+		// Ask for confirmation
+		saveChoice := ""
+		prompt2 := &survey.Select{
+			Message: "What would you like to do?",
+			Options: []string{"Accept changes", "Review each change", "Decline changes", "Edit manually", "Regenerate"},
+			Default: "Accept changes",
+		}
+		survey.AskOne(prompt2, &saveChoice)
 		
-	case "Edit manually":
-		pterm.Info.Println("Manual edit functionality coming soon!")
+		switch saveChoice {
+		case "Accept changes":
+			err = writeFile(filename, refactored, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+			pterm.Success.Printf("File updated: %s\n", filename)
+			
+		case "Review each change":
+			review := ui.ReviewHunks(filename, originalContent, refactored)
+			if !review.Changed() {
+				pterm.Info.Println("No changes accepted. File unchanged.")
+				break
+			}
+			err = writeFile(filename, review.Content, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+			pterm.Success.Printf("File updated: %s\n", filename)
+			
+		case "Decline changes":
+			pterm.Info.Println("Changes declined. File unchanged.")
+			
+		case "Edit manually":
+			// Edit the proposed version, then show the new diff and ask again
+			edited, err := ui.OpenEditorFor(filename, refactored)
+			if err != nil {
+				pterm.Warning.Printf("Could not edit the changes: %v\n", err)
+			} else {
+				refactored = edited
+				check, repairs = validate.Check(ctx, filename, refactored), 0
+			}
+			continue
+			
+		case "Regenerate":
+			return runRefactor(cmd, args)
+		}
 		
-	case "Regenerate":
-		return runRefactor(cmd, args)
+		return nil
 	}
-	
-	return nil
 }
 
-func buildRefactorPrompt(filename, task, originalCode string) string {
+func buildRefactorPrompt(filename, task, originalCode, projectContext string) string {
+	if projectContext != "" {
+		projectContext = "\n" + projectContext
+	}
+	
 	prompt := fmt.Sprintf(`You are an expert code refactoring assistant. Your task is to refactor the following code.
 
 Task: %s
 Filename: %s
-
+%s
 Original Code:
 %s
 
@@ -152,43 +197,16 @@ Requirements:
 - Apply modern best practices and idioms
 - Fix any obvious bugs or issues
 - Add appropriate comments where helpful
-- DO NOT include markdown code blocks or any formatting
+
+%s`, task, filename, projectContext, originalCode, editFormat(`- DO NOT include markdown code blocks or any formatting
 - Return ONLY the refactored code content
 
-Refactored code:`, task, filename, originalCode)
+Refactored code:`))
 	
 	return prompt
 }
 
 func showDiff(original, refactored string) {
 	pterm.DefaultSection.Println("Proposed Changes")
-	
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(original, refactored, false)
-	
-	// Create a more readable diff display
-	for _, diff := range diffs {
-		lines := strings.Split(diff.Text, "\n")
-		for i, line := range lines {
-			if line == "" && i == len(lines)-1 {
-				continue // Skip last empty line
-			}
-			
-			switch diff.Type {
-			case diffmatchpatch.DiffDelete:
-				pterm.FgRed.Printf("- %s\n", line)
-			case diffmatchpatch.DiffInsert:
-				pterm.FgGreen.Printf("+ %s\n", line)
-			case diffmatchpatch.DiffEqual:
-				// Only show a few lines of context
-				if len(lines) > 6 && i > 2 && i < len(lines)-3 {
-					if i == 3 {
-						fmt.Println("  ...")
-					}
-					continue
-				}
-				fmt.Printf("  %s\n", line)
-			}
-		}
-	}
-}
\ No newline at end of file
+	fmt.Print(diff.NewViewer().Render(original, refactored))
+}
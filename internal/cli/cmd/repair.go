@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ai"
+	"github.com/snowsoft/codeweaver/internal/config"
+	"github.com/snowsoft/codeweaver/internal/validate"
+)
+
+// checkAndRepair checks the syntax of code generated for fileName. While it
+// has errors, they are sent back to the model to be fixed, up to
+// defaults.repair_attempts times. It returns the last version of the code,
+// its check result and the number of repair attempts made.
+func checkAndRepair(ctx context.Context, client ai.AIProvider, settings ai.Config, fileName, code string) (string, *validate.Result, int) {
+	attempts := config.ForPath(fileName).Defaults.RepairAttempts
+	result := validate.Check(ctx, fileName, code)
+
+	repairs := 0
+	for result.Failed() && repairs < attempts {
+		repairs++
+		pterm.Warning.Printf("%s reported syntax errors, asking the model to fix them (attempt %d of %d)\n",
+			result.Checker, repairs, attempts)
+
+		response, err := generateCode(ctx, client, settings, buildRepairPrompt(fileName, code, result))
+		if err != nil {
+			pterm.Warning.Printf("Repair failed: %v\n", err)
+			break
+		}
+		if wholeFile {
+			response = extractCode(response, fileName)
+		} else if response, err = applyEdits(code, response); err != nil {
+			pterm.Warning.Printf("Repair could not be applied: %v\n", err)
+			continue
+		}
+		code = response
+		result = validate.Check(ctx, fileName, code)
+	}
+	return code, result, repairs
+}
+
+// buildRepairPrompt asks the model to fix the syntax errors in code
+func buildRepairPrompt(fileName, code string, result *validate.Result) string {
+	return fmt.Sprintf(`The following file does not pass a syntax check (%s). Fix these errors without making any other changes.
+
+Filename: %s
+
+Errors:
+%s
+
+Code:
+%s
+
+%s`, result.Checker, fileName, strings.Join(result.Errors, "\n"), code,
+		editFormat("Return the complete corrected file, without any explanations or markdown formatting."))
+}
+
+// printCheck shows the outcome of checkAndRepair
+func printCheck(result *validate.Result, repairs int) {
+	attempts := fmt.Sprintf("%d repair attempts", repairs)
+	if repairs == 1 {
+		attempts = "1 repair attempt"
+	}
+
+	switch {
+	case result.Skipped != "":
+		pterm.Info.Printf("Syntax not checked: %s\n", result.Skipped)
+	case result.Failed():
+		if repairs > 0 {
+			pterm.Warning.Printf("Syntax check (%s) still fails after %s:\n", result.Checker, attempts)
+		} else {
+			pterm.Warning.Printf("Syntax check (%s) failed:\n", result.Checker)
+		}
+		for _, e := range result.Errors {
+			pterm.FgGray.Printf("    %s\n", e)
+		}
+	case repairs > 0:
+		pterm.Success.Printf("Syntax check (%s) passed after %s\n", result.Checker, attempts)
+	default:
+		pterm.Success.Printf("Syntax check (%s) passed\n", result.Checker)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/ui"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var reviewTask string
+
+// ReviewCmd represents the review command
+var ReviewCmd = &cobra.Command{
+	Use:   "review <file_name>",
+	Short: "Review code for issues and improvements",
+	Long: `Perform a comprehensive code review, checking for bugs, security issues,
+performance problems, and adherence to best practices.
+    
+The review will provide detailed feedback without modifying the original file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReview,
+}
+
+func init() {
+	ReviewCmd.Flags().StringVarP(&reviewTask, "task", "t", "", "Specific review focus (e.g., 'security', 'performance', 'best-practices'); @file, @symbol:Name and @dir:path attach code")
+	ReviewCmd.Flags().BoolVar(&withDiff, "with-diff", false, "Include the uncommitted git changes")
+	ReviewCmd.Flags().BoolVar(&withHistory, "with-history", false, "Include the file's recent commits and the files often changed with it")
+}
+
+func runReview(cmd *cobra.Command, args []string) error {
+	fileName := args[0]
+
+	// Check if file exists
+	if !utils.FileExists(fileName) {
+		return fmt.Errorf("file %s does not exist", fileName)
+	}
+
+	// Attach code referenced in the review focus and the git context
+	budget := newTokenBudget()
+	references, err := attachReferences(reviewTask, budget)
+	if err != nil {
+		return err
+	}
+	gitContext, err := gatherGitContext(fileName, budget)
+	if err != nil {
+		return err
+	}
+
+	// Create spinner
+	spinner, err := pterm.DefaultSpinner.Start("Reviewing code...")
+	if err != nil {
+		// Continue without spinner
+		pterm.Warning.Println("Failed to start spinner")
+	}
+
+	// Read file
+	code, err := utils.ReadFile(fileName)
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to read file: %v", err))
+		return err
+	}
+
+	// Detect language
+	language := utils.DetectLanguage(fileName)
+
+	// Initialize AI client
+	client, settings, err := newAIClient()
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to initialize AI client: %v", err))
+		return err
+	}
+
+	// Build prompt
+	prompt := buildReviewPrompt(language, code, reviewTask, strings.TrimSpace(references.Prompt()+"\n"+gitContext.Prompt()))
+
+	// Perform review
+	review, err := generateCode(cmd.Context(), client, settings, prompt)
+	if err != nil {
+		spinner.Fail(fmt.Sprintf("Failed to review code: %v", err))
+		return err
+	}
+
+	spinner.Success("Code review completed!")
+
+	// Display review results
+	displayReview(fileName, review)
+
+	// Offer to save review
+	if ui.ConfirmAction("Save review to file?") {
+		reviewFileName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "_review.md"
+		reviewContent := fmt.Sprintf("# Code Review: %s\n\nDate: %s\n\n%s",
+			fileName,
+			time.Now().Format("2006-01-02 15:04:05"),
+			review)
+
+		if err := writeFile(reviewFileName, reviewContent, 0644); err != nil {
+			pterm.Warning.Printf("Failed to save review: %v\n", err)
+		} else {
+			pterm.Success.Printf("Review saved to %s\n", reviewFileName)
+		}
+	}
+
+	return nil
+}
+
+func buildReviewPrompt(language, code, focus, extraContext string) string {
+	reviewAreas := []string{
+		"Code quality and readability",
+		"Potential bugs and logic errors",
+		"Security vulnerabilities",
+		"Performance issues",
+		"Best practices and conventions",
+		"Error handling",
+		"Code duplication",
+		"Maintainability",
+	}
+
+	if focus != "" {
+		reviewAreas = append([]string{fmt.Sprintf("Focus area: %s", focus)}, reviewAreas...)
+	}
+
+	if extraContext != "" {
+		extraContext = "\n" + extraContext + "\n"
+	}
+
+	prompt := fmt.Sprintf(`You are an expert %s developer performing a code review. Review the following code thoroughly.
+
+Code to review:
+%s
+%s
+Review the code for:
+%s
+
+Provide a detailed review with:
+1. Overall assessment
+2. Specific issues found (with line references where applicable)
+3. Suggestions for improvement
+4. Examples of how to fix critical issues
+5. Positive aspects of the code
+
+Format your response as a structured review with clear sections and bullet points.
+Be constructive and specific in your feedback.`,
+		language, code, extraContext, strings.Join(reviewAreas, "\n- "))
+
+	return prompt
+}
+
+func displayReview(fileName string, review string) {
+	pterm.DefaultHeader.WithFullWidth().Printf("Code Review: %s", fileName)
+
+	// Parse and display review sections
+	sections := strings.Split(review, "\n\n")
+	for _, section := range sections {
+		if strings.HasPrefix(section, "#") {
+			// Section header
+			pterm.DefaultSection.Println(strings.TrimPrefix(section, "# "))
+		} else if strings.HasPrefix(section, "-") || strings.HasPrefix(section, "*") {
+			// Bullet points
+			lines := strings.Split(section, "\n")
+			for _, line := range lines {
+				if strings.TrimSpace(line) != "" {
+					pterm.DefaultBasicText.WithStyle(pterm.NewStyle(pterm.FgDefault)).Println("• " + strings.TrimLeft(line, "-* "))
+				}
+			}
+		} else {
+			// Regular paragraph
+			pterm.DefaultParagraph.Println(section)
+		}
+	}
+}
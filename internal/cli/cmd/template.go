@@ -6,11 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/snowsoft/codeweaver/internal/cli/templates"
+	"github.com/snowsoft/codeweaver/internal/walker"
 )
 
 // TemplateCmd represents the template command
@@ -169,7 +171,7 @@ func runTemplateUse(cmd *cobra.Command, args []string) error {
 		}
 		
 		// Write file
-		if err := os.WriteFile(fullPath, []byte(processedContent), 0644); err != nil {
+		if err := writeBytes(fullPath, []byte(processedContent), 0644); err != nil {
 			pterm.Error.Printf("Failed to create %s: %v\n", path, err)
 			progressbar.Increment()
 			continue
@@ -250,38 +252,26 @@ func runTemplateSave(cmd *cobra.Command, args []string) error {
 	spinner, _ := pterm.DefaultSpinner.Start("Copying files...")
 	
 	fileCount := 0
-	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
-		// Skip hidden files and directories
-		if strings.HasPrefix(info.Name(), ".") && info.Name() != ".gitignore" {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		
-		// Skip common ignore patterns
-		if info.IsDir() && (info.Name() == "node_modules" || info.Name() == "vendor" || info.Name() == "dist" || info.Name() == "build") {
-			return filepath.SkipDir
-		}
-		
-		if !info.IsDir() {
-			relPath, _ := filepath.Rel(sourceDir, path)
-			destPath := filepath.Join(templateDir, "files", relPath)
+	// Copy what git would track, keeping .gitignore files for the new project
+	opts := walker.Options{
+		Patterns:         []string{"!.gitignore"},
+		IncludeBinary:    true,
+		IncludeGenerated: true,
+	}
+	err := walker.Walk(sourceDir, opts, func(entry walker.Entry) error {
+		if !entry.Info.IsDir() {
+			destPath := filepath.Join(templateDir, "files", filepath.FromSlash(entry.Rel))
 			
 			// Create directory
 			os.MkdirAll(filepath.Dir(destPath), 0755)
 			
 			// Copy file
-			content, err := os.ReadFile(path)
+			content, err := os.ReadFile(entry.Path)
 			if err != nil {
 				return err
 			}
 			
-			if err := os.WriteFile(destPath, content, info.Mode()); err != nil {
+			if err := os.WriteFile(destPath, content, entry.Info.Mode()); err != nil {
 				return err
 			}
 			
@@ -359,7 +349,7 @@ func loadAndUseCustomTemplate(templateName, projectName string) error {
 			processedContent := string(content)
 			processedContent = strings.ReplaceAll(processedContent, "{{PROJECT_NAME}}", projectName)
 			
-			if err := os.WriteFile(destPath, []byte(processedContent), info.Mode()); err != nil {
+			if err := writeBytes(destPath, []byte(processedContent), info.Mode()); err != nil {
 				return err
 			}
 			
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/journal"
+	"github.com/snowsoft/codeweaver/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var undoForce bool
+
+// UndoCmd reverts an operation from the change journal
+var UndoCmd = &cobra.Command{
+	Use:   "undo [id]",
+	Short: "Revert the files changed by an operation",
+	Long: `Put back every file an operation changed as it was before: modified
+files get their previous content and added files are removed. Without an ID
+the latest operation that was not undone is reverted; see 'weaver history'.
+
+Files edited since the operation are listed and the undo asks for
+confirmation, as those edits would be lost. The undo is recorded as an
+operation too, so undoing it redoes the original one.
+
+Examples:
+  weaver undo
+  weaver undo 12`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUndo,
+}
+
+func init() {
+	UndoCmd.Flags().BoolVarP(&undoForce, "force", "f", false, "Undo without asking, even if files were edited since")
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	j, err := openJournal()
+	if err != nil {
+		return err
+	}
+	ops, err := j.List()
+	if err != nil {
+		return fmt.Errorf("failed to read the journal: %w", err)
+	}
+	undoneBy := undoneOperations(ops)
+
+	var op *journal.Operation
+	if len(args) == 1 {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("operation ID must be a number: %s", args[0])
+		}
+		if op, err = j.Get(id); err != nil {
+			return err
+		}
+		if by, ok := undoneBy[id]; ok {
+			return fmt.Errorf("operation %d was already undone by operation %d; undo %d to redo it", id, by, by)
+		}
+	} else {
+		for _, candidate := range ops {
+			if _, undone := undoneBy[candidate.ID]; !undone && candidate.Undoes == 0 {
+				op = candidate
+				break
+			}
+		}
+		if op == nil {
+			pterm.Info.Println("Nothing to undo")
+			return nil
+		}
+	}
+
+	pterm.Info.Printf("Undoing operation %d (%s): %s\n", op.ID, op.Time.Format("2006-01-02 15:04"), op.Command)
+	for _, c := range op.Files {
+		action := "restore"
+		if c.Kind() == "A" {
+			action = "remove "
+		}
+		pterm.FgGray.Printf("    %s %s\n", action, c.Path)
+	}
+
+	if modified := j.Modified(op); len(modified) > 0 {
+		if len(modified) == 1 {
+			pterm.Warning.Printf("%s was changed after operation %d; undoing it discards that change\n", modified[0], op.ID)
+		} else {
+			pterm.Warning.Printf("%d files were changed after operation %d; undoing it discards those changes:\n", len(modified), op.ID)
+			for _, path := range modified {
+				pterm.FgGray.Printf("    %s\n", path)
+			}
+		}
+		if !undoForce && !ui.ConfirmAction("Undo anyway?") {
+			pterm.Info.Println("Undo cancelled.")
+			return nil
+		}
+	}
+
+	undo, err := j.Undo(op, commandLine())
+	if undo.ID > 0 {
+		pterm.Success.Printf("Reverted operation %d\n", op.ID)
+		pterm.Info.Printf("Recorded as operation %d; 'weaver undo %d' redoes it\n", undo.ID, undo.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("some files could not be restored: %w", err)
+	}
+	return nil
+}
@@ -1,3 +0,0 @@
-﻿package cli
-
-// Commands are registered in root.go init() function
\ No newline at end of file
@@ -0,0 +1,261 @@
+// Package context gathers related project files to send along with a prompt
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/snowsoft/codeweaver/internal/walker"
+)
+
+// DefaultBudget is the token budget used when Options.MaxTokens is not set
+const DefaultBudget = 4000
+
+// maxFileSize is the size above which files are not considered
+const maxFileSize = 256 * 1024
+
+// minExcerptTokens is the smallest excerpt worth including
+const minExcerptTokens = 64
+
+// How a file is included in the context
+const (
+	ModeFull    = "full"
+	ModeOutline = "outline"
+	ModeExcerpt = "excerpt"
+)
+
+// Options configure Gather
+type Options struct {
+	Dir       string // directory to search
+	Depth     int    // how many directory levels below Dir to search
+	MaxTokens int    // token budget for the gathered files
+}
+
+// File is a file chosen for the context
+type File struct {
+	Path     string // relative to Options.Dir
+	Language string
+	Score    float64
+	Reasons  []string
+	Mode     string // ModeFull, ModeOutline or ModeExcerpt
+	Content  string
+	Tokens   int
+}
+
+// Result is the gathered context
+type Result struct {
+	Files   []File
+	Tokens  int
+	Budget  int
+	Skipped int // relevant files left out because the budget was used up
+}
+
+// candidate is a file under Options.Dir that may be related to the target
+type candidate struct {
+	path     string // absolute
+	rel      string // slash path relative to Options.Dir
+	language string
+	modTime  time.Time
+	content  string
+	score    float64
+	reasons  []string
+}
+
+// Gather ranks the files under opts.Dir by relevance to target and returns
+// the most relevant ones, shortened to outlines or excerpts where needed to
+// stay within the token budget. target does not have to exist yet.
+func Gather(target string, opts Options) (*Result, error) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultBudget
+	}
+
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", opts.Dir, err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("context directory %s does not exist", opts.Dir)
+	}
+	targetPath, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", target, err)
+	}
+
+	candidates, err := collect(dir, targetPath, opts.Depth)
+	if err != nil {
+		return nil, err
+	}
+
+	targetContent, _ := os.ReadFile(targetPath)
+	rank(candidates, dir, targetPath, string(targetContent))
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].rel < candidates[j].rel
+	})
+
+	return fit(candidates, opts.MaxTokens), nil
+}
+
+// collect reads the source files under dir, up to depth levels deep
+func collect(dir, target string, depth int) ([]*candidate, error) {
+	var candidates []*candidate
+
+	err := walker.Walk(dir, walker.Options{MaxDepth: depth + 1}, func(entry walker.Entry) error {
+		if entry.Info.IsDir() || entry.Path == target || entry.Info.Size() > maxFileSize {
+			return nil
+		}
+		language := utils.DetectLanguage(entry.Path)
+		if language == "unknown" {
+			return nil
+		}
+
+		content, err := os.ReadFile(entry.Path)
+		if err != nil {
+			return nil
+		}
+		candidates = append(candidates, &candidate{
+			path:     entry.Path,
+			rel:      entry.Rel,
+			language: language,
+			modTime:  entry.Info.ModTime(),
+			content:  string(content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// rank scores each candidate by how closely it relates to the target
+func rank(candidates []*candidate, dir, target, targetContent string) {
+	targetRel, _ := filepath.Rel(dir, target)
+	targetRel = filepath.ToSlash(targetRel)
+	targetLanguage := utils.DetectLanguage(target)
+	targetImports := importsOf(targetRel, targetLanguage, targetContent)
+	targetTokens := nameTokens(targetRel)
+	now := time.Now()
+
+	for _, c := range candidates {
+		if filepath.Dir(c.path) == filepath.Dir(target) {
+			if c.language == "go" && targetLanguage == "go" {
+				c.add(3, "same package")
+			} else {
+				c.add(3, "same directory")
+			}
+		}
+
+		if importsFile(targetImports, c.rel, c.language) {
+			c.add(4, "imported by the target")
+		}
+		if importsFile(importsOf(c.rel, c.language, c.content), targetRel, targetLanguage) {
+			c.add(2, "imports the target")
+		}
+
+		if similarity := jaccard(targetTokens, nameTokens(c.rel)); similarity == 1 {
+			c.add(2, "same name")
+		} else if similarity > 0 {
+			c.add(2*similarity, "similar name")
+		}
+
+		// Recency and language only break ties between otherwise related files
+		if c.score > 0 {
+			switch age := now.Sub(c.modTime); {
+			case age < 24*time.Hour:
+				c.add(1, "modified today")
+			case age < 7*24*time.Hour:
+				c.add(0.5, "modified this week")
+			}
+			if c.language == targetLanguage {
+				c.score += 0.25
+			}
+		}
+	}
+}
+
+func (c *candidate) add(score float64, reason string) {
+	c.score += score
+	c.reasons = append(c.reasons, reason)
+}
+
+// fit includes the ranked candidates in full where the budget allows,
+// falling back to an outline and then to an excerpt
+func fit(candidates []*candidate, budget int) *Result {
+	result := &Result{Budget: budget}
+
+	for _, c := range candidates {
+		if c.score <= 0 {
+			break
+		}
+		remaining := budget - result.Tokens
+		if remaining <= 0 {
+			result.Skipped++
+			continue
+		}
+
+		file := File{
+			Path:     c.rel,
+			Language: c.language,
+			Score:    c.score,
+			Reasons:  c.reasons,
+		}
+
+		if tokens := EstimateTokens(c.content); tokens <= remaining {
+			file.Mode, file.Content, file.Tokens = ModeFull, c.content, tokens
+		} else if outline := Outline(c.rel, c.language, c.content); outline != "" && EstimateTokens(outline) <= remaining {
+			file.Mode, file.Content, file.Tokens = ModeOutline, outline, EstimateTokens(outline)
+		} else if excerpt := excerpt(c.content, remaining); EstimateTokens(excerpt) >= minExcerptTokens {
+			file.Mode, file.Content, file.Tokens = ModeExcerpt, excerpt, EstimateTokens(excerpt)
+		} else {
+			result.Skipped++
+			continue
+		}
+
+		result.Files = append(result.Files, file)
+		result.Tokens += file.Tokens
+	}
+
+	return result
+}
+
+// EstimateTokens approximates the number of tokens in s
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// excerpt returns the leading lines of content that fit in budget tokens
+func excerpt(content string, budget int) string {
+	var out strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if EstimateTokens(out.String()+line) > budget {
+			break
+		}
+		out.WriteString(line)
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+// Prompt formats the gathered files for inclusion in a prompt. A nil
+// Result yields an empty string.
+func (r *Result) Prompt() string {
+	if r == nil || len(r.Files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Related files from the project:\n")
+	for _, file := range r.Files {
+		fmt.Fprintf(&b, "\nRelated file (%s, %s):\n```%s\n%s\n```\n", file.Path, file.Mode, file.Language, file.Content)
+	}
+	return b.String()
+}
@@ -0,0 +1,307 @@
+package context
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// emptyTree is the hash of git's empty tree, the base of the diff in a
+// repository without commits
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// Defaults for GitOptions
+const (
+	DefaultCommits   = 5
+	DefaultCoChanged = 5
+)
+
+// coChangeWindow is how many commits touching the target are searched for
+// files changed together with it
+const coChangeWindow = 100
+
+// ErrNotRepository is returned by Git when the target is not in a git repository
+var ErrNotRepository = errors.New("not a git repository")
+
+// GitOptions configure Git
+type GitOptions struct {
+	Diff      bool // include uncommitted changes
+	History   bool // include recent commits and co-changed files
+	Commits   int  // recent commits touching the target to include
+	CoChanged int  // co-changed files to include
+	MaxTokens int  // token budget for everything included
+}
+
+// Commit is a commit that touched the target
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    string
+	Subject string
+}
+
+// CoChange is a file that was changed in the same commits as the target
+type CoChange struct {
+	Path  string // relative to the repository root
+	Count int    // commits changing both files
+}
+
+// GitContext is what version control knows about a file
+type GitContext struct {
+	Root          string // repository root
+	Path          string // target, relative to Root
+	Diff          string // uncommitted changes, those to the target first
+	DiffFiles     int    // files with uncommitted changes
+	Commits       []Commit
+	CoChanged     []CoChange
+	Tokens        int
+	Budget        int
+	DiffTruncated bool // the diff was shortened to fit the budget
+}
+
+// Git reads the uncommitted changes, recent commits and co-changed files
+// of target from the repository it belongs to. target has to be inside a
+// git work tree, but does not have to be tracked or exist.
+func Git(target string, opts GitOptions) (*GitContext, error) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultBudget
+	}
+	if opts.Commits <= 0 {
+		opts.Commits = DefaultCommits
+	}
+	if opts.CoChanged <= 0 {
+		opts.CoChanged = DefaultCoChanged
+	}
+
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", target, err)
+	}
+	root, rel, err := gitPath(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GitContext{Root: root, Path: rel, Budget: opts.MaxTokens}
+
+	// Commits and co-changed files are short; the diff gets what remains
+	if opts.History {
+		if g.Commits, err = g.history(opts.Commits); err != nil {
+			return nil, err
+		}
+		if g.CoChanged, err = g.coChanged(opts.CoChanged); err != nil {
+			return nil, err
+		}
+		g.Tokens = EstimateTokens(g.historyPrompt())
+		// The least relevant entries go when even these do not fit
+		for g.Tokens > opts.MaxTokens && len(g.Commits)+len(g.CoChanged) > 0 {
+			if len(g.CoChanged) > 0 {
+				g.CoChanged = g.CoChanged[:len(g.CoChanged)-1]
+			} else {
+				g.Commits = g.Commits[:len(g.Commits)-1]
+			}
+			g.Tokens = EstimateTokens(g.historyPrompt())
+		}
+	}
+	if opts.Diff {
+		if err := g.diff(max(opts.MaxTokens-g.Tokens, 0)); err != nil {
+			return nil, err
+		}
+		g.Tokens += EstimateTokens(g.Diff)
+	}
+	return g, nil
+}
+
+// gitPath returns the top level of the work tree containing the absolute
+// path abs and the slash path of abs relative to it
+func gitPath(abs string) (string, string, error) {
+	// The target and its directory may not exist yet
+	dir := filepath.Dir(abs)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// git resolves symbolic links in the root, so the path is built from
+	// the prefix of dir rather than by comparing the two
+	out, err := runGit(dir, "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return "", "", ErrNotRepository
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	root, prefix := lines[0], ""
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+	rest, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", abs, err)
+	}
+	return filepath.FromSlash(root), path.Join(prefix, filepath.ToSlash(rest)), nil
+}
+
+// runGit runs git in dir and returns its output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// diff reads the uncommitted changes, staged or not, shortened to budget tokens
+func (g *GitContext) diff(budget int) error {
+	base := "HEAD"
+	if !g.hasCommits() {
+		base = emptyTree
+	}
+
+	own, err := runGit(g.Root, "diff", base, "--", g.Path)
+	if err != nil {
+		return err
+	}
+	others, err := runGit(g.Root, "diff", base, "--", ".", ":(exclude)"+g.Path)
+	if err != nil {
+		return err
+	}
+	names, err := runGit(g.Root, "diff", "--name-only", base)
+	if err != nil {
+		return err
+	}
+	g.DiffFiles = len(strings.Fields(names))
+
+	diff := strings.TrimRight(own+others, "\n")
+	if EstimateTokens(diff) > budget {
+		diff = excerpt(diff, budget)
+		g.DiffTruncated = true
+	}
+	g.Diff = diff
+	return nil
+}
+
+// history returns the last n commits touching the target, newest first
+func (g *GitContext) history(n int) ([]Commit, error) {
+	if !g.hasCommits() {
+		return nil, nil
+	}
+	out, err := runGit(g.Root, "log", fmt.Sprintf("-n%d", n), "--follow", "--date=short",
+		"--format=%h%x1f%an%x1f%ad%x1f%s", "--", g.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Date: fields[2], Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// coChanged returns the n existing files most often changed in the same
+// commits as the target
+func (g *GitContext) coChanged(n int) ([]CoChange, error) {
+	if !g.hasCommits() {
+		return nil, nil
+	}
+	out, err := runGit(g.Root, "log", fmt.Sprintf("-n%d", coChangeWindow), "--full-diff",
+		"--name-only", "--format=", "--", g.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, file := range strings.Split(out, "\n") {
+		file = strings.TrimSpace(file)
+		if file == "" || file == g.Path {
+			continue
+		}
+		counts[file]++
+	}
+
+	var changes []CoChange
+	for file, count := range counts {
+		if _, err := os.Stat(filepath.Join(g.Root, filepath.FromSlash(file))); err == nil {
+			changes = append(changes, CoChange{Path: file, Count: count})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Count != changes[j].Count {
+			return changes[i].Count > changes[j].Count
+		}
+		return changes[i].Path < changes[j].Path
+	})
+	if len(changes) > n {
+		changes = changes[:n]
+	}
+	return changes, nil
+}
+
+// hasCommits reports whether the repository has a commit checked out
+func (g *GitContext) hasCommits() bool {
+	_, err := runGit(g.Root, "rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+// historyPrompt formats the commits and co-changed files
+func (g *GitContext) historyPrompt() string {
+	var b strings.Builder
+	if len(g.Commits) > 0 {
+		fmt.Fprintf(&b, "Recent commits touching %s:\n", g.Path)
+		for _, c := range g.Commits {
+			fmt.Fprintf(&b, "- %s %s (%s, %s)\n", c.Hash, c.Subject, c.Author, c.Date)
+		}
+	}
+	if len(g.CoChanged) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Files often changed together with %s:\n", g.Path)
+		for _, c := range g.CoChanged {
+			fmt.Fprintf(&b, "- %s (changed together %d times)\n", c.Path, c.Count)
+		}
+	}
+	return b.String()
+}
+
+// Prompt formats the git context for inclusion in a prompt. A nil
+// GitContext yields an empty string.
+func (g *GitContext) Prompt() string {
+	if g == nil {
+		return ""
+	}
+
+	var parts []string
+	if history := g.historyPrompt(); history != "" {
+		parts = append(parts, history)
+	}
+	if g.Diff != "" {
+		note := ""
+		if g.DiffTruncated {
+			note = ", shortened"
+		}
+		parts = append(parts, fmt.Sprintf("Uncommitted changes (%d files%s):\n```diff\n%s\n```\n", g.DiffFiles, note, g.Diff))
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,201 @@
+package context
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo is a temporary git repository
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q")
+	return r
+}
+
+func (r *testRepo) git(args ...string) {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{
+		"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false",
+	}, args...)...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+os.DevNull, "GIT_CONFIG_NOSYSTEM=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func (r *testRepo) write(rel, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commit writes files (path, content pairs) and commits them
+func (r *testRepo) commit(message string, files ...string) {
+	r.t.Helper()
+	for i := 0; i+1 < len(files); i += 2 {
+		r.write(files[i], files[i+1])
+	}
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", message)
+}
+
+func (r *testRepo) path(rel string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(rel))
+}
+
+func TestGitNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	_, err := Git(filepath.Join(dir, "main.go"), GitOptions{Diff: true, History: true})
+	if !errors.Is(err, ErrNotRepository) {
+		t.Fatalf("Git outside a repository returned %v, want ErrNotRepository", err)
+	}
+}
+
+func TestGitDiff(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("initial", "app/main.go", "package main\n", "app/util.go", "package main\n")
+	r.write("app/util.go", "package main\n\nfunc helper() {}\n")
+	r.write("app/main.go", "package main\n\nfunc main() {}\n")
+
+	g, err := Git(r.path("app/main.go"), GitOptions{Diff: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Path != "app/main.go" {
+		t.Errorf("Path = %q, want app/main.go", g.Path)
+	}
+	if g.DiffFiles != 2 {
+		t.Errorf("DiffFiles = %d, want 2", g.DiffFiles)
+	}
+	main, util := strings.Index(g.Diff, "+func main() {}"), strings.Index(g.Diff, "+func helper() {}")
+	if main < 0 || util < 0 {
+		t.Fatalf("diff is missing a change:\n%s", g.Diff)
+	}
+	if main > util {
+		t.Errorf("the target's changes should come first:\n%s", g.Diff)
+	}
+	if g.Commits != nil || g.CoChanged != nil {
+		t.Errorf("history was read without History")
+	}
+	if !strings.Contains(g.Prompt(), "```diff") {
+		t.Errorf("Prompt() does not include the diff:\n%s", g.Prompt())
+	}
+}
+
+func TestGitDiffStagedWithoutCommits(t *testing.T) {
+	r := newTestRepo(t)
+	r.write("main.py", "print('hello')\n")
+	r.git("add", "main.py")
+
+	g, err := Git(r.path("main.py"), GitOptions{Diff: true, History: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(g.Diff, "+print('hello')") {
+		t.Errorf("staged change missing from diff:\n%s", g.Diff)
+	}
+	if len(g.Commits) != 0 {
+		t.Errorf("Commits = %v, want none", g.Commits)
+	}
+}
+
+func TestGitDiffBudget(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("initial", "big.txt", "start\n")
+	r.write("big.txt", "start\n"+strings.Repeat("a line that was added to the file\n", 500))
+
+	g, err := Git(r.path("big.txt"), GitOptions{Diff: true, MaxTokens: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.DiffTruncated {
+		t.Error("diff over budget was not marked as truncated")
+	}
+	if g.Tokens > 200 {
+		t.Errorf("Tokens = %d, over the budget of 200", g.Tokens)
+	}
+}
+
+func TestGitHistory(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("add service", "service.go", "package app\n", "handler.go", "package app\n")
+	r.commit("change both", "service.go", "package app\n\n// v2\n", "handler.go", "package app\n\n// v2\n")
+	r.commit("unrelated", "readme.md", "docs\n")
+	r.commit("service and store", "service.go", "package app\n\n// v3\n", "store.go", "package app\n", "gone.go", "package app\n")
+	r.git("rm", "-q", "gone.go")
+	r.git("commit", "-q", "-m", "remove gone.go")
+
+	g, err := Git(r.path("service.go"), GitOptions{History: true, Commits: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subjects []string
+	for _, c := range g.Commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if got, want := strings.Join(subjects, ", "), "service and store, change both"; got != want {
+		t.Errorf("commits = %s, want %s", got, want)
+	}
+	if len(g.Commits) > 0 && (g.Commits[0].Author != "Test" || g.Commits[0].Hash == "") {
+		t.Errorf("commit not parsed: %+v", g.Commits[0])
+	}
+
+	want := []CoChange{{Path: "handler.go", Count: 2}, {Path: "store.go", Count: 1}}
+	if len(g.CoChanged) != len(want) {
+		t.Fatalf("CoChanged = %v, want %v", g.CoChanged, want)
+	}
+	for i := range want {
+		if g.CoChanged[i] != want[i] {
+			t.Errorf("CoChanged[%d] = %v, want %v", i, g.CoChanged[i], want[i])
+		}
+	}
+
+	prompt := g.Prompt()
+	for _, s := range []string{"Recent commits touching service.go", "handler.go (changed together 2 times)"} {
+		if !strings.Contains(prompt, s) {
+			t.Errorf("Prompt() is missing %q:\n%s", s, prompt)
+		}
+	}
+}
+
+func TestGitNewFileInSubdirectory(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("initial", "README.md", "hello\n")
+
+	g, err := Git(r.path("pkg/new/file.go"), GitOptions{Diff: true, History: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Path != "pkg/new/file.go" {
+		t.Errorf("Path = %q, want pkg/new/file.go", g.Path)
+	}
+	if g.Diff != "" || len(g.Commits) != 0 || len(g.CoChanged) != 0 {
+		t.Errorf("unexpected context for a new file: %+v", g)
+	}
+	if g.Prompt() != "" {
+		t.Errorf("Prompt() = %q, want empty", g.Prompt())
+	}
+}
@@ -0,0 +1,398 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/snowsoft/codeweaver/internal/symbols"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/snowsoft/codeweaver/internal/walker"
+)
+
+// Reference kinds
+const (
+	RefFile   = "file"   // @src/auth.go
+	RefLines  = "lines"  // @src/auth.go#L20-60
+	RefSymbol = "symbol" // @symbol:UserService.Create or @src/auth.go#Create
+	RefDir    = "dir"    // @dir:internal/api
+)
+
+// maxSymbolMatches is how many declarations a bare symbol reference may match
+const maxSymbolMatches = 3
+
+// Reference is an @reference in a task and the content it attaches
+type Reference struct {
+	Text      string // as written in the task
+	Kind      string
+	Path      string // file or directory, relative to ReferenceOptions.Dir
+	Symbol    string
+	StartLine int // 1-based line range, 0 for a whole file or directory
+	EndLine   int
+	Language  string
+	Mode      string // ModeFull, ModeOutline or ModeExcerpt
+	Content   string
+	Tokens    int
+	Note      string // extra detail, e.g. how many files a directory outline covers
+	Err       error  // why nothing was attached
+}
+
+// Location describes what the reference resolved to, e.g. "src/auth.go:20-60"
+func (r Reference) Location() string {
+	loc := r.Path
+	if r.StartLine > 0 {
+		loc += fmt.Sprintf(":%d-%d", r.StartLine, r.EndLine)
+	}
+	if r.Symbol != "" {
+		loc += " (" + r.Symbol + ")"
+	}
+	return loc
+}
+
+// ReferenceOptions configure ResolveReferences
+type ReferenceOptions struct {
+	Dir       string // directory paths are relative to and symbols are searched in
+	MaxTokens int    // token budget for everything attached
+}
+
+// Attachments are the references found in a task
+type Attachments struct {
+	References []Reference
+	Tokens     int
+	Budget     int
+}
+
+// referencePattern matches @path, @path#L1-2, @path#Symbol, @symbol:Name and
+// @dir:path. The @ has to start a word, so e-mail addresses are not matched.
+var referencePattern = regexp.MustCompile("(?:^|[\\s(\\[{\"'`,])@((?:symbol|dir):)?([^\\s#\"'`()\\[\\]{},;]+)(?:#([^\\s\"'`()\\[\\]{},;]+))?")
+
+// lineRange matches the L20-60 fragment of a line reference
+var lineRange = regexp.MustCompile(`^L(\d+)(?:-L?(\d+))?$`)
+
+// ParseReferences returns the references written in task, in order and
+// without duplicates. Nothing is resolved yet.
+func ParseReferences(task string) []Reference {
+	var refs []Reference
+	seen := make(map[string]bool)
+	for _, m := range referencePattern.FindAllStringSubmatch(task, -1) {
+		prefix, target, fragment := m[1], m[2], m[3]
+		// Sentence punctuation after the reference is not part of it
+		if fragment != "" {
+			fragment = strings.TrimRight(fragment, ".:!?")
+		} else {
+			target = strings.TrimRight(target, ".:!?")
+		}
+		if target == "" {
+			continue
+		}
+
+		ref := Reference{Text: "@" + prefix + target, Kind: RefFile, Path: target}
+		switch {
+		case prefix == "symbol:":
+			ref.Kind, ref.Path, ref.Symbol = RefSymbol, "", target
+		case prefix == "dir:":
+			ref.Kind = RefDir
+		case fragment != "":
+			ref.Text += "#" + fragment
+			if lines := lineRange.FindStringSubmatch(fragment); lines != nil {
+				ref.Kind = RefLines
+				ref.StartLine, _ = strconv.Atoi(lines[1])
+				ref.EndLine = ref.StartLine
+				if lines[2] != "" {
+					ref.EndLine, _ = strconv.Atoi(lines[2])
+				}
+			} else {
+				ref.Kind, ref.Symbol = RefSymbol, fragment
+			}
+		}
+
+		if !seen[ref.Text] {
+			seen[ref.Text] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// ResolveReferences finds the references in task and reads what they point
+// to, shortening content where needed to stay within the token budget.
+// References that cannot be resolved are returned with Err set. A plain
+// @word that names no file is taken for something else, like a decorator,
+// and left out.
+func ResolveReferences(task string, opts ReferenceOptions) (*Attachments, error) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultBudget
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", opts.Dir, err)
+	}
+
+	r := &resolver{dir: dir}
+	a := &Attachments{Budget: opts.MaxTokens}
+	for _, ref := range ParseReferences(task) {
+		resolved, err := r.resolve(ref)
+		if err != nil {
+			if ref.Kind == RefFile && !strings.ContainsAny(ref.Path, "/.") {
+				continue
+			}
+			ref.Err = err
+			a.References = append(a.References, ref)
+			continue
+		}
+		for _, ref := range resolved {
+			a.add(ref)
+		}
+	}
+	return a, nil
+}
+
+// add fits ref into the remaining budget and appends it
+func (a *Attachments) add(ref Reference) {
+	remaining := a.Budget - a.Tokens
+	content := ref.Content
+
+	if tokens := EstimateTokens(content); tokens <= remaining {
+		ref.Mode, ref.Tokens = ModeFull, tokens
+		if ref.Kind == RefDir {
+			ref.Mode = ModeOutline
+		}
+	} else if outline := Outline(ref.Path, ref.Language, content); ref.Kind == RefFile && outline != "" && EstimateTokens(outline) <= remaining {
+		ref.Mode, ref.Content, ref.Tokens = ModeOutline, outline, EstimateTokens(outline)
+	} else if excerpt := excerpt(content, remaining); EstimateTokens(excerpt) >= minExcerptTokens {
+		ref.Mode, ref.Content, ref.Tokens = ModeExcerpt, excerpt, EstimateTokens(excerpt)
+	} else {
+		ref.Content = ""
+		ref.Err = fmt.Errorf("does not fit the remaining %d tokens of the budget", remaining)
+	}
+
+	a.References = append(a.References, ref)
+	a.Tokens += ref.Tokens
+}
+
+// Attached returns the references whose content was attached
+func (a *Attachments) Attached() []Reference {
+	var refs []Reference
+	for _, ref := range a.References {
+		if ref.Err == nil {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// Prompt formats the attached references for inclusion in a prompt. A nil
+// Attachments yields an empty string.
+func (a *Attachments) Prompt() string {
+	if a == nil {
+		return ""
+	}
+	refs := a.Attached()
+	if len(refs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Code referenced in the task:\n")
+	for _, ref := range refs {
+		fmt.Fprintf(&b, "\n%s (%s, %s):\n```%s\n%s\n```\n", ref.Text, ref.Location(), ref.Mode, ref.Language, ref.Content)
+	}
+	return b.String()
+}
+
+// resolver reads the targets of references under dir
+type resolver struct {
+	dir   string
+	files []string // source files with a symbol parser, read on first use
+}
+
+// resolve returns the content ref points to; a symbol reference may
+// resolve to several declarations
+func (r *resolver) resolve(ref Reference) ([]Reference, error) {
+	switch ref.Kind {
+	case RefDir:
+		return r.directory(ref)
+	case RefSymbol:
+		if ref.Path == "" {
+			return r.symbol(ref)
+		}
+	}
+
+	rel, content, err := r.read(ref.Path)
+	if err != nil {
+		return nil, err
+	}
+	ref.Path = rel
+	ref.Language = utils.DetectLanguage(rel)
+
+	switch ref.Kind {
+	case RefLines:
+		lines := strings.Split(content, "\n")
+		if ref.StartLine < 1 || ref.StartLine > len(lines) || ref.EndLine < ref.StartLine {
+			return nil, fmt.Errorf("%s has no lines %d-%d", rel, ref.StartLine, ref.EndLine)
+		}
+		if ref.EndLine > len(lines) {
+			ref.EndLine = len(lines)
+		}
+		ref.Content = strings.Join(lines[ref.StartLine-1:ref.EndLine], "\n")
+	case RefSymbol:
+		syms, err := symbols.Extract(rel, []byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read symbols of %s: %w", rel, err)
+		}
+		s, ok := symbols.Find(syms, ref.Symbol)
+		if !ok {
+			return nil, fmt.Errorf("%s does not declare %s", rel, ref.Symbol)
+		}
+		ref = symbolReference(ref, s, content)
+	default:
+		ref.Content = content
+	}
+	return []Reference{ref}, nil
+}
+
+// read returns the path of file relative to dir and its content
+func (r *resolver) read(file string) (string, string, error) {
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, filepath.FromSlash(file))
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		return "", "", fmt.Errorf("%s does not exist", file)
+	case info.IsDir():
+		return "", "", fmt.Errorf("%s is a directory; use @dir:%s", file, file)
+	case info.Size() > maxFileSize:
+		return "", "", fmt.Errorf("%s is larger than %d KB", file, maxFileSize/1024)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if walker.IsBinary(content) {
+		return "", "", fmt.Errorf("%s is a binary file", file)
+	}
+	return r.rel(path), string(content), nil
+}
+
+// rel returns path relative to dir, or path itself when it is outside dir
+func (r *resolver) rel(path string) string {
+	rel, err := filepath.Rel(r.dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// directory attaches the outlines of the source files under a directory
+func (r *resolver) directory(ref Reference) ([]Reference, error) {
+	path := ref.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, filepath.FromSlash(ref.Path))
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", ref.Path)
+	}
+	files, err := walker.Files(path, walker.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", ref.Path, err)
+	}
+
+	ref.Path = r.rel(path)
+	var b strings.Builder
+	count := 0
+	for _, file := range files {
+		language := utils.DetectLanguage(file)
+		if language == "unknown" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(path, file))
+		if err != nil || len(content) > maxFileSize {
+			continue
+		}
+		count++
+		fmt.Fprintf(&b, "// %s\n", filepath.ToSlash(filepath.Join(ref.Path, file)))
+		if outline := Outline(file, language, string(content)); outline != "" {
+			b.WriteString(outline + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("%s has no source files", ref.Path)
+	}
+
+	ref.Content = strings.TrimRight(b.String(), "\n")
+	ref.Note = fmt.Sprintf("%d files", count)
+	if count == 1 {
+		ref.Note = "1 file"
+	}
+	return []Reference{ref}, nil
+}
+
+// symbol attaches the declarations of a symbol found anywhere under dir
+func (r *resolver) symbol(ref Reference) ([]Reference, error) {
+	if r.files == nil {
+		files, err := walker.Files(r.dir, walker.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", r.dir, err)
+		}
+		r.files = []string{}
+		for _, file := range files {
+			if symbols.Supported(utils.DetectLanguage(file)) {
+				r.files = append(r.files, file)
+			}
+		}
+	}
+
+	var refs []Reference
+	for _, file := range r.files {
+		content, err := os.ReadFile(filepath.Join(r.dir, file))
+		if err != nil || len(content) > maxFileSize {
+			continue
+		}
+		syms, err := symbols.Extract(file, content)
+		if err != nil {
+			continue
+		}
+		if s, ok := symbols.Find(syms, ref.Symbol); ok {
+			match := ref
+			match.Path = filepath.ToSlash(file)
+			match.Language = utils.DetectLanguage(file)
+			refs = append(refs, symbolReference(match, s, string(content)))
+		}
+	}
+
+	switch {
+	case len(refs) == 0:
+		return nil, fmt.Errorf("no declaration of %s found", ref.Symbol)
+	case len(refs) > maxSymbolMatches:
+		var paths []string
+		for _, m := range refs {
+			paths = append(paths, m.Path)
+		}
+		return nil, fmt.Errorf("%s is declared in %d files (%s); qualify it or use @<file>#%s",
+			ref.Symbol, len(refs), strings.Join(paths[:maxSymbolMatches], ", ")+", ...", ref.Symbol)
+	}
+	return refs, nil
+}
+
+// symbolReference fills in ref with the lines declaring s in content
+func symbolReference(ref Reference, s symbols.Symbol, content string) Reference {
+	lines := strings.Split(content, "\n")
+	end := s.EndLine
+	if end > len(lines) {
+		end = len(lines)
+	}
+	ref.Symbol = s.Name
+	ref.StartLine, ref.EndLine = s.StartLine, end
+	ref.Content = strings.Join(lines[s.StartLine-1:end], "\n")
+	return ref
+}
@@ -0,0 +1,201 @@
+package context
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/snowsoft/codeweaver/internal/graph"
+	"github.com/snowsoft/codeweaver/internal/symbols"
+)
+
+// importsOf returns the modules imported by the file at rel, as slash
+// paths without extension. Relative imports are resolved against the
+// file's directory, so the paths are relative to the context directory.
+func importsOf(rel, language, content string) []string {
+	dir := path.Dir(rel)
+	var specs []string
+
+	for _, imp := range graph.ParseImports(language, content) {
+		spec := imp.Spec
+		switch language {
+		case "go":
+			specs = append(specs, spec)
+
+		case "javascript", "typescript":
+			if !strings.HasPrefix(spec, ".") {
+				continue // packages from node_modules
+			}
+			specs = append(specs, trimExt(path.Join(dir, spec)))
+
+		case "python":
+			specs = append(specs, pythonModule(dir, spec))
+			// "from pkg import name" may import a name rather than a module
+			if i := strings.LastIndex(spec, "."); i > 0 && strings.Trim(spec[:i], ".") != "" {
+				specs = append(specs, pythonModule(dir, spec[:i]))
+			}
+
+		case "php":
+			if strings.Contains(spec, "/") || strings.HasSuffix(spec, ".php") {
+				specs = append(specs, trimExt(path.Join(dir, spec)))
+			} else {
+				specs = append(specs, strings.ReplaceAll(spec, `\`, "/"))
+			}
+
+		case "java", "kotlin", "scala":
+			specs = append(specs, strings.ReplaceAll(strings.TrimSuffix(spec, ".*"), ".", "/"))
+
+		case "rust":
+			if module, ok := strings.CutPrefix(spec, "self::"); ok {
+				specs = append(specs, path.Join(dir, module))
+			} else if module, ok := strings.CutPrefix(spec, "crate::"); ok {
+				specs = append(specs, strings.ReplaceAll(module, "::", "/"))
+			}
+		}
+	}
+
+	return specs
+}
+
+// pythonModule turns a dotted module name into a slash path. Relative
+// imports (from .models import x) are resolved against dir.
+func pythonModule(dir, module string) string {
+	trimmed := strings.TrimLeft(module, ".")
+	dots := len(module) - len(trimmed)
+	modulePath := strings.ReplaceAll(trimmed, ".", "/")
+	if dots == 0 {
+		return modulePath
+	}
+	for i := 1; i < dots; i++ {
+		dir = path.Dir(dir)
+	}
+	return path.Join(dir, modulePath)
+}
+
+// importsFile reports whether any of imports refers to the file at rel
+func importsFile(imports []string, rel, language string) bool {
+	if len(imports) == 0 {
+		return false
+	}
+
+	// Go imports name a package, which is the file's directory, by its full
+	// import path. Elsewhere the import may be relative to a source root
+	// (src/, app/) and differ in case from the directory (App\Models).
+	goPackage := language == "go"
+	module := strings.ToLower(trimExt(rel))
+	if goPackage {
+		module = path.Dir(rel)
+		if module == "." {
+			return false
+		}
+	}
+
+	for _, spec := range imports {
+		spec = strings.Trim(path.Clean(spec), "/")
+		if spec == "." || spec == "" {
+			continue
+		}
+		if goPackage {
+			if spec == module || strings.HasSuffix(spec, "/"+module) {
+				return true
+			}
+			continue
+		}
+
+		spec = strings.ToLower(spec)
+		if spec == module || strings.HasSuffix(spec, "/"+module) || strings.HasSuffix(module, "/"+spec) {
+			return true
+		}
+		// index.js, __init__.py and mod.rs stand for their directory
+		if base := path.Base(module); base == "index" || base == "__init__" || base == "mod" {
+			dir := path.Dir(module)
+			if spec == dir || strings.HasSuffix(dir, "/"+spec) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func trimExt(p string) string {
+	return strings.TrimSuffix(p, path.Ext(p))
+}
+
+// nameTokens splits a file name into lower-case words, ignoring the
+// extension and test markers: UserController.test.ts -> user, controller
+func nameTokens(rel string) map[string]bool {
+	name := path.Base(rel)
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+
+	tokens := make(map[string]bool)
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			if w := strings.ToLower(string(word)); w != "test" && w != "tests" && w != "spec" {
+				tokens[w] = true
+			}
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// jaccard returns the share of words two names have in common
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// Lines that declare something worth showing in an outline
+var (
+	declaration       = regexp.MustCompile(`^\s*(?:export\s+|pub(?:\(crate\))?\s+|public\s+|private\s+|protected\s+|internal\s+|abstract\s+|final\s+|static\s+|async\s+|default\s+)*(?:func|type|class|interface|trait|struct|enum|impl|fn|def|function|module|namespace|var|let|const)\b`)
+	methodDeclaration = regexp.MustCompile(`^\s*(?:public|private|protected)\b.*\(`)
+)
+
+// Outline returns the declarations of content without their bodies:
+// signatures and doc summaries from the symbols package where the language
+// is supported, otherwise the lines that look like declarations
+func Outline(path, language, content string) string {
+	if symbols.Supported(language) {
+		if syms, err := symbols.Extract(path, []byte(content)); err == nil && len(syms) > 0 {
+			return symbols.Outline(language, syms)
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		// Only top-level and class-level declarations
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent > 4 || !(declaration.MatchString(line) || methodDeclaration.MatchString(line)) {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t{"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,12 +1,15 @@
+// Package cli wires every Weaver command into a single command tree
 package cli
 
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/snowsoft/codeweaver/internal/cli/cmd"
+	"github.com/snowsoft/codeweaver/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/snowsoft/codeweaver/internal/cli/cmd"
 )
 
 var (
@@ -20,8 +23,13 @@ var rootCmd = &cobra.Command{
 	Short: "AI-powered universal code generation and transformation tool",
 	Long: `CodeWeaver is a powerful CLI tool that automates code writing, refactoring,
 documentation, and testing processes using AI. It works with any programming
-language and understands project context to perform intelligent code transformations.`,
-	Version: "0.1.0",
+language and understands project context to perform intelligent code transformations.
+
+All changes are shown for review and must be approved before they are applied.`,
+	Version: cmd.Version,
+	PersistentPostRun: func(*cobra.Command, []string) {
+		cmd.ReportChanges()
+	},
 }
 
 // Execute runs the root command
@@ -33,22 +41,39 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/weaver/config.yaml)")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
-	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "debug mode")
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/weaver/config.yaml)")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	flags.BoolVar(&debug, "debug", false, "debug mode")
+	flags.String("provider", "", "AI provider (ollama, claude, openai, gemini)")
+	flags.String("api-url", "", "AI provider API URL")
+	flags.String("model", "", "AI model to use")
+	flags.Float64("temperature", 0, "Generation temperature (0.0-1.0)")
 
 	// Bind flags to viper
-	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
-	viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
+	if err := config.BindFlags(flags); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
 
 	// Register commands
 	rootCmd.AddCommand(cmd.VersionCmd)
 	rootCmd.AddCommand(cmd.DoctorCmd)
 	rootCmd.AddCommand(cmd.NewCmd)
 	rootCmd.AddCommand(cmd.RefactorCmd)
+	rootCmd.AddCommand(cmd.DocumentCmd)
+	rootCmd.AddCommand(cmd.TestCmd)
+	rootCmd.AddCommand(cmd.ReviewCmd)
 	rootCmd.AddCommand(cmd.CreateCmd)
-    rootCmd.AddCommand(cmd.TemplateCmd) 
-
+	rootCmd.AddCommand(cmd.TemplateCmd)
+	rootCmd.AddCommand(cmd.HealProjectCmd)
+	rootCmd.AddCommand(cmd.ConfigCmd)
+	rootCmd.AddCommand(cmd.IndexCmd)
+	rootCmd.AddCommand(cmd.AskCmd)
+	rootCmd.AddCommand(cmd.GraphCmd)
+	rootCmd.AddCommand(cmd.AnalyzeImpactCmd)
+	rootCmd.AddCommand(cmd.PlanFeatureCmd)
+	rootCmd.AddCommand(cmd.HistoryCmd)
+	rootCmd.AddCommand(cmd.UndoCmd)
 }
 
 func initConfig() {
@@ -56,10 +81,7 @@ func initConfig() {
 		viper.SetConfigFile(cfgFile)
 	} else {
 		home, err := os.UserHomeDir()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
+		cobra.CheckErr(err)
 
 		// Search config in ~/.config/weaver
 		viper.AddConfigPath(home + "/.config/weaver")
@@ -67,14 +89,30 @@ func initConfig() {
 		viper.SetConfigType("yaml")
 	}
 
-	// Environment variables
-	viper.SetEnvPrefix("WEAVER")
+	// Environment variables, e.g. WEAVER_AI_DEFAULT_MODEL
+	viper.SetEnvPrefix(config.EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
-	// Read config file
-	if err := viper.ReadInConfig(); err == nil {
-		if verbose {
-			fmt.Println("Using config file:", viper.ConfigFileUsed())
+	// Load configuration shared by all commands
+	if _, err := config.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if verbose && viper.ConfigFileUsed() != "" {
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	}
+	if project := config.Project(); verbose && project != nil {
+		fmt.Fprintln(os.Stderr, "Using project config:", project.Path)
+	}
+	if project := config.Project(); project != nil {
+		for _, key := range project.Ignored {
+			fmt.Fprintf(os.Stderr, "Warning: %s sets %s, which can only be set in your user config; ignoring it\n", project.Path, key)
 		}
 	}
-}
\ No newline at end of file
+
+	if legacy := config.Legacy(); legacy != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s uses an old config layout; run 'weaver config migrate' to update it\n", legacy.Path)
+	}
+}
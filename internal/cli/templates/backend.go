@@ -416,31 +416,31 @@ dmypy.json
 ### Local Development
 
 1. Create virtual environment:
-\`\`\`bash
+` + "```" + `bash
 python -m venv venv
 source venv/bin/activate  # On Windows: venv\\Scripts\\activate
-\`\`\`
+` + "```" + `
 
 2. Install dependencies:
-\`\`\`bash
+` + "```" + `bash
 pip install -r requirements.txt
-\`\`\`
+` + "```" + `
 
 3. Copy environment variables:
-\`\`\`bash
+` + "```" + `bash
 cp .env.example .env
-\`\`\`
+` + "```" + `
 
 4. Run the application:
-\`\`\`bash
+` + "```" + `bash
 uvicorn main:app --reload
-\`\`\`
+` + "```" + `
 
 ### Docker Development
 
-\`\`\`bash
+` + "```" + `bash
 docker-compose up
-\`\`\`
+` + "```" + `
 
 ## API Documentation
 
@@ -450,9 +450,9 @@ Once running, visit:
 
 ## Testing
 
-\`\`\`bash
+` + "```" + `bash
 pytest
-\`\`\``,
+` + "```",
 		},
 		Commands: []string{
 			"python -m venv venv",
@@ -582,7 +582,7 @@ const startServer = async () => {
   try {
     await connectDB();
     app.listen(PORT, () => {
-      logger.info(\`Server is running on port \${PORT}\`);
+      logger.info(` + "`" + `Server is running on port ${PORT}` + "`" + `);
     });
   } catch (error) {
     logger.error('Failed to start server:', error);
@@ -979,30 +979,30 @@ coverage/
 ## Installation
 
 1. Install dependencies:
-\`\`\`bash
+` + "```" + `bash
 npm install
-\`\`\`
+` + "```" + `
 
 2. Copy environment variables:
-\`\`\`bash
+` + "```" + `bash
 cp .env.example .env
-\`\`\`
+` + "```" + `
 
 3. Start MongoDB (if not using Docker):
-\`\`\`bash
+` + "```" + `bash
 mongod
-\`\`\`
+` + "```" + `
 
 4. Run development server:
-\`\`\`bash
+` + "```" + `bash
 npm run dev
-\`\`\`
+` + "```" + `
 
 ## Docker
 
-\`\`\`bash
+` + "```" + `bash
 docker-compose up
-\`\`\`
+` + "```" + `
 
 ## API Endpoints
 
@@ -1019,9 +1019,9 @@ docker-compose up
 
 ## Testing
 
-\`\`\`bash
+` + "```" + `bash
 npm test
-\`\`\``,
+` + "```",
 		},
 		Commands: []string{
 			"npm install",
@@ -211,50 +211,50 @@ A CLI tool built with Go and Cobra.
 
 ## Installation
 
-\`\`\`bash
+` + "```" + `bash
 go install {{MODULE_NAME}}@latest
-\`\`\`
+` + "```" + `
 
 Or build from source:
 
-\`\`\`bash
+` + "```" + `bash
 git clone <repository>
 cd {{PROJECT_NAME}}
 make build
-\`\`\`
+` + "```" + `
 
 ## Usage
 
-\`\`\`bash
+` + "```" + `bash
 {{PROJECT_NAME}} --help
-\`\`\`
+` + "```" + `
 
 ### Commands
 
-- \`version\` - Show version information
-- \`config\` - Manage configuration
-  - \`get\` - Get a configuration value
-  - \`set\` - Set a configuration value
+- ` + "`" + `version` + "`" + ` - Show version information
+- ` + "`" + `config` + "`" + ` - Manage configuration
+  - ` + "`" + `get` + "`" + ` - Get a configuration value
+  - ` + "`" + `set` + "`" + ` - Set a configuration value
 
 ## Development
 
 ### Building
 
-\`\`\`bash
+` + "```" + `bash
 make build
-\`\`\`
+` + "```" + `
 
 ### Testing
 
-\`\`\`bash
+` + "```" + `bash
 make test
-\`\`\`
+` + "```" + `
 
 ### Installing locally
 
-\`\`\`bash
+` + "```" + `bash
 make install
-\`\`\``,
+` + "```",
 		},
 		Commands: []string{
 			"go mod tidy",
@@ -611,23 +611,23 @@ Thumbs.db`,
 
 ### From source
 
-\`\`\`bash
+` + "```" + `bash
 git clone <repository>
 cd {{PROJECT_NAME}}
 pip install -e .
-\`\`\`
+` + "```" + `
 
 ### From PyPI
 
-\`\`\`bash
+` + "```" + `bash
 pip install {{PROJECT_NAME}}
-\`\`\`
+` + "```" + `
 
 ## Usage
 
 ### Basic Commands
 
-\`\`\`bash
+` + "```" + `bash
 # Show help
 {{PROJECT_NAME}} --help
 
@@ -640,32 +640,32 @@ pip install {{PROJECT_NAME}}
 # Manage configuration
 {{PROJECT_NAME}} config --list
 {{PROJECT_NAME}} config -k api_key -v "your-key"
-\`\`\`
+` + "```" + `
 
 ## Development
 
 ### Setup development environment
 
-\`\`\`bash
+` + "```" + `bash
 python -m venv venv
 source venv/bin/activate  # On Windows: venv\\Scripts\\activate
 pip install -r requirements-dev.txt
-\`\`\`
+` + "```" + `
 
 ### Running tests
 
-\`\`\`bash
+` + "```" + `bash
 pytest
 pytest --cov={{PROJECT_NAME}}
-\`\`\`
+` + "```" + `
 
 ### Code formatting
 
-\`\`\`bash
+` + "```" + `bash
 black {{PROJECT_NAME}}
 flake8 {{PROJECT_NAME}}
 mypy {{PROJECT_NAME}}
-\`\`\``,
+` + "```",
 		},
 		Commands: []string{
 			"python -m venv venv",
@@ -804,13 +804,13 @@ export const processCommand = new Command('process')
       // Write or display output
       if (options.output) {
         await fs.writeFile(options.output, output);
-        spinner.succeed(chalk.green(\`Output saved to \${options.output}\`));
+        spinner.succeed(chalk.green(` + "`" + `Output saved to ${options.output}` + "`" + `));
       } else {
         spinner.stop();
         console.log(output);
       }
     } catch (error) {
-      spinner.fail(chalk.red(\`Error: \${error.message}\`));
+      spinner.fail(chalk.red(` + "`" + `Error: ${error.message}` + "`" + `));
       process.exit(1);
     }
   });`,
@@ -830,20 +830,20 @@ export const configCommand = new Command('config')
       const allConfig = config.getAll();
       console.log(chalk.cyan('Configuration:'));
       Object.entries(allConfig).forEach(([key, value]) => {
-        console.log(\`  \${chalk.gray(key)}: \${value}\`);
+        console.log(` + "`" + `  ${chalk.gray(key)}: ${value}` + "`" + `);
       });
     } else if (options.get) {
       const value = config.get(options.get);
       if (value !== undefined) {
-        console.log(\`\${options.get}: \${value}\`);
+        console.log(` + "`" + `${options.get}: ${value}` + "`" + `);
       } else {
-        console.log(chalk.red(\`Key '\${options.get}' not found\`));
+        console.log(chalk.red(` + "`" + `Key '${options.get}' not found` + "`" + `));
       }
     } else if (options.set) {
       const [key, value] = options.set.split('=');
       if (key && value) {
         config.set(key, value);
-        console.log(chalk.green(\`Set \${key} = \${value}\`));
+        console.log(chalk.green(` + "`" + `Set ${key} = ${value}` + "`" + `));
       } else {
         console.log(chalk.red('Invalid format. Use: --set key=value'));
       }
@@ -908,18 +908,18 @@ export const initCommand = new Command('init')
       );
       
       // Create README
-      const readme = \`# \${projectName}
+      const readme = ` + "`" + `# ${projectName}
 
-\${answers.description}
+${answers.description}
 
 Created with {{PROJECT_NAME}}
-\`;
+` + "`" + `;
       
       await fs.writeFile(path.join(projectDir, 'README.md'), readme);
       
-      console.log(chalk.green(\`✓ Project initialized at \${projectDir}\`));
+      console.log(chalk.green(` + "`" + `✓ Project initialized at ${projectDir}` + "`" + `));
     } catch (error) {
-      console.log(chalk.red(\`Error: \${error.message}\`));
+      console.log(chalk.red(` + "`" + `Error: ${error.message}` + "`" + `));
       process.exit(1);
     }
   });`,
@@ -970,12 +970,12 @@ describe('CLI Commands', () => {
   const cli = 'ts-node src/index.ts';
   
   test('should display version', () => {
-    const output = execSync(\`\${cli} --version\`).toString();
+    const output = execSync(` + "`" + `${cli} --version` + "`" + `).toString();
     expect(output).toContain('1.0.0');
   });
   
   test('should display help', () => {
-    const output = execSync(\`\${cli} --help\`).toString();
+    const output = execSync(` + "`" + `${cli} --help` + "`" + `).toString();
     expect(output).toContain('{{PROJECT_NAME}}');
     expect(output).toContain('{{DESCRIPTION}}');
   });
@@ -985,7 +985,7 @@ describe('CLI Commands', () => {
     fs.writeFileSync(testFile, JSON.stringify({ test: 'data' }));
     
     try {
-      const output = execSync(\`\${cli} process \${testFile}\`).toString();
+      const output = execSync(` + "`" + `${cli} process ${testFile}` + "`" + `).toString();
       expect(output).toContain('test');
       expect(output).toContain('data');
     } finally {
@@ -1011,62 +1011,62 @@ coverage/
 
 ### Global installation
 
-\`\`\`bash
+` + "```" + `bash
 npm install -g {{PROJECT_NAME}}
-\`\`\`
+` + "```" + `
 
 ### Local development
 
-\`\`\`bash
+` + "```" + `bash
 git clone <repository>
 cd {{PROJECT_NAME}}
 npm install
 npm run build
 npm link
-\`\`\`
+` + "```" + `
 
 ## Usage
 
 ### Commands
 
 #### Process files
-\`\`\`bash
+` + "```" + `bash
 {{PROJECT_NAME}} process input.json -o output.json -f json
-\`\`\`
+` + "```" + `
 
 #### Initialize project
-\`\`\`bash
+` + "```" + `bash
 {{PROJECT_NAME}} init -n my-project
-\`\`\`
+` + "```" + `
 
 #### Manage configuration
-\`\`\`bash
+` + "```" + `bash
 {{PROJECT_NAME}} config --list
 {{PROJECT_NAME}} config --get api_key
 {{PROJECT_NAME}} config --set api_key=your-key
-\`\`\`
+` + "```" + `
 
 ## Development
 
 ### Build
-\`\`\`bash
+` + "```" + `bash
 npm run build
-\`\`\`
+` + "```" + `
 
 ### Run in development
-\`\`\`bash
+` + "```" + `bash
 npm run dev -- --help
-\`\`\`
+` + "```" + `
 
 ### Testing
-\`\`\`bash
+` + "```" + `bash
 npm test
-\`\`\`
+` + "```" + `
 
 ### Linting
-\`\`\`bash
+` + "```" + `bash
 npm run lint
-\`\`\``,
+` + "```",
 		},
 		Commands: []string{
 			"npm install",
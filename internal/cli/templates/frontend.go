@@ -176,16 +176,16 @@ dist-ssr
 
 ## Getting Started
 
-\`\`\`bash
+` + "```" + `bash
 npm install
 npm run dev
-\`\`\`
+` + "```" + `
 
 ## Build
 
-\`\`\`bash
+` + "```" + `bash
 npm run build
-\`\`\`
+` + "```" + `
 
 ## Technologies
 
@@ -419,21 +419,21 @@ dist-ssr
 
 ## Project Setup
 
-\`\`\`bash
+` + "```" + `bash
 npm install
-\`\`\`
+` + "```" + `
 
 ### Compile and Hot-Reload for Development
 
-\`\`\`bash
+` + "```" + `bash
 npm run dev
-\`\`\`
+` + "```" + `
 
 ### Type-Check, Compile and Minify for Production
 
-\`\`\`bash
+` + "```" + `bash
 npm run build
-\`\`\``,
+` + "```",
 		},
 		Commands: []string{
 			"npm install",
@@ -659,7 +659,7 @@ This is a [Next.js](https://nextjs.org/) project bootstrapped with CodeWeaver.
 
 First, run the development server:
 
-\`\`\`bash
+` + "```" + `bash
 npm run dev
 # or
 yarn dev
@@ -667,7 +667,7 @@ yarn dev
 pnpm dev
 # or
 bun dev
-\`\`\`
+` + "```" + `
 
 Open [http://localhost:3000](http://localhost:3000) with your browser to see the result.
 
@@ -593,50 +593,50 @@ yarn-error.log
 ## Installation
 
 1. Clone the repository
-\`\`\`bash
+` + "```" + `bash
 git clone <repository-url>
 cd {{PROJECT_NAME}}
-\`\`\`
+` + "```" + `
 
 2. Install PHP dependencies
-\`\`\`bash
+` + "```" + `bash
 composer install
-\`\`\`
+` + "```" + `
 
 3. Copy environment file
-\`\`\`bash
+` + "```" + `bash
 cp .env.example .env
-\`\`\`
+` + "```" + `
 
 4. Generate application key
-\`\`\`bash
+` + "```" + `bash
 php artisan key:generate
-\`\`\`
+` + "```" + `
 
 5. Configure your database in .env file
 
 6. Run migrations
-\`\`\`bash
+` + "```" + `bash
 php artisan migrate
-\`\`\`
+` + "```" + `
 
 7. Install frontend dependencies (if using)
-\`\`\`bash
+` + "```" + `bash
 npm install
 npm run build
-\`\`\`
+` + "```" + `
 
 8. Start the development server
-\`\`\`bash
+` + "```" + `bash
 php artisan serve
-\`\`\`
+` + "```" + `
 
 ## Docker Setup
 
-\`\`\`bash
+` + "```" + `bash
 docker-compose up -d
 docker-compose exec app php artisan migrate
-\`\`\`
+` + "```" + `
 
 ## API Documentation
 
@@ -656,9 +656,9 @@ The API uses Laravel Sanctum for authentication.
 
 ## Testing
 
-\`\`\`bash
+` + "```" + `bash
 php artisan test
-\`\`\`
+` + "```" + `
 
 ## License
 
@@ -1110,18 +1110,18 @@ return new class extends Migration
 
 ## Setup
 
-\`\`\`bash
+` + "```" + `bash
 composer install
 cp .env.example .env
 php artisan key:generate
 php artisan migrate
 php artisan serve
-\`\`\`
+` + "```" + `
 
 ## Testing API with cURL
 
 ### Register
-\`\`\`bash
+` + "```" + `bash
 curl -X POST http://localhost:8000/api/v1/register \\
   -H "Content-Type: application/json" \\
   -d '{
@@ -1130,24 +1130,24 @@ curl -X POST http://localhost:8000/api/v1/register \\
     "password": "password",
     "password_confirmation": "password"
   }'
-\`\`\`
+` + "```" + `
 
 ### Login
-\`\`\`bash
+` + "```" + `bash
 curl -X POST http://localhost:8000/api/v1/login \\
   -H "Content-Type: application/json" \\
   -d '{
     "email": "test@example.com",
     "password": "password"
   }'
-\`\`\`
+` + "```" + `
 
 ## Docker Support
 
-\`\`\`bash
+` + "```" + `bash
 docker-compose up -d
 docker-compose exec app php artisan migrate
-\`\`\``,
+` + "```",
 			"artisan": `#!/usr/bin/env php
 <?php
 
@@ -1503,43 +1503,43 @@ export default defineConfig({
 ## Installation
 
 1. Install PHP dependencies
-\`\`\`bash
+` + "```" + `bash
 composer install
-\`\`\`
+` + "```" + `
 
 2. Install NPM dependencies
-\`\`\`bash
+` + "```" + `bash
 npm install
-\`\`\`
+` + "```" + `
 
 3. Copy environment file
-\`\`\`bash
+` + "```" + `bash
 cp .env.example .env
-\`\`\`
+` + "```" + `
 
 4. Generate application key
-\`\`\`bash
+` + "```" + `bash
 php artisan key:generate
-\`\`\`
+` + "```" + `
 
 5. Run migrations
-\`\`\`bash
+` + "```" + `bash
 php artisan migrate
-\`\`\`
+` + "```" + `
 
 6. Build assets
-\`\`\`bash
+` + "```" + `bash
 npm run build
-\`\`\`
+` + "```" + `
 
 7. Start development servers
-\`\`\`bash
+` + "```" + `bash
 # Terminal 1
 php artisan serve
 
 # Terminal 2
 npm run dev
-\`\`\`
+` + "```" + `
 
 ## Livewire Components
 
@@ -1551,19 +1551,19 @@ Full CRUD operations with real-time updates.
 
 ## Creating New Components
 
-\`\`\`bash
+` + "```" + `bash
 php artisan make:livewire ComponentName
-\`\`\`
+` + "```" + `
 
 This creates:
-- \`app/Livewire/ComponentName.php\`
-- \`resources/views/livewire/component-name.blade.php\`
+- ` + "`" + `app/Livewire/ComponentName.php` + "`" + `
+- ` + "`" + `resources/views/livewire/component-name.blade.php` + "`" + `
 
 ## Usage in Blade
 
-\`\`\`blade
+` + "```" + `blade
 @livewire('component-name')
-\`\`\`
+` + "```" + `
 
 ## License
 
@@ -0,0 +1,63 @@
+// Package templates contains the built-in project templates
+package templates
+
+import "sort"
+
+// TemplateVariable describes a value the user is asked for when a template is used
+type TemplateVariable struct {
+	Name        string
+	Description string
+	Default     string
+	Required    bool
+}
+
+// Template represents a project template
+type Template struct {
+	Name        string
+	Description string
+	Category    string
+	Variables   []TemplateVariable
+	Files       map[string]string
+	Commands    []string
+}
+
+var registry = make(map[string]Template)
+
+// RegisterTemplate adds a template to the registry
+func RegisterTemplate(name string, template Template) {
+	registry[name] = template
+}
+
+// GetTemplate returns the template registered under name
+func GetTemplate(name string) (Template, bool) {
+	template, ok := registry[name]
+	return template, ok
+}
+
+// GetCategories returns the sorted list of template categories
+func GetCategories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, template := range registry {
+		if !seen[template.Category] {
+			seen[template.Category] = true
+			categories = append(categories, template.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// GetTemplatesByCategory returns the templates in a category sorted by name
+func GetTemplatesByCategory(category string) []Template {
+	var templates []Template
+	for _, template := range registry {
+		if template.Category == category {
+			templates = append(templates, template)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
@@ -1,95 +1,150 @@
+// Package config defines the Weaver configuration schema and loads it
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
-	
+	"time"
+
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
+// CurrentVersion is the schema version written by this release.
+// Files without a version use one of the older layouts and are migrated on load.
+const CurrentVersion = 1
+
+// Config is the Weaver configuration schema
 type Config struct {
+	// Schema version
+	Version int `yaml:"version" mapstructure:"version"`
+
 	// AI Settings
 	AI struct {
-		DefaultProvider string  `yaml:"default_provider"`
-		DefaultModel    string  `yaml:"default_model"`
-		Temperature     float64 `yaml:"temperature"`
-		MaxTokens       int     `yaml:"max_tokens"`
-		Stream          bool    `yaml:"stream"`
-	} `yaml:"ai"`
-	
+		DefaultProvider string        `yaml:"default_provider" mapstructure:"default_provider"`
+		DefaultModel    string        `yaml:"default_model" mapstructure:"default_model"`
+		Temperature     float64       `yaml:"temperature" mapstructure:"temperature"`
+		MaxTokens       int           `yaml:"max_tokens" mapstructure:"max_tokens"`
+		Stream          bool          `yaml:"stream" mapstructure:"stream"`
+		Timeout         time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	} `yaml:"ai" mapstructure:"ai"`
+
 	// Provider Settings
-	Providers map[string]ProviderConfig `yaml:"providers"`
-	
+	Providers map[string]ProviderConfig `yaml:"providers" mapstructure:"providers"`
+
+	// Model aliases, e.g. fast: codellama:7b
+	Models map[string]string `yaml:"models" mapstructure:"models"`
+
 	// UI Settings
 	UI struct {
-		Theme       string `yaml:"theme"`
-		ShowSpinner bool   `yaml:"show_spinner"`
+		Theme       string `yaml:"theme" mapstructure:"theme"`
+		ShowSpinner bool   `yaml:"show_spinner" mapstructure:"show_spinner"`
 		Colors      struct {
-			Added    string `yaml:"added"`
-			Removed  string `yaml:"removed"`
-			Modified string `yaml:"modified"`
-		} `yaml:"colors"`
-	} `yaml:"ui"`
-	
+			Added    string `yaml:"added" mapstructure:"added"`
+			Removed  string `yaml:"removed" mapstructure:"removed"`
+			Modified string `yaml:"modified" mapstructure:"modified"`
+		} `yaml:"colors" mapstructure:"colors"`
+		Diff struct {
+			Layout  string `yaml:"layout" mapstructure:"layout"`
+			Context int    `yaml:"context" mapstructure:"context"`
+		} `yaml:"diff" mapstructure:"diff"`
+	} `yaml:"ui" mapstructure:"ui"`
+
 	// Defaults
 	Defaults struct {
-		ContextDepth int    `yaml:"context_depth"`
-		AutoBackup   bool   `yaml:"auto_backup"`
-		BackupDir    string `yaml:"backup_dir"`
-	} `yaml:"defaults"`
+		ContextDepth   int    `yaml:"context_depth" mapstructure:"context_depth"`
+		AutoBackup     bool   `yaml:"auto_backup" mapstructure:"auto_backup"`
+		BackupDir      string `yaml:"backup_dir" mapstructure:"backup_dir"`
+		RepairAttempts int    `yaml:"repair_attempts" mapstructure:"repair_attempts"`
+	} `yaml:"defaults" mapstructure:"defaults"`
+
+	// Language-specific settings
+	Languages map[string]LanguageConfig `yaml:"languages" mapstructure:"languages"`
+
+	// Semantic code index used by weaver index and weaver ask
+	Index struct {
+		EmbeddingModel string `yaml:"embedding_model" mapstructure:"embedding_model"`
+		ChunkLines     int    `yaml:"chunk_lines" mapstructure:"chunk_lines"`
+	} `yaml:"index" mapstructure:"index"`
+
+	// Per-path overrides, only read from the project's .weaver.yml
+	Overrides []OverrideConfig `yaml:"overrides,omitempty" mapstructure:"-"`
 }
 
+// ProviderConfig holds the settings of a single AI provider
 type ProviderConfig struct {
-	APIKey      string  `yaml:"api_key,omitempty"`
-	APIURL      string  `yaml:"api_url,omitempty"`
-	Model       string  `yaml:"model"`
-	Temperature float64 `yaml:"temperature"`
-	MaxTokens   int     `yaml:"max_tokens"`
+	APIKey      string        `yaml:"api_key,omitempty" mapstructure:"api_key"`
+	APIURL      string        `yaml:"api_url,omitempty" mapstructure:"api_url"`
+	Model       string        `yaml:"model" mapstructure:"model"`
+	Temperature float64       `yaml:"temperature" mapstructure:"temperature"`
+	MaxTokens   int           `yaml:"max_tokens" mapstructure:"max_tokens"`
+	Timeout     time.Duration `yaml:"timeout,omitempty" mapstructure:"timeout"`
+}
+
+// LanguageConfig holds per-language preferences
+type LanguageConfig struct {
+	TestFramework string `yaml:"test_framework" mapstructure:"test_framework"`
+	DocStyle      string `yaml:"doc_style" mapstructure:"doc_style"`
+	// Formatter command reading code on stdin, "none" to disable; empty finds the project's formatter
+	Formatter string `yaml:"formatter,omitempty" mapstructure:"formatter"`
+}
+
+// OverrideConfig is the schema of an entry under overrides
+type OverrideConfig struct {
+	Path   string `yaml:"path"`
+	Config `yaml:",inline"`
 }
 
-var cfg *Config
+// ColorNames are the colors accepted under ui.colors
+var ColorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white", "gray"}
+
+// DiffLayouts are the values accepted for ui.diff.layout
+var DiffLayouts = []string{"auto", "unified", "side-by-side"}
+
+var (
+	cfg    *Config
+	legacy *MigrationResult
+)
 
 // Load loads configuration from file
 func Load() (*Config, error) {
 	if cfg != nil {
 		return cfg, nil
 	}
-	
+
 	cfg = &Config{}
-	
-	// Set defaults
-	viper.SetDefault("ai.default_provider", "ollama")
-	viper.SetDefault("ai.default_model", "codellama:13b-instruct")
-	viper.SetDefault("ai.temperature", 0.7)
-	viper.SetDefault("ai.max_tokens", 2000)
-	viper.SetDefault("ai.stream", true)
-	
-	viper.SetDefault("providers.ollama.api_url", "http://localhost:11434")
-	viper.SetDefault("providers.ollama.model", "codellama:13b-instruct")
-	
-	viper.SetDefault("ui.theme", "dark")
-	viper.SetDefault("ui.show_spinner", true)
-	
-	viper.SetDefault("defaults.context_depth", 3)
-	viper.SetDefault("defaults.auto_backup", true)
-	viper.SetDefault("defaults.backup_dir", ".weaver_backups")
-	
+
+	setDefaults()
+
 	// Try to read config file
-	if err := viper.ReadInConfig(); err == nil {
-		if err := viper.Unmarshal(cfg); err != nil {
-			return nil, err
-		}
-	} else {
+	if err := viper.ReadInConfig(); err != nil {
 		// Create default config file if it doesn't exist
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			if err := createDefaultConfig(); err != nil {
 				return nil, err
 			}
 		}
+	} else if err := loadLegacyLayout(viper.ConfigFileUsed()); err != nil {
+		return nil, err
 	}
-	
-	// Override with environment variables
+
+	// The project's .weaver.yml takes precedence over the user config
+	if err := loadProjectConfig(); err != nil {
+		return nil, err
+	}
+
+	// Flags and environment variables bound to viper take precedence over the file
+	if err := decode(expandSettings(viper.AllSettings(), ""), cfg); err != nil {
+		return nil, err
+	}
+	applyEnvOverrides(cfg)
+
+	return cfg, nil
+}
+
+// applyEnvOverrides applies the environment variables that are not named after a setting
+func applyEnvOverrides(cfg *Config) {
 	if apiKey := os.Getenv("OLLAMA_API_KEY"); apiKey != "" {
 		if cfg.Providers == nil {
 			cfg.Providers = make(map[string]ProviderConfig)
@@ -98,52 +153,124 @@ func Load() (*Config, error) {
 		ollamaConfig.APIKey = apiKey
 		cfg.Providers["ollama"] = ollamaConfig
 	}
-	
-	return cfg, nil
 }
 
-func createDefaultConfig() error {
+func setDefaults() {
+	viper.SetDefault("version", CurrentVersion)
+
+	viper.SetDefault("ai.default_provider", "ollama")
+	viper.SetDefault("ai.temperature", 0.7)
+	viper.SetDefault("ai.max_tokens", 2000)
+	viper.SetDefault("ai.stream", true)
+
+	viper.SetDefault("providers.ollama.api_url", "http://localhost:11434")
+	viper.SetDefault("providers.ollama.model", "codellama:13b-instruct")
+
+	viper.SetDefault("ui.theme", "dark")
+	viper.SetDefault("ui.show_spinner", true)
+	viper.SetDefault("ui.colors.added", "green")
+	viper.SetDefault("ui.colors.removed", "red")
+	viper.SetDefault("ui.colors.modified", "yellow")
+	viper.SetDefault("ui.diff.layout", "auto")
+	viper.SetDefault("ui.diff.context", 3)
+
+	viper.SetDefault("defaults.context_depth", 3)
+	viper.SetDefault("defaults.auto_backup", true)
+	viper.SetDefault("defaults.backup_dir", ".weaver_backups")
+	viper.SetDefault("defaults.repair_attempts", 2)
+
+	viper.SetDefault("index.embedding_model", "nomic-embed-text")
+	viper.SetDefault("index.chunk_lines", 60)
+}
+
+// loadLegacyLayout translates a config file written in an older layout so
+// that it can be used without migrating it first
+func loadLegacyLayout(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if !NeedsMigration(raw) {
+		return nil
+	}
+
+	migrated, unmapped := Migrate(raw)
+	legacy = &MigrationResult{Path: path, Unmapped: unmapped}
+	return viper.MergeConfigMap(migrated)
+}
+
+// Legacy reports the config file that was loaded from an older layout,
+// or nil when the file already uses the current schema
+func Legacy() *MigrationResult {
+	return legacy
+}
+
+// DefaultPath returns the location of the user configuration file
+func DefaultPath() (string, error) {
 	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "weaver", "config.yaml"), nil
+}
+
+func createDefaultConfig() error {
+	configFile, err := DefaultPath()
 	if err != nil {
 		return err
 	}
-	
-	configDir := filepath.Join(home, ".config", "weaver")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return err
 	}
-	
-	configFile := filepath.Join(configDir, "config.yaml")
-	
-	defaultConfig := `# CodeWeaver Configuration
+
+	return os.WriteFile(configFile, []byte(DefaultYAML), 0644)
+}
+
+// DefaultYAML is the configuration written on first run
+const DefaultYAML = `# CodeWeaver Configuration
+version: 1
 
 # AI Settings
 ai:
   default_provider: ollama
-  default_model: codellama:13b-instruct
   temperature: 0.7
   max_tokens: 2000
   stream: true
+  timeout: 120s
 
 # Provider Settings
 providers:
   ollama:
     api_url: http://localhost:11434
     model: codellama:13b-instruct
-    
-  # Uncomment and configure to use other providers
+
+  # Uncomment and configure to use other providers. Any value may use
+  # ${VAR} or ${VAR:-default}; API keys may also be read from a file
+  # (file:~/.secrets/claude) or a credential helper (cmd:pass show claude).
   # claude:
   #   api_key: ${CLAUDE_API_KEY}
   #   model: claude-3-opus-20240229
-  #   
+  #
   # openai:
   #   api_key: ${OPENAI_API_KEY}
   #   model: gpt-4-turbo-preview
-  #   
+  #
   # gemini:
   #   api_key: ${GEMINI_API_KEY}
   #   model: gemini-pro
 
+# Model aliases usable with --model
+models:
+  fast: codellama:7b
+  balanced: codellama:13b-instruct
+  quality: codellama:34b
+
 # UI Settings
 ui:
   theme: dark
@@ -152,16 +279,37 @@ ui:
     added: green
     removed: red
     modified: yellow
+  diff:
+    layout: auto # auto, unified or side-by-side
+    context: 3   # unchanged lines shown around each change
 
 # Default Settings
 defaults:
   context_depth: 3
-  auto_backup: true
-  backup_dir: .weaver_backups
+  auto_backup: true # record written files so that 'weaver undo' can restore them
+  backup_dir: .weaver_backups # change journal, relative to the project root
+  repair_attempts: 2 # times code with syntax errors is sent back to the model
+
+# Language-specific settings
+languages:
+  python:
+    test_framework: pytest
+    doc_style: google
+  javascript:
+    test_framework: jest
+    doc_style: jsdoc
+  go:
+    test_framework: testing
+    doc_style: godoc
+  php:
+    test_framework: phpunit
+    doc_style: phpdoc
+
+# Semantic code index (weaver index, weaver ask)
+index:
+  embedding_model: nomic-embed-text
+  chunk_lines: 60
 `
-	
-	return os.WriteFile(configFile, []byte(defaultConfig), 0644)
-}
 
 // Get returns the current configuration
 func Get() *Config {
@@ -169,4 +317,4 @@ func Get() *Config {
 		Load()
 	}
 	return cfg
-}
\ No newline at end of file
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a config file held as a YAML node tree so that edits keep the
+// user's comments and key order
+type Document struct {
+	path string
+	root yaml.Node
+}
+
+// ReadDocument loads the config file at path. A missing file yields an empty document.
+func ReadDocument(path string) (*Document, error) {
+	doc := &Document{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc.root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if doc.root.Kind == 0 {
+		doc.root = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode}},
+		}
+	}
+	if doc.mapping().Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: top level must be a mapping", path)
+	}
+
+	return doc, nil
+}
+
+// Path returns the file the document was read from
+func (d *Document) Path() string {
+	return d.path
+}
+
+// Get returns the node stored under a dotted key
+func (d *Document) Get(key string) (*yaml.Node, bool) {
+	node := d.mapping()
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil, false
+		}
+		_, value := lookup(node, part)
+		if value == nil {
+			return nil, false
+		}
+		node = value
+	}
+	return node, true
+}
+
+// Set stores value under a dotted key. The value is parsed as YAML, so
+// numbers and booleans keep their type. Missing sections are created.
+func (d *Document) Set(key, value string) error {
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return fmt.Errorf("invalid value %q: %w", value, err)
+	}
+	newValue := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: ""}
+	if len(parsed.Content) > 0 {
+		newValue = parsed.Content[0]
+	}
+
+	parts := strings.Split(key, ".")
+	node := d.mapping()
+	for i, part := range parts {
+		_, existing := lookup(node, part)
+
+		if i == len(parts)-1 {
+			if existing != nil {
+				// Keep the comments attached to the old value
+				newValue.LineComment = existing.LineComment
+				newValue.HeadComment = existing.HeadComment
+				newValue.FootComment = existing.FootComment
+				*existing = *newValue
+			} else {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, newValue)
+			}
+			return nil
+		}
+
+		if existing == nil {
+			existing = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, existing)
+		}
+		if existing.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a section", strings.Join(parts[:i+1], "."))
+		}
+		node = existing
+	}
+
+	return nil
+}
+
+// Unset removes a dotted key. It reports whether the key was present.
+func (d *Document) Unset(key string) bool {
+	parts := strings.Split(key, ".")
+	parent := d.mapping()
+	if len(parts) > 1 {
+		var ok bool
+		if parent, ok = d.Get(strings.Join(parts[:len(parts)-1], ".")); !ok || parent.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == parts[len(parts)-1] {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes encodes the document
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&d.root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Save writes the document back to its file, keeping the file's permissions
+func (d *Document) Save() error {
+	data, err := d.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(d.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(d.path, data, mode)
+}
+
+func (d *Document) mapping() *yaml.Node {
+	return d.root.Content[0]
+}
+
+// lookup finds key in a mapping node and returns its key and value nodes
+func lookup(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Secret references accepted by ResolveSecret
+const (
+	secretFilePrefix = "file:"
+	secretCmdPrefix  = "cmd:"
+)
+
+// Expand replaces ${VAR} and ${VAR:-default} in s with the value of the
+// environment variable VAR. An unset or empty VAR expands to the default,
+// or to the empty string when there is none.
+func Expand(s string) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		out.WriteString(s[:start])
+		name, fallback, _ := strings.Cut(s[start+2:end], ":-")
+		if value := os.Getenv(name); value != "" {
+			out.WriteString(value)
+		} else {
+			out.WriteString(fallback)
+		}
+		s = s[end+1:]
+	}
+	out.WriteString(s)
+	return out.String()
+}
+
+// expandSettings expands ${VAR} references in the string settings of a
+// nested settings map, except in values from the project's .weaver.yml: a
+// repository could otherwise read the user's environment into a prompt or
+// a request header. prefix is the dotted key of settings.
+func expandSettings(settings map[string]interface{}, prefix string) map[string]interface{} {
+	expanded := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		fullKey := strings.ToLower(prefix + key)
+		switch value := value.(type) {
+		case map[string]interface{}:
+			expanded[key] = expandSettings(value, fullKey+".")
+		case string:
+			expanded[key] = ExpandSetting(fullKey, value)
+		case []interface{}:
+			items := make([]interface{}, len(value))
+			for i, item := range value {
+				if text, ok := item.(string); ok {
+					item = ExpandSetting(fullKey, text)
+				}
+				items[i] = item
+			}
+			expanded[key] = items
+		default:
+			expanded[key] = value
+		}
+	}
+	return expanded
+}
+
+// ExpandSetting expands ${VAR} references in value, the value of the
+// setting key, unless it comes from the project's .weaver.yml
+func ExpandSetting(key, value string) string {
+	if SourceOf(key) == SourceProject {
+		return value
+	}
+	return Expand(value)
+}
+
+// decode converts nested settings, as returned by viper, into c
+func decode(settings map[string]interface{}, c *Config) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Result:           c,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(settings)
+}
+
+var (
+	secretsMu sync.Mutex
+	secrets   = make(map[string]string)
+)
+
+// ResolveSecret returns the value of the secret setting key. A value of the
+// form "file:<path>" is read from that file and "cmd:<command>" is the output
+// of the command, such as a credential helper. Other values are returned as is.
+//
+// References are only resolved for settings from the user's own
+// configuration or the environment, never from a project's .weaver.yml,
+// which may come from someone else's repository.
+func ResolveSecret(key, value string) (string, error) {
+	isReference := strings.HasPrefix(value, secretFilePrefix) || strings.HasPrefix(value, secretCmdPrefix)
+	if isReference && SourceOf(key) == SourceProject {
+		return "", fmt.Errorf("%s: file: and cmd: references are not allowed in %s", key, ProjectFileName)
+	}
+
+	switch {
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimSpace(strings.TrimPrefix(value, secretFilePrefix))
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case strings.HasPrefix(value, secretCmdPrefix):
+		secretsMu.Lock()
+		defer secretsMu.Unlock()
+		if secret, ok := secrets[value]; ok {
+			return secret, nil
+		}
+
+		command := strings.TrimSpace(strings.TrimPrefix(value, secretCmdPrefix))
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+		cmd.Stderr = os.Stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("credential helper for %s failed: %w", key, err)
+		}
+
+		secret := strings.TrimSpace(string(output))
+		secrets[value] = secret
+		return secret, nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MigrationResult describes the outcome of migrating a config file
+type MigrationResult struct {
+	Path       string
+	BackupPath string
+	Unmapped   []string // dotted keys that have no place in the current schema
+}
+
+// sectionOrder is the order top-level sections are written in
+var sectionOrder = []string{"version", "ai", "providers", "models", "ui", "defaults", "languages", "index"}
+
+// legacyKeys maps keys of the old "ollama" layout to their current location
+var legacyKeys = map[string]string{
+	"ollama.api_url":     "providers.ollama.api_url",
+	"ollama.api_key":     "providers.ollama.api_key",
+	"ollama.model":       "providers.ollama.model",
+	"ollama.temperature": "ai.temperature",
+	"ollama.max_tokens":  "ai.max_tokens",
+	"ollama.timeout":     "ai.timeout",
+	"ui.diff_colors":     "ui.colors",
+}
+
+// legacyDefaultModel is the ai.default_model older releases wrote to every
+// config file. It is dropped when migrating, as it would override the model
+// of any other provider the user switches to.
+const legacyDefaultModel = "codellama:13b-instruct"
+
+// LegacyKey returns the current location of a key from an older layout.
+// Keys that were not renamed are returned unchanged.
+func LegacyKey(key string) string {
+	for old, current := range legacyKeys {
+		if key == old {
+			return current
+		}
+		if strings.HasPrefix(key, old+".") {
+			return current + strings.TrimPrefix(key, old)
+		}
+	}
+	return key
+}
+
+// NeedsMigration reports whether raw uses a layout older than CurrentVersion
+func NeedsMigration(raw map[string]interface{}) bool {
+	version, _ := raw["version"].(int)
+	return version < CurrentVersion
+}
+
+// Migrate converts a configuration in one of the older layouts to the
+// current schema. It returns the migrated settings and the dotted keys that
+// could not be mapped.
+func Migrate(raw map[string]interface{}) (map[string]interface{}, []string) {
+	migrated := map[string]interface{}{"version": CurrentVersion}
+	var unmapped []string
+
+	for _, key := range sortedKeys(raw) {
+		value := raw[key]
+		switch key {
+		case "version":
+			continue
+		case "ollama":
+			section, ok := value.(map[string]interface{})
+			if !ok {
+				unmapped = append(unmapped, key)
+				continue
+			}
+			for _, subKey := range sortedKeys(section) {
+				oldKey := key + "." + subKey
+				newKey := LegacyKey(oldKey)
+				if newKey == oldKey {
+					unmapped = append(unmapped, oldKey)
+					continue
+				}
+				setPath(migrated, newKey, section[subKey])
+			}
+		case "ai", "providers", "models", "ui", "defaults", "languages", "index":
+			section, ok := value.(map[string]interface{})
+			if !ok {
+				unmapped = append(unmapped, key)
+				continue
+			}
+			for _, subKey := range sortedKeys(section) {
+				if key == "ai" && subKey == "default_model" && section[subKey] == legacyDefaultModel {
+					continue
+				}
+				setPath(migrated, LegacyKey(key+"."+subKey), section[subKey])
+			}
+		default:
+			unmapped = append(unmapped, key)
+		}
+	}
+
+	return migrated, unmapped
+}
+
+// MigrateFile rewrites the config file at path in the current layout. The
+// original is kept next to it as a timestamped backup. Files that already
+// use the current layout are left untouched and a nil result is returned.
+func MigrateFile(path string) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if !NeedsMigration(raw) {
+		return nil, nil
+	}
+
+	migrated, unmapped := Migrate(raw)
+	output, err := encodeOrdered(migrated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	result := &MigrationResult{
+		Path:       path,
+		BackupPath: fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405")),
+		Unmapped:   unmapped,
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(result.BackupPath, data, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.WriteFile(path, output, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return result, nil
+}
+
+// encodeOrdered writes the sections in sectionOrder rather than map order
+func encodeOrdered(settings map[string]interface{}) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode, HeadComment: "CodeWeaver Configuration"}
+
+	for _, key := range sectionOrder {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return nil, err
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+	}
+
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(out.String()), nil
+}
+
+// setPath stores value under a dotted key, creating intermediate maps
+func setPath(settings map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+
+	// Merge sections such as ui.colors instead of replacing them
+	if section, ok := value.(map[string]interface{}); ok {
+		if existing, ok := current[parts[len(parts)-1]].(map[string]interface{}); ok {
+			for k, v := range section {
+				existing[k] = v
+			}
+			return
+		}
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
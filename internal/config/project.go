@@ -0,0 +1,247 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the per-project config file. It is searched for from the
+// working directory upward and merged over the user configuration.
+const ProjectFileName = ".weaver.yml"
+
+// ProjectConfig is the project config file in effect
+type ProjectConfig struct {
+	Path      string // the .weaver.yml file
+	Root      string // the directory containing it
+	Overrides []Override
+	Ignored   []string // user-only settings found in the file, which are not applied
+
+	keys map[string]bool // dotted keys set by the file
+}
+
+// userOnlyKeys are settings a project file may not set, as patterns over
+// slash-separated keys. A repository could otherwise send the user's
+// prompts and credentials to a server of its choosing, or have weaver run
+// a command of its choosing as the formatter.
+var userOnlyKeys = []string{"providers/*/api_url", "languages/*/formatter"}
+
+// UserOnly reports whether the setting key can only be set in the user
+// configuration
+func UserOnly(key string) bool {
+	for _, pattern := range userOnlyKeys {
+		if ok, _ := path.Match(pattern, strings.ReplaceAll(key, ".", "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Override holds settings that apply only to the files under Path.
+// Path is relative to the project root and is either a directory
+// (e.g. "frontend/") or a glob (e.g. "*.test.ts").
+type Override struct {
+	Path     string
+	Settings map[string]interface{}
+}
+
+var project *ProjectConfig
+
+// Project returns the project config file in effect, or nil when there is none
+func Project() *ProjectConfig {
+	return project
+}
+
+// FindProjectFile searches dir and its parents for ProjectFileName
+func FindProjectFile(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		candidate := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig merges the project config file over the user
+// configuration. Per-path overrides are kept aside for ForPath.
+func loadProjectConfig() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	file, ok := FindProjectFile(dir)
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	p := &ProjectConfig{Path: file, Root: filepath.Dir(file), keys: make(map[string]bool)}
+
+	if list, ok := raw["overrides"]; ok {
+		entries, ok := list.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: overrides must be a list", file)
+		}
+		for _, entry := range entries {
+			settings, ok := entry.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: every override must be a mapping", file)
+			}
+			overridePath, _ := settings["path"].(string)
+			if overridePath == "" {
+				return fmt.Errorf("%s: every override needs a path", file)
+			}
+			delete(settings, "path")
+			for _, key := range removeUserOnly(settings, "") {
+				p.Ignored = append(p.Ignored, fmt.Sprintf("overrides[%s].%s", overridePath, key))
+			}
+			p.Overrides = append(p.Overrides, Override{Path: overridePath, Settings: settings})
+		}
+		delete(raw, "overrides")
+	}
+	delete(raw, "version")
+	p.Ignored = append(removeUserOnly(raw, ""), p.Ignored...)
+
+	for key := range flatten(raw, "") {
+		p.keys[key] = true
+	}
+
+	project = p
+	return viper.MergeConfigMap(raw)
+}
+
+// ForPath returns the configuration that applies to a file: the loaded
+// configuration with the matching project overrides applied. Environment
+// variables and flags still take precedence over overrides.
+func ForPath(file string) *Config {
+	base := Get()
+	if project == nil || file == "" {
+		return base
+	}
+	matched := project.overridesFor(file)
+	if len(matched) == 0 {
+		return base
+	}
+
+	v := viper.New()
+	if err := v.MergeConfigMap(expandSettings(viper.AllSettings(), "")); err != nil {
+		return base
+	}
+	for _, override := range matched {
+		for key, value := range flatten(override.Settings, "") {
+			if source := SourceOf(key); source == SourceEnv || source == SourceFlag {
+				continue
+			}
+			v.Set(key, value)
+		}
+	}
+
+	c := &Config{}
+	if err := decode(v.AllSettings(), c); err != nil {
+		return base
+	}
+	applyEnvOverrides(c)
+	return c
+}
+
+// overridesFor returns the overrides matching file, in file order
+func (p *ProjectConfig) overridesFor(file string) []Override {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(p.Root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	var matched []Override
+	for _, override := range p.Overrides {
+		if matchOverride(override.Path, rel) {
+			matched = append(matched, override)
+		}
+	}
+	return matched
+}
+
+// matchOverride reports whether the project-relative path rel falls under pattern
+func matchOverride(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+
+	if strings.ContainsAny(pattern, "*?[") {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		// Patterns without a slash match the file name at any depth
+		if !strings.Contains(pattern, "/") {
+			ok, _ := path.Match(pattern, path.Base(rel))
+			return ok
+		}
+		return false
+	}
+
+	pattern = strings.TrimSuffix(pattern, "/")
+	return rel == pattern || strings.HasPrefix(rel, pattern+"/")
+}
+
+// removeUserOnly deletes the user-only settings from a nested settings map
+// and returns their dotted keys
+func removeUserOnly(settings map[string]interface{}, prefix string) []string {
+	var removed []string
+	for key, value := range settings {
+		fullKey := strings.ToLower(prefix + key)
+		if section, ok := value.(map[string]interface{}); ok {
+			removed = append(removed, removeUserOnly(section, fullKey+".")...)
+			continue
+		}
+		if UserOnly(fullKey) {
+			delete(settings, key)
+			removed = append(removed, fullKey)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// flatten returns the leaves of a nested settings map keyed by dotted,
+// lower-case paths, the way viper names them
+func flatten(settings map[string]interface{}, prefix string) map[string]interface{} {
+	leaves := make(map[string]interface{})
+	for key, value := range settings {
+		fullKey := strings.ToLower(prefix + key)
+		if section, ok := value.(map[string]interface{}); ok {
+			for k, v := range flatten(section, fullKey+".") {
+				leaves[k] = v
+			}
+			continue
+		}
+		leaves[fullKey] = value
+	}
+	return leaves
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes the environment variables that override settings,
+// e.g. WEAVER_AI_DEFAULT_MODEL
+const EnvPrefix = "WEAVER"
+
+// Source identifies the layer an effective setting comes from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceProject Source = "project"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// flagKeys maps settings to the global flags that override them
+var flagKeys = map[string]string{
+	"verbose":                  "verbose",
+	"debug":                    "debug",
+	"ai.default_provider":      "provider",
+	"ai.default_model":         "model",
+	"ai.temperature":           "temperature",
+	"providers.ollama.api_url": "api-url",
+}
+
+// boundFlags are the global flags passed to BindFlags
+var boundFlags *pflag.FlagSet
+
+// BindFlags binds the global flags to the settings they override
+func BindFlags(flags *pflag.FlagSet) error {
+	boundFlags = flags
+	for key, name := range flagKeys {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("flag --%s is not defined", name)
+		}
+		if err := viper.BindPFlag(key, flag); err != nil {
+			return fmt.Errorf("error binding flag %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// EnvVar returns the environment variable that overrides key
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// SourceOf reports which layer supplies the effective value of key
+func SourceOf(key string) Source {
+	if name, ok := flagKeys[key]; ok && boundFlags != nil {
+		if flag := boundFlags.Lookup(name); flag != nil && flag.Changed {
+			return SourceFlag
+		}
+	}
+	if _, ok := os.LookupEnv(EnvVar(key)); ok {
+		return SourceEnv
+	}
+	if key == "providers.ollama.api_key" && os.Getenv("OLLAMA_API_KEY") != "" {
+		return SourceEnv
+	}
+	if project != nil && project.keys[key] {
+		return SourceProject
+	}
+	if viper.InConfig(key) {
+		return SourceFile
+	}
+	return SourceDefault
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validate checks a user config file against the schema. It returns every
+// problem found, or nil when the file is valid.
+func Validate(data []byte) []error {
+	return validate(data, false)
+}
+
+// ValidateProject checks a project .weaver.yml against the schema
+func ValidateProject(data []byte) []error {
+	return validate(data, true)
+}
+
+func validate(data []byte, isProject bool) []error {
+	// ${VAR} references in a project file are used as written
+	if !isProject {
+		data = expandText(data)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return []error{err}
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	// Project files never used the old layouts, so their version is optional
+	if !isProject && NeedsMigration(raw) {
+		return []error{errors.New("file uses an old config layout; run 'weaver config migrate'")}
+	}
+
+	var errs []error
+
+	// Unknown keys and values of the wrong type
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && err != io.EOF {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				errs = append(errs, errors.New(msg))
+			}
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Version > CurrentVersion {
+		errs = append(errs, fmt.Errorf("version %d is newer than this release supports (%d)", c.Version, CurrentVersion))
+	}
+	if isProject {
+		errs = append(errs, checkUserOnly(raw)...)
+	}
+	if !isProject && len(c.Overrides) > 0 {
+		errs = append(errs, fmt.Errorf("overrides are only read from a project %s", ProjectFileName))
+	}
+	for i, override := range c.Overrides {
+		prefix := fmt.Sprintf("overrides[%d]", i)
+		if override.Path == "" {
+			errs = append(errs, fmt.Errorf("%s needs a path", prefix))
+		} else {
+			prefix = fmt.Sprintf("overrides[%s]", override.Path)
+		}
+		if len(override.Overrides) > 0 {
+			errs = append(errs, fmt.Errorf("%s: overrides cannot be nested", prefix))
+		}
+		errs = append(errs, checkValues(&override.Config, prefix+".")...)
+	}
+
+	// A project file usually names a provider configured in the user file
+	if !isProject && c.AI.DefaultProvider != "" && len(c.Providers) > 0 {
+		if _, ok := c.Providers[c.AI.DefaultProvider]; !ok {
+			errs = append(errs, fmt.Errorf("ai.default_provider %q has no entry under providers", c.AI.DefaultProvider))
+		}
+	}
+
+	return append(errs, checkValues(&c, "")...)
+}
+
+// checkUserOnly reports the settings of a project file, including its
+// overrides, that can only be set in the user configuration
+func checkUserOnly(raw map[string]interface{}) []error {
+	var errs []error
+	report := func(settings map[string]interface{}, prefix string) {
+		var keys []string
+		for key := range flatten(settings, "") {
+			if UserOnly(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			errs = append(errs, fmt.Errorf("%s%s can only be set in the user config, not in %s", prefix, key, ProjectFileName))
+		}
+	}
+
+	overrides, _ := raw["overrides"].([]interface{})
+	settings := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		if key != "overrides" {
+			settings[key] = value
+		}
+	}
+	report(settings, "")
+	for _, entry := range overrides {
+		if override, ok := entry.(map[string]interface{}); ok {
+			report(override, fmt.Sprintf("overrides[%v].", override["path"]))
+		}
+	}
+	return errs
+}
+
+// checkValues reports settings outside their valid range. prefix is
+// prepended to the key names in the messages.
+func checkValues(c *Config, prefix string) []error {
+	var errs []error
+	if c.AI.Temperature < 0 || c.AI.Temperature > 2 {
+		errs = append(errs, fmt.Errorf("%sai.temperature must be between 0 and 2, got %v", prefix, c.AI.Temperature))
+	}
+	if c.AI.MaxTokens < 0 {
+		errs = append(errs, fmt.Errorf("%sai.max_tokens must not be negative, got %d", prefix, c.AI.MaxTokens))
+	}
+	if c.AI.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("%sai.timeout must not be negative, got %s", prefix, c.AI.Timeout))
+	}
+	for name, provider := range c.Providers {
+		if provider.Temperature < 0 || provider.Temperature > 2 {
+			errs = append(errs, fmt.Errorf("%sproviders.%s.temperature must be between 0 and 2, got %v", prefix, name, provider.Temperature))
+		}
+		if provider.MaxTokens < 0 {
+			errs = append(errs, fmt.Errorf("%sproviders.%s.max_tokens must not be negative, got %d", prefix, name, provider.MaxTokens))
+		}
+	}
+	if c.Index.ChunkLines < 0 {
+		errs = append(errs, fmt.Errorf("%sindex.chunk_lines must not be negative, got %d", prefix, c.Index.ChunkLines))
+	}
+	colors := [][2]string{{"added", c.UI.Colors.Added}, {"removed", c.UI.Colors.Removed}, {"modified", c.UI.Colors.Modified}}
+	for _, color := range colors {
+		if color[1] != "" && !slices.Contains(ColorNames, color[1]) {
+			errs = append(errs, fmt.Errorf("%sui.colors.%s must be one of %s, got %q", prefix, color[0], strings.Join(ColorNames, ", "), color[1]))
+		}
+	}
+	if c.UI.Diff.Layout != "" && !slices.Contains(DiffLayouts, c.UI.Diff.Layout) {
+		errs = append(errs, fmt.Errorf("%sui.diff.layout must be one of %s, got %q", prefix, strings.Join(DiffLayouts, ", "), c.UI.Diff.Layout))
+	}
+	if c.UI.Diff.Context < 0 {
+		errs = append(errs, fmt.Errorf("%sui.diff.context must not be negative, got %d", prefix, c.UI.Diff.Context))
+	}
+	if c.Defaults.ContextDepth < 0 {
+		errs = append(errs, fmt.Errorf("%sdefaults.context_depth must not be negative, got %d", prefix, c.Defaults.ContextDepth))
+	}
+	if c.Defaults.RepairAttempts < 0 {
+		errs = append(errs, fmt.Errorf("%sdefaults.repair_attempts must not be negative, got %d", prefix, c.Defaults.RepairAttempts))
+	}
+
+	return errs
+}
+
+// expandText expands ${VAR} references outside comments so that values such
+// as "timeout: ${WEAVER_TIMEOUT:-120s}" are checked as what they expand to
+func expandText(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines[i] = Expand(line)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
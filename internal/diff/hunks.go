@@ -0,0 +1,314 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// DefaultContext is the number of unchanged lines shown around a change
+const DefaultContext = 3
+
+// Kinds of hunk lines, as in unified diffs
+const (
+	Context = ' '
+	Removed = '-'
+	Added   = '+'
+)
+
+// noNewline marks a line without a line ending, as in unified diffs
+const noNewline = "\\ No newline at end of file"
+
+// Line is a line of a hunk. Text keeps its line ending, if any.
+type Line struct {
+	Kind byte
+	Text string
+}
+
+// Hunk is a group of nearby changes with the unchanged lines around them
+type Hunk struct {
+	OldStart int // 0-based index of the first line in the original
+	OldLines int
+	NewStart int // 0-based index of the first line in the modified text
+	NewLines int
+	Lines    []Line
+}
+
+// Hunks compares original and modified line by line and groups the changes
+// into hunks with up to context unchanged lines around them. Changes closer
+// than twice the context share a hunk.
+func Hunks(original, modified string, context int) []Hunk {
+	lines := diffLines(original, modified)
+
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Context {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].Kind != Context {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Kind == Context {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, run)
+				break
+			}
+			end = run
+		}
+
+		hunks = append(hunks, newHunk(lines, start, end))
+		i = end
+	}
+	return hunks
+}
+
+// diffLines returns the lines of both texts marked as unchanged, removed or
+// added
+func diffLines(original, modified string) []Line {
+	return diffUnits(splitLines(original), splitLines(modified))
+}
+
+// splitLines splits text into lines that keep their line endings
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffUnits diffs two sequences of lines or words. Each distinct unit is
+// diffed as a single rune; go-diff's own DiffLinesToChars encodes lines as
+// decimal indexes, which the character diff then splits apart.
+func diffUnits(a, b []string) []Line {
+	index := make(map[string]rune)
+	var table []string
+	encode := func(units []string) []rune {
+		runes := make([]rune, 0, len(units))
+		for _, unit := range units {
+			r, ok := index[unit]
+			if !ok {
+				// Skip the surrogate range, which does not survive conversion to a string
+				r = rune(len(table))
+				if r >= 0xD800 {
+					r += 0x800
+				}
+				index[unit] = r
+				table = append(table, unit)
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+	decode := func(r rune) string {
+		if r >= 0xE000 {
+			r -= 0x800
+		}
+		return table[r]
+	}
+
+	dmp := diffmatchpatch.New()
+	var units []Line
+	for _, d := range dmp.DiffMainRunes(encode(a), encode(b), false) {
+		kind := byte(Context)
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			kind = Removed
+		case diffmatchpatch.DiffInsert:
+			kind = Added
+		}
+		for _, r := range d.Text {
+			units = append(units, Line{Kind: kind, Text: decode(r)})
+		}
+	}
+	return units
+}
+
+// newHunk makes a hunk of lines[start:end], counting the lines before it
+func newHunk(lines []Line, start, end int) Hunk {
+	var h Hunk
+	for _, l := range lines[:start] {
+		if l.Kind != Added {
+			h.OldStart++
+		}
+		if l.Kind != Removed {
+			h.NewStart++
+		}
+	}
+	h.Lines = append([]Line(nil), lines[start:end]...)
+	h.count()
+	return h
+}
+
+// count sets OldLines and NewLines from the hunk's lines
+func (h *Hunk) count() {
+	h.OldLines, h.NewLines = 0, 0
+	for _, l := range h.Lines {
+		if l.Kind != Added {
+			h.OldLines++
+		}
+		if l.Kind != Removed {
+			h.NewLines++
+		}
+	}
+}
+
+// Changes returns the number of removed and added lines
+func (h Hunk) Changes() (removed, added int) {
+	for _, l := range h.Lines {
+		switch l.Kind {
+		case Removed:
+			removed++
+		case Added:
+			added++
+		}
+	}
+	return removed, added
+}
+
+// Header returns the hunk's unified-diff header, e.g. "@@ -10,7 +10,8 @@"
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart+1, h.OldLines, h.NewStart+1, h.NewLines)
+}
+
+// String returns the hunk in unified-diff format
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header() + "\n")
+	for _, l := range h.Lines {
+		b.WriteByte(l.Kind)
+		b.WriteString(strings.TrimSuffix(l.Text, "\n"))
+		b.WriteString("\n")
+		if !strings.HasSuffix(l.Text, "\n") {
+			b.WriteString(noNewline + "\n")
+		}
+	}
+	return b.String()
+}
+
+// CanSplit reports whether the hunk holds changes separated by unchanged lines
+func (h Hunk) CanSplit() bool {
+	return len(h.Split()) > 1
+}
+
+// Split divides the hunk at the unchanged lines between its changes. The
+// unchanged lines are shared out, so the pieces can be applied separately.
+func (h Hunk) Split() []Hunk {
+	// Find the runs of unchanged lines between changes
+	type run struct{ start, end int }
+	var gaps []run
+	seenChange := false
+	for i := 0; i < len(h.Lines); i++ {
+		if h.Lines[i].Kind != Context {
+			seenChange = true
+			continue
+		}
+		j := i
+		for j < len(h.Lines) && h.Lines[j].Kind == Context {
+			j++
+		}
+		if seenChange && j < len(h.Lines) {
+			gaps = append(gaps, run{i, j})
+		}
+		i = j - 1
+	}
+	if len(gaps) == 0 {
+		return []Hunk{h}
+	}
+
+	var pieces []Hunk
+	start, oldStart, newStart := 0, h.OldStart, h.NewStart
+	for _, gap := range append(gaps, run{len(h.Lines), len(h.Lines)}) {
+		// The first half of a gap trails the previous piece, the rest leads the next
+		end := gap.start + (gap.end-gap.start+1)/2
+		piece := Hunk{OldStart: oldStart, NewStart: newStart, Lines: append([]Line(nil), h.Lines[start:end]...)}
+		piece.count()
+		pieces = append(pieces, piece)
+		start, oldStart, newStart = end, oldStart+piece.OldLines, newStart+piece.NewLines
+	}
+	return pieces
+}
+
+// Apply returns original with hunks applied. The hunks have to come from
+// the same original, in order and without overlapping, as Hunks and Split
+// return them.
+func Apply(original string, hunks []Hunk) string {
+	lines := splitLines(original)
+
+	var b strings.Builder
+	next := 0
+	for _, h := range hunks {
+		for ; next < h.OldStart && next < len(lines); next++ {
+			b.WriteString(lines[next])
+		}
+		for _, l := range h.Lines {
+			if l.Kind != Removed {
+				b.WriteString(l.Text)
+			}
+		}
+		next = h.OldStart + h.OldLines
+	}
+	for ; next < len(lines); next++ {
+		b.WriteString(lines[next])
+	}
+	return b.String()
+}
+
+// ParseEdit reads back a hunk edited as text in the format of String. The
+// unchanged and removed lines have to stay as they are, since they are what
+// the hunk replaces; added lines may be changed freely, and removed lines
+// may be kept by turning them into unchanged lines. Lines starting with #
+// are ignored.
+func (h Hunk) ParseEdit(text string) (Hunk, error) {
+	edited := Hunk{OldStart: h.OldStart, NewStart: h.NewStart}
+	for _, raw := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if strings.HasPrefix(raw, "#") || strings.HasPrefix(raw, "@@") || raw == noNewline {
+			continue
+		}
+		kind, content := byte(Context), ""
+		if raw != "" {
+			kind, content = raw[0], raw[1:]
+		}
+		switch kind {
+		case Context, Removed, Added:
+		default:
+			return h, fmt.Errorf("line %q does not start with ' ', '-' or '+'", raw)
+		}
+		edited.Lines = append(edited.Lines, Line{Kind: kind, Text: content + "\n"})
+	}
+
+	old := func(lines []Line) []string {
+		var out []string
+		for _, l := range lines {
+			if l.Kind != Added {
+				out = append(out, strings.TrimSuffix(l.Text, "\n"))
+			}
+		}
+		return out
+	}
+	if strings.Join(old(edited.Lines), "\n") != strings.Join(old(h.Lines), "\n") {
+		return h, fmt.Errorf("the unchanged and removed lines were changed, so the hunk no longer applies")
+	}
+
+	// Keep a missing newline at the end of the file
+	if n := len(h.Lines); n > 0 && !strings.HasSuffix(h.Lines[n-1].Text, "\n") && len(edited.Lines) > 0 {
+		last := &edited.Lines[len(edited.Lines)-1]
+		last.Text = strings.TrimSuffix(last.Text, "\n")
+	}
+	edited.count()
+	return edited, nil
+}
@@ -0,0 +1,280 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/pterm/pterm"
+)
+
+// tabWidth is the number of spaces a tab takes in the side-by-side layout
+const tabWidth = 4
+
+// segment is a piece of a line, marked when it differs from the other side
+type segment struct {
+	text    string
+	changed bool
+}
+
+// row is a line of the rendered diff: an unchanged line, or a removed and
+// an added line paired up so that the words that changed can be marked
+type row struct {
+	changed  bool
+	old, new int // line numbers, 0 on the side without a line
+	oldText  []segment
+	newText  []segment
+}
+
+// rows returns the rows of a hunk. Within a run of changes, the removed
+// lines are paired with the added lines in order.
+func rows(h Hunk) []row {
+	var rows []row
+	oldNum, newNum := h.OldStart+1, h.NewStart+1
+	for i := 0; i < len(h.Lines); {
+		if h.Lines[i].Kind == Context {
+			text := []segment{{text: trimEOL(h.Lines[i].Text)}}
+			rows = append(rows, row{old: oldNum, new: newNum, oldText: text, newText: text})
+			oldNum++
+			newNum++
+			i++
+			continue
+		}
+
+		var removed, added []string
+		for ; i < len(h.Lines) && h.Lines[i].Kind != Context; i++ {
+			if h.Lines[i].Kind == Removed {
+				removed = append(removed, trimEOL(h.Lines[i].Text))
+			} else {
+				added = append(added, trimEOL(h.Lines[i].Text))
+			}
+		}
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			r := row{changed: true}
+			switch {
+			case j < len(removed) && j < len(added):
+				r.oldText, r.newText = wordDiff(removed[j], added[j])
+			case j < len(removed):
+				r.oldText = []segment{{text: removed[j]}}
+			default:
+				r.newText = []segment{{text: added[j]}}
+			}
+			if j < len(removed) {
+				r.old = oldNum
+				oldNum++
+			}
+			if j < len(added) {
+				r.new = newNum
+				newNum++
+			}
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// wordDiff splits a removed and an added line into segments, marking the
+// words that differ. Lines with less than half in common are left unmarked,
+// since marking nearly everything does not help.
+func wordDiff(a, b string) (before, after []segment) {
+	units := diffUnits(words(a), words(b))
+	same := 0
+	for _, u := range units {
+		if u.Kind == Context {
+			same += len(u.Text)
+		}
+	}
+	if same*2 < max(len(a), len(b)) {
+		return []segment{{text: a}}, []segment{{text: b}}
+	}
+
+	add := func(segments []segment, text string, changed bool) []segment {
+		if n := len(segments); n > 0 && segments[n-1].changed == changed {
+			segments[n-1].text += text
+			return segments
+		}
+		return append(segments, segment{text: text, changed: changed})
+	}
+	for _, u := range units {
+		switch u.Kind {
+		case Context:
+			before = add(before, u.Text, false)
+			after = add(after, u.Text, false)
+		case Removed:
+			before = add(before, u.Text, true)
+		case Added:
+			after = add(after, u.Text, true)
+		}
+	}
+	return before, after
+}
+
+// words splits a line into words, runs of spaces and single other characters
+func words(line string) []string {
+	class := func(r rune) int {
+		switch {
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 0
+		}
+	}
+
+	var words []string
+	start := 0
+	prev := -1
+	for i, r := range line {
+		c := class(r)
+		if i > start && (c == 0 || c != prev) {
+			words = append(words, line[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(line) {
+		words = append(words, line[start:])
+	}
+	return words
+}
+
+// trimEOL removes the line ending from a line
+func trimEOL(line string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+}
+
+// renderer writes the rows of hunks in one of the layouts
+type renderer struct {
+	opts       Options
+	width      int
+	numWidth   int
+	sideBySide bool
+}
+
+// gap writes the marker for n collapsed unchanged lines
+func (r *renderer) gap(b *strings.Builder, n int) {
+	if n <= 0 {
+		return
+	}
+	lines := "lines"
+	if n == 1 {
+		lines = "line"
+	}
+	b.WriteString(pterm.Gray(fmt.Sprintf("%s ⋯ %d unchanged %s", strings.Repeat(" ", r.numWidth), n, lines)) + "\n")
+}
+
+// hunk writes the rows of a hunk
+func (r *renderer) hunk(b *strings.Builder, h Hunk) {
+	rows := rows(h)
+	if r.sideBySide {
+		for _, row := range rows {
+			r.sideBySideRow(b, row)
+		}
+		return
+	}
+
+	// Each run of changes lists the removed lines before the added ones
+	for i := 0; i < len(rows); {
+		if !rows[i].changed {
+			b.WriteString(" " + r.gutter(rows[i].old, rows[i].new) + paint(rows[i].newText, 0) + "\n")
+			i++
+			continue
+		}
+		j := i
+		for j < len(rows) && rows[j].changed {
+			j++
+		}
+		for _, row := range rows[i:j] {
+			if row.old > 0 {
+				b.WriteString(r.opts.Removed.Sprint("-"+r.gutter(row.old, 0)) + paint(row.oldText, r.opts.Removed) + "\n")
+			}
+		}
+		for _, row := range rows[i:j] {
+			if row.new > 0 {
+				b.WriteString(r.opts.Added.Sprint("+"+r.gutter(0, row.new)) + paint(row.newText, r.opts.Added) + "\n")
+			}
+		}
+		i = j
+	}
+}
+
+// gutter returns the line numbers in front of a line in the unified layout
+func (r *renderer) gutter(oldNum, newNum int) string {
+	return r.number(oldNum) + " " + r.number(newNum) + " │ "
+}
+
+// number returns a line number padded to the width of the largest one, or
+// blanks for 0
+func (r *renderer) number(n int) string {
+	if n == 0 {
+		return strings.Repeat(" ", r.numWidth)
+	}
+	return fmt.Sprintf("%*d", r.numWidth, n)
+}
+
+// sideBySideRow writes a row with the original on the left and the
+// modified version on the right
+func (r *renderer) sideBySideRow(b *strings.Builder, row row) {
+	// Each side is a marker, the line number, a space and the text
+	column := (r.width - 3) / 2
+	textWidth := max(column-r.numWidth-2, 10)
+
+	if !row.changed {
+		left := " " + pterm.Gray(r.number(row.old)) + " " + fit(row.oldText, textWidth, 0)
+		right := " " + pterm.Gray(r.number(row.new)) + " " + fit(row.newText, textWidth, 0)
+		b.WriteString(left + " │ " + strings.TrimRight(right, " ") + "\n")
+		return
+	}
+
+	left := strings.Repeat(" ", textWidth+r.numWidth+2)
+	if row.old > 0 {
+		left = r.opts.Removed.Sprint("-"+r.number(row.old)+" ") + fit(row.oldText, textWidth, r.opts.Removed)
+	}
+	right := ""
+	if row.new > 0 {
+		right = r.opts.Added.Sprint("+"+r.number(row.new)+" ") + fit(row.newText, textWidth, r.opts.Added)
+	}
+	separator := " │ "
+	if row.old > 0 && row.new > 0 {
+		separator = r.opts.Modified.Sprint(separator)
+	}
+	b.WriteString(left + separator + strings.TrimRight(right, " ") + "\n")
+}
+
+// fit paints segments cut or padded to width columns, expanding tabs
+func fit(segments []segment, width int, color pterm.Color) string {
+	var fitted []segment
+	used := 0
+	for _, s := range segments {
+		text := strings.ReplaceAll(s.text, "\t", strings.Repeat(" ", tabWidth))
+		w := runewidth.StringWidth(text)
+		if used+w > width {
+			text = runewidth.Truncate(text, width-used, "…")
+			fitted = append(fitted, segment{text: text, changed: s.changed})
+			used = width
+			break
+		}
+		fitted = append(fitted, segment{text: text, changed: s.changed})
+		used += w
+	}
+	return paint(fitted, color) + strings.Repeat(" ", width-used)
+}
+
+// paint colors segments, showing the changed ones in reverse video. Color 0
+// leaves them uncolored.
+func paint(segments []segment, color pterm.Color) string {
+	var b strings.Builder
+	for _, s := range segments {
+		switch {
+		case color == 0:
+			b.WriteString(s.text)
+		case s.changed:
+			b.WriteString(pterm.NewStyle(color, pterm.Reverse).Sprint(s.text))
+		default:
+			b.WriteString(color.Sprint(s.text))
+		}
+	}
+	return b.String()
+}
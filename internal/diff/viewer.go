@@ -2,86 +2,167 @@
 package diff
 
 import (
-    "fmt"
-    "strings"
-    
-    "github.com/pterm/pterm"
-    "github.com/sergi/go-diff/diffmatchpatch"
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/config"
+)
+
+// Layouts for rendering a diff, as set with ui.diff.layout
+const (
+	LayoutAuto       = "auto"
+	LayoutUnified    = "unified"
+	LayoutSideBySide = "side-by-side"
 )
 
+// sideBySideWidth is the terminal width from which the auto layout shows
+// the two versions side by side
+const sideBySideWidth = 120
+
+// Options controls how a diff is rendered
+type Options struct {
+	Layout   string
+	Context  int // unchanged lines shown around each change
+	Width    int // terminal width; 0 detects it
+	Added    pterm.Color
+	Removed  pterm.Color
+	Modified pterm.Color
+}
+
+// colors maps the names accepted under ui.colors to terminal colors
+var colors = map[string]pterm.Color{
+	"black":   pterm.FgBlack,
+	"red":     pterm.FgRed,
+	"green":   pterm.FgGreen,
+	"yellow":  pterm.FgYellow,
+	"blue":    pterm.FgBlue,
+	"magenta": pterm.FgMagenta,
+	"cyan":    pterm.FgCyan,
+	"white":   pterm.FgWhite,
+	"gray":    pterm.FgGray,
+}
+
+// DefaultOptions returns the options set in the ui section of the config
+func DefaultOptions() Options {
+	opts := Options{
+		Layout:   LayoutAuto,
+		Context:  DefaultContext,
+		Added:    pterm.FgGreen,
+		Removed:  pterm.FgRed,
+		Modified: pterm.FgYellow,
+	}
+	cfg := config.Get()
+	if cfg == nil {
+		return opts
+	}
+	if cfg.UI.Diff.Layout != "" {
+		opts.Layout = cfg.UI.Diff.Layout
+	}
+	if cfg.UI.Diff.Context >= 0 {
+		opts.Context = cfg.UI.Diff.Context
+	}
+	setColor(&opts.Added, cfg.UI.Colors.Added)
+	setColor(&opts.Removed, cfg.UI.Colors.Removed)
+	setColor(&opts.Modified, cfg.UI.Colors.Modified)
+	return opts
+}
+
+// setColor sets color to the named one, leaving it as it is for names that
+// are not known
+func setColor(color *pterm.Color, name string) {
+	if c, ok := colors[strings.ToLower(name)]; ok {
+		*color = c
+	}
+}
+
 // Viewer handles diff display
 type Viewer struct {
-    dmp *diffmatchpatch.DiffMatchPatch
+	opts Options
 }
 
-// NewViewer creates a new diff viewer
+// NewViewer creates a diff viewer with the options from the config
 func NewViewer() *Viewer {
-    return &Viewer{
-        dmp: diffmatchpatch.New(),
-    }
+	return NewViewerWithOptions(DefaultOptions())
+}
+
+// NewViewerWithOptions creates a diff viewer with the given options
+func NewViewerWithOptions(opts Options) *Viewer {
+	return &Viewer{opts: opts}
 }
 
 // GenerateDiff generates a colored diff between two texts
 func (v *Viewer) GenerateDiff(original, modified, fileName string) string {
-    diffs := v.dmp.DiffMain(original, modified, false)
-    
-    var output strings.Builder
-    
-    output.WriteString(pterm.DefaultHeader.Sprint("Diff for: " + fileName))
-    output.WriteString("\n\n")
-    
-    lineNumOrig := 1
-    lineNumMod := 1
-    
-    for _, diff := range diffs {
-        lines := strings.Split(diff.Text, "\n")
-        
-        switch diff.Type {
-        case diffmatchpatch.DiffDelete:
-            for i, line := range lines {
-                if i == len(lines)-1 && line == "" {
-                    continue
-                }
-                output.WriteString(pterm.Red(fmt.Sprintf("-%4d | %s\n", lineNumOrig, line)))
-                lineNumOrig++
-            }
-            
-        case diffmatchpatch.DiffInsert:
-            for i, line := range lines {
-                if i == len(lines)-1 && line == "" {
-                    continue
-                }
-                output.WriteString(pterm.Green(fmt.Sprintf("+%4d | %s\n", lineNumMod, line)))
-                lineNumMod++
-            }
-            
-        case diffmatchpatch.DiffEqual:
-            for i, line := range lines {
-                if i == len(lines)-1 && line == "" {
-                    continue
-                }
-                output.WriteString(fmt.Sprintf(" %4d | %s\n", lineNumOrig, line))
-                lineNumOrig++
-                lineNumMod++
-            }
-        }
-    }
-    
-    return output.String()
+	var output strings.Builder
+	output.WriteString(pterm.DefaultHeader.Sprint("Diff for: " + fileName))
+	output.WriteString("\n\n")
+	output.WriteString(v.Render(original, modified))
+	return output.String()
+}
+
+// Render renders the changed lines with their context, highlighting the
+// words that changed within a line. Longer runs of unchanged lines are
+// collapsed.
+func (v *Viewer) Render(original, modified string) string {
+	hunks := Hunks(original, modified, v.opts.Context)
+	if len(hunks) == 0 {
+		return pterm.Gray("No changes") + "\n"
+	}
+
+	r := v.renderer(hunks)
+	var output strings.Builder
+	next := 0
+	for _, h := range hunks {
+		r.gap(&output, h.OldStart-next)
+		r.hunk(&output, h)
+		next = h.OldStart + h.OldLines
+	}
+	r.gap(&output, len(splitLines(original))-next)
+	return output.String()
+}
+
+// RenderHunk renders a single hunk under its header in the unified layout
+func (v *Viewer) RenderHunk(h Hunk) string {
+	r := v.renderer([]Hunk{h})
+	r.sideBySide = false
+
+	var output strings.Builder
+	output.WriteString(pterm.FgCyan.Sprint(h.Header()) + "\n")
+	r.hunk(&output, h)
+	return output.String()
 }
 
 // GenerateUnifiedDiff generates a unified diff format
 func (v *Viewer) GenerateUnifiedDiff(original, modified, fileName string) string {
-    diffs := v.dmp.DiffMain(original, modified, false)
-    patches := v.dmp.PatchMake(original, diffs)
-    
-    var output strings.Builder
-    output.WriteString(fmt.Sprintf("--- %s\n", fileName))
-    output.WriteString(fmt.Sprintf("+++ %s (modified)\n", fileName))
-    
-    for _, patch := range patches {
-        output.WriteString(patch.String())
-    }
-    
-    return output.String()
-}
\ No newline at end of file
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("--- %s\n", fileName))
+	output.WriteString(fmt.Sprintf("+++ %s (modified)\n", fileName))
+
+	for _, h := range Hunks(original, modified, v.opts.Context) {
+		output.WriteString(h.String())
+	}
+
+	return output.String()
+}
+
+// renderer returns a renderer for hunks, choosing the layout for the
+// terminal width
+func (v *Viewer) renderer(hunks []Hunk) *renderer {
+	width := v.opts.Width
+	if width <= 0 {
+		width = pterm.GetTerminalWidth()
+	}
+
+	// Line numbers are as wide as the largest one
+	largest := 0
+	for _, h := range hunks {
+		largest = max(largest, h.OldStart+h.OldLines, h.NewStart+h.NewLines)
+	}
+
+	return &renderer{
+		opts:       v.opts,
+		width:      width,
+		numWidth:   max(len(fmt.Sprint(largest)), 3),
+		sideBySide: v.opts.Layout == LayoutSideBySide || (v.opts.Layout != LayoutUnified && width >= sideBySideWidth),
+	}
+}
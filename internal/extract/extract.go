@@ -0,0 +1,374 @@
+// Package extract finds the code in model responses. Responses may hold the
+// code bare or in fenced Markdown blocks, with prose around them, several
+// blocks, fences nested inside a block, and several files separated by
+// "// File: path" headers; the block for a file is chosen by its name and
+// language tag.
+package extract
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Block is a fenced code block in a response
+type Block struct {
+	Language string // first word of the info string, in lower case
+	File     string // file named by the info string, the line above the fence or a File: header
+	Content  string
+}
+
+// File is one named file of a response
+type File struct {
+	Path    string
+	Content string
+}
+
+var (
+	fence = regexp.MustCompile("^(\\s*)(`{3,}|~{3,})\\s*(.*?)\\s*$")
+
+	// fileHeader is a comment naming the file the following lines belong to
+	fileHeader = regexp.MustCompile(`(?i)^\s*(?://+|#+|--|;+|/\*+|<!--)\s*(?:file|filename|path)\s*:\s*(.+?)\s*(?:\*+/|-->)?\s*$`)
+
+	// fileLabel is a line above a fence that names its file, such as
+	// "**main.go**", "`src/app.ts`:", "### main.py" or "File: main.go"
+	fileLabel = regexp.MustCompile("(?i)^(?:#+\\s*|[-*]\\s+)?(?:\\*\\*|__)?(?:file(?:name)?\\s*:\\s*)?[`'\"]?([\\w@+~./\\\\-]+)[`'\"]?(?:\\*\\*|__)?\\s*:?$")
+
+	// infoFile is a file name given as an attribute of the info string
+	infoFile = regexp.MustCompile(`(?i)^(?:title|file|filename|path)=["']?([^"']+)["']?$`)
+
+	// preamble is a line of prose that introduces bare code
+	preamble = regexp.MustCompile(`(?i)^(?:sure|certainly|of course|okay|ok|here|below|the following|this is|i've|i have)\b.*[:.!]$`)
+
+	// codeStart and codeEnd recognise a first line of code, which makes the
+	// response a bare file rather than a preamble and fenced blocks
+	codeStart = regexp.MustCompile(`^\s*(?:package |import |from \S+ import |<\?php|#!|#include|//|/\*|using |namespace |use |"use strict"|'use strict'|const |let |var |func |def |class |export |@)`)
+	codeEnd   = regexp.MustCompile(`[{;(\[]\s*$`)
+)
+
+// Blocks returns the outermost fenced blocks of a response. A block closes
+// at a fence of its kind at least as long as the one that opened it. When
+// the model nests fences of the same length, a fence with an info string
+// opens an inner block and the next bare fence closes it. A block that is
+// never closed runs to the end of the response.
+func Blocks(response string) []Block {
+	lines := strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n")
+
+	var blocks []Block
+	for i := 0; i < len(lines); i++ {
+		m := fence.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		indent, marker := m[1], m[2]
+		block := parseInfo(m[3])
+		if block.File == "" {
+			block.File = labelAbove(lines, i)
+		}
+
+		var content []string
+		depth, closed := 0, false
+		for i++; i < len(lines); i++ {
+			if inner := fence.FindStringSubmatch(lines[i]); inner != nil &&
+				inner[2][0] == marker[0] && len(inner[2]) >= len(marker) {
+				if inner[3] != "" {
+					depth++
+				} else if depth > 0 {
+					depth--
+				} else {
+					closed = true
+					break
+				}
+			}
+			content = append(content, strings.TrimPrefix(lines[i], indent))
+		}
+		if !closed && len(content) > 0 {
+			// Models sometimes put the closing fence at the end of the last line
+			last := len(content) - 1
+			content[last] = strings.TrimSuffix(strings.TrimRight(content[last], " \t"), marker)
+		}
+
+		block.Content = trim(strings.Join(content, "\n"))
+		if block.File == "" {
+			if first, _, _ := strings.Cut(block.Content, "\n"); fileHeader.MatchString(first) {
+				block.File = cleanPath(fileHeader.FindStringSubmatch(first)[1])
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// Files returns the named files of a response: the blocks whose file is
+// known, and the sections that "// File: path" headers divide a block, or
+// the bare response, into
+func Files(response string) []File {
+	var files []File
+	for _, s := range sections(response) {
+		if s.File != "" {
+			files = append(files, File{Path: s.File, Content: s.Content})
+		}
+	}
+	return files
+}
+
+// Code returns the code for fileName in a response. A section named after
+// the file is preferred; otherwise the block tagged with the file's
+// language, then an untagged one, and the longest of equals. A response
+// whose code comes before any fence is taken as a bare file, without the
+// line of prose that may introduce it.
+func Code(response, fileName string) string {
+	var best *Block
+	bestScore := -1
+	languages := Languages(fileName)
+	for _, s := range sections(response) {
+		score := 1
+		switch {
+		case s.File != "" && Matches(s.File, fileName):
+			return s.Content
+		case s.File != "":
+			score = 0
+		case s.Language != "" && slices.Contains(languages, s.Language):
+			score = 3
+		case s.Language == "":
+			score = 2
+		}
+		if score > bestScore || score == bestScore && len(s.Content) > len(best.Content) {
+			best, bestScore = &s, score
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Content
+}
+
+// Objects returns the texts in a response that may hold a JSON object: the
+// fenced blocks tagged json or starting with "{", then the span from the
+// first "{" to the last "}"
+func Objects(response string) []string {
+	var objects []string
+	for _, b := range Blocks(response) {
+		if b.Language == "json" || strings.HasPrefix(b.Content, "{") {
+			objects = append(objects, b.Content)
+		}
+	}
+	if start, end := strings.Index(response, "{"), strings.LastIndex(response, "}"); start >= 0 && end > start {
+		objects = append(objects, response[start:end+1])
+	}
+	return objects
+}
+
+// Matches reports whether a file named in a response is fileName: the same
+// path, one ending with the other, or the same base name
+func Matches(named, fileName string) bool {
+	named, fileName = cleanPath(named), cleanPath(fileName)
+	if named == "" || fileName == "" {
+		return false
+	}
+	return named == fileName ||
+		strings.HasSuffix(named, "/"+fileName) || strings.HasSuffix(fileName, "/"+named) ||
+		path.Base(named) == path.Base(fileName)
+}
+
+// Languages returns the language tags a block holding fileName may have
+func Languages(fileName string) []string {
+	base := strings.ToLower(filepath.Base(fileName))
+	if tags, ok := nameTags[base]; ok {
+		return tags
+	}
+	ext := strings.TrimPrefix(filepath.Ext(base), ".")
+	if tags, ok := extTags[ext]; ok {
+		return tags
+	}
+	if ext == "" {
+		return nil
+	}
+	return []string{ext}
+}
+
+// extTags are the language tags used for each file extension
+var extTags = map[string][]string{
+	"go":    {"go", "golang"},
+	"py":    {"python", "py", "python3"},
+	"js":    {"javascript", "js", "node"},
+	"mjs":   {"javascript", "js"},
+	"cjs":   {"javascript", "js"},
+	"jsx":   {"jsx", "javascript", "js"},
+	"ts":    {"typescript", "ts"},
+	"tsx":   {"tsx", "typescript", "ts"},
+	"php":   {"php"},
+	"rb":    {"ruby", "rb"},
+	"rs":    {"rust", "rs"},
+	"java":  {"java"},
+	"kt":    {"kotlin", "kt"},
+	"cs":    {"csharp", "cs", "c#"},
+	"cpp":   {"cpp", "c++"},
+	"cc":    {"cpp", "c++"},
+	"hpp":   {"cpp", "c++"},
+	"c":     {"c"},
+	"h":     {"c", "cpp"},
+	"sh":    {"bash", "sh", "shell", "zsh"},
+	"bash":  {"bash", "sh", "shell"},
+	"md":    {"markdown", "md"},
+	"yml":   {"yaml", "yml"},
+	"yaml":  {"yaml", "yml"},
+	"json":  {"json", "jsonc"},
+	"html":  {"html"},
+	"vue":   {"vue", "html"},
+	"blade": {"blade", "php", "html"},
+	"sql":   {"sql"},
+	"swift": {"swift"},
+	"toml":  {"toml"},
+}
+
+// nameTags are the language tags of files known by their name
+var nameTags = map[string][]string{
+	"dockerfile": {"dockerfile", "docker"},
+	"makefile":   {"makefile", "make"},
+}
+
+// section is a block, or the part of one under a File: header
+type section = Block
+
+// sections returns the fenced blocks of a response, or the bare response
+// when it has none or starts with code, each split at its File: headers
+func sections(response string) []section {
+	blocks := Blocks(response)
+	if len(blocks) == 0 || isBare(response) {
+		blocks = []Block{{Content: stripPreamble(response)}}
+	}
+
+	var result []section
+	for _, b := range blocks {
+		result = append(result, split(b)...)
+	}
+	return result
+}
+
+// split divides a block at its File: headers. Lines before the first
+// header stay with the block's own file.
+func split(b Block) []section {
+	var result []section
+	current := section{Language: b.Language, File: b.File}
+	var lines []string
+	flush := func() {
+		if current.Content = trim(strings.Join(lines, "\n")); current.Content != "" {
+			result = append(result, current)
+		}
+		lines = nil
+	}
+
+	for _, line := range strings.Split(b.Content, "\n") {
+		if m := fileHeader.FindStringSubmatch(line); m != nil && isPath(cleanPath(m[1])) {
+			flush()
+			current = section{Language: b.Language, File: cleanPath(m[1])}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return result
+}
+
+// isBare reports whether the first line of a response is code, so that any
+// fences in it belong to the code, as in a string holding Markdown. A
+// response starting with prose or a File: header uses its fenced blocks.
+func isBare(response string) bool {
+	for _, line := range strings.Split(response, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if fence.MatchString(line) || fileHeader.MatchString(line) {
+			return false
+		}
+		return codeStart.MatchString(line) || codeEnd.MatchString(line)
+	}
+	return false
+}
+
+// stripPreamble removes a line of prose introducing bare code
+func stripPreamble(response string) string {
+	response = trim(response)
+	if first, rest, ok := strings.Cut(response, "\n"); ok && preamble.MatchString(strings.TrimSpace(first)) {
+		return trim(rest)
+	}
+	return response
+}
+
+// parseInfo reads the language and file name from a fence's info string:
+// "go", "go:main.go", "main.go", or "go title=main.go"
+func parseInfo(info string) Block {
+	fields := strings.Fields(strings.Trim(info, "{}"))
+	if len(fields) == 0 {
+		return Block{}
+	}
+
+	var block Block
+	first := strings.TrimPrefix(fields[0], ".")
+	if language, file, ok := strings.Cut(first, ":"); ok && isPath(file) {
+		block.Language, block.File = language, cleanPath(file)
+	} else if isPath(first) && filepath.Ext(first) != "" {
+		block.File = cleanPath(first)
+	} else {
+		block.Language = first
+	}
+	block.Language = strings.ToLower(block.Language)
+
+	for _, field := range fields[1:] {
+		if m := infoFile.FindStringSubmatch(field); m != nil && block.File == "" {
+			block.File = cleanPath(m[1])
+		}
+	}
+	return block
+}
+
+// labelAbove returns the file named by the line above a fence, if any
+func labelAbove(lines []string, i int) string {
+	for j := i - 1; j >= 0 && j >= i-2; j-- {
+		line := strings.TrimSpace(lines[j])
+		if line == "" {
+			continue
+		}
+		if m := fileLabel.FindStringSubmatch(line); m != nil && isPath(m[1]) && strings.Contains(m[1], ".") {
+			return cleanPath(m[1])
+		}
+		return ""
+	}
+	return ""
+}
+
+// isPath reports whether s looks like a file path
+func isPath(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t") || strings.Trim(s, ".") == "" {
+		return false
+	}
+	return strings.ContainsAny(s, "./\\")
+}
+
+// cleanPath normalises a path named in a response
+func cleanPath(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), "`'\"*")
+	if p == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
+
+// trim removes blank lines around text, keeping the indentation of its
+// first line
+func trim(text string) string {
+	text = strings.TrimRight(text, " \t\r\n")
+	for {
+		line, rest, ok := strings.Cut(text, "\n")
+		if !ok || strings.TrimSpace(line) != "" {
+			if strings.TrimSpace(text) == "" {
+				return ""
+			}
+			return text
+		}
+		text = rest
+	}
+}
@@ -0,0 +1,75 @@
+package extract
+
+import "testing"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		fileName string
+		want     string
+	}{
+		{
+			name:     "preamble before a fenced block",
+			response: "Here is the updated code:\n\n```go\npackage main\n\nfunc main() {}\n```\n",
+			fileName: "main.go",
+			want:     "package main\n\nfunc main() {}",
+		},
+		{
+			name:     "file name in backticks in the preamble",
+			response: "Here is the updated `main.go`\n\n```go\npackage main\n```\n\nThe loop now exits early.",
+			fileName: "main.go",
+			want:     "package main",
+		},
+		{
+			name:     "preamble ending with an equals sign",
+			response: "The fix sets the default timeout =\n\n```go\nconst timeout = 30\n```",
+			fileName: "main.go",
+			want:     "const timeout = 30",
+		},
+		{
+			name:     "file names in backticks above the blocks",
+			response: "`main.go`\n```go\npackage main\n```\n\n`util.go`\n```go\npackage util\n```",
+			fileName: "util.go",
+			want:     "package util",
+		},
+		{
+			name:     "File: header before a fenced block",
+			response: "// File: main.go\n```go\npackage main\n```",
+			fileName: "main.go",
+			want:     "package main",
+		},
+		{
+			name:     "language tag preferred over untagged block",
+			response: "Run it with:\n```\ngo run .\n```\n```go\npackage main\n```",
+			fileName: "main.go",
+			want:     "package main",
+		},
+		{
+			name:     "bare code",
+			response: "package main\n\nfunc main() {}\n",
+			fileName: "main.go",
+			want:     "package main\n\nfunc main() {}",
+		},
+		{
+			name:     "bare code with a preamble",
+			response: "Here is the code:\npackage main\n",
+			fileName: "main.go",
+			want:     "package main",
+		},
+		{
+			name:     "bare code holding fences in a string",
+			response: "package main\n\nconst doc = `\n```go\nx := 1\n```\n`\n",
+			fileName: "main.go",
+			want:     "package main\n\nconst doc = `\n```go\nx := 1\n```\n`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.response, tt.fileName); got != tt.want {
+				t.Errorf("Code() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Package format finds a project's code formatter and runs it on generated
+// code
+package format
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Off disables formatting when set as a language's formatter
+const Off = "none"
+
+// timeout bounds a single formatter run
+const timeout = 30 * time.Second
+
+// Formatter formats source code, in-process or with an external tool
+type Formatter struct {
+	Name    string
+	command []string // with {file} standing for the file's path; nil formats Go in-process
+	inPlace bool     // the tool rewrites a file instead of reading stdin
+}
+
+// prettierFiles are the extensions prettier formats
+var prettierFiles = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true,
+	".css": true, ".scss": true, ".less": true, ".json": true, ".html": true, ".vue": true,
+	".md": true, ".yaml": true, ".yml": true,
+}
+
+// prettierConfigs are the files that configure prettier for a project
+var prettierConfigs = []string{
+	".prettierrc", ".prettierrc.json", ".prettierrc.yml", ".prettierrc.yaml", ".prettierrc.json5",
+	".prettierrc.js", ".prettierrc.cjs", ".prettierrc.mjs", ".prettierrc.toml",
+	"prettier.config.js", "prettier.config.cjs", "prettier.config.mjs",
+}
+
+// ForFile returns the formatter for fileName. command, when set, is the
+// formatter configured for the file's language: it reads the code on stdin
+// and writes the result to stdout, with {file} replaced by the file's path;
+// Off disables formatting. Otherwise the project's formatter is found from
+// its config files, the project's own tools (node_modules/.bin, vendor/bin,
+// a virtualenv) and PATH. ForFile returns nil when there is no formatter.
+func ForFile(fileName, command string) *Formatter {
+	command = strings.TrimSpace(command)
+	if command == Off {
+		return nil
+	}
+	if command != "" {
+		fields := strings.Fields(command)
+		return &Formatter{Name: filepath.Base(fields[0]), command: fields}
+	}
+
+	dir := existingDir(fileName)
+	ext := strings.ToLower(filepath.Ext(fileName))
+	switch {
+	case ext == ".go":
+		if tool, ok := findTool(dir, "goimports"); ok {
+			return &Formatter{Name: "goimports", command: []string{tool, "-srcdir", dir}}
+		}
+		return &Formatter{Name: "gofmt"}
+
+	case prettierFiles[ext]:
+		tool, local := localTool(dir, "prettier", filepath.Join("node_modules", ".bin"))
+		if !local {
+			if !usesPrettier(dir) {
+				return nil
+			}
+			var ok bool
+			if tool, ok = findTool(dir, "prettier"); !ok {
+				return nil
+			}
+		}
+		return &Formatter{Name: "prettier", command: []string{tool, "--stdin-filepath", "{file}"}}
+
+	case ext == ".py":
+		pyproject, _ := os.ReadFile(findUp(dir, "pyproject.toml"))
+		ruff, hasRuff := findTool(dir, "ruff")
+		black, hasBlack := findTool(dir, "black")
+		usesRuff := findUp(dir, "ruff.toml", ".ruff.toml") != "" || bytes.Contains(pyproject, []byte("[tool.ruff"))
+		usesBlack := bytes.Contains(pyproject, []byte("[tool.black"))
+		switch {
+		case hasRuff && (usesRuff || !usesBlack):
+			return &Formatter{Name: "ruff", command: []string{ruff, "format", "--stdin-filename", "{file}", "-"}}
+		case hasBlack:
+			return &Formatter{Name: "black", command: []string{black, "--quiet", "--stdin-filename", "{file}", "-"}}
+		}
+
+	case ext == ".php":
+		tool, ok := findTool(dir, "php-cs-fixer")
+		if !ok {
+			return nil
+		}
+		command := []string{tool, "fix", "--quiet", "--using-cache=no"}
+		if config := findUp(dir, ".php-cs-fixer.php", ".php-cs-fixer.dist.php"); config != "" {
+			command = append(command, "--config="+config)
+		}
+		return &Formatter{Name: "php-cs-fixer", command: append(command, "{file}"), inPlace: true}
+	}
+	return nil
+}
+
+// Format returns content formatted as the source of fileName
+func (f *Formatter) Format(ctx context.Context, fileName, content string) (string, error) {
+	if f.command == nil {
+		formatted, err := format.Source([]byte(content))
+		if err != nil {
+			return content, err
+		}
+		return string(formatted), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	path, err := filepath.Abs(fileName)
+	if err != nil {
+		return content, err
+	}
+	if f.inPlace {
+		// Format a copy, named like the file so that the tool applies the same rules
+		tmp, err := os.MkdirTemp("", "weaver-format-*")
+		if err != nil {
+			return content, err
+		}
+		defer os.RemoveAll(tmp)
+		path = filepath.Join(tmp, filepath.Base(fileName))
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			return content, err
+		}
+	}
+
+	args := make([]string, len(f.command))
+	for i, arg := range f.command {
+		args[i] = strings.ReplaceAll(arg, "{file}", path)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = existingDir(fileName)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if !f.inPlace {
+		cmd.Stdin = strings.NewReader(content)
+	}
+	if err := cmd.Run(); err != nil {
+		if msg := firstLine(stderr.String()); msg != "" {
+			return content, fmt.Errorf("%w: %s", err, msg)
+		}
+		return content, err
+	}
+
+	if f.inPlace {
+		formatted, err := os.ReadFile(path)
+		if err != nil {
+			return content, err
+		}
+		return string(formatted), nil
+	}
+	if stdout.Len() == 0 && content != "" {
+		return content, fmt.Errorf("%s printed nothing", f.Name)
+	}
+	return stdout.String(), nil
+}
+
+// usesPrettier reports whether the project around dir configures prettier
+func usesPrettier(dir string) bool {
+	if findUp(dir, prettierConfigs...) != "" {
+		return true
+	}
+	pkg, err := os.ReadFile(findUp(dir, "package.json"))
+	return err == nil && bytes.Contains(pkg, []byte(`"prettier"`))
+}
+
+// findTool looks for a tool installed in the project, then on PATH
+func findTool(dir, name string) (string, bool) {
+	if tool, ok := localTool(dir, name, filepath.Join("node_modules", ".bin"), filepath.Join("vendor", "bin"),
+		filepath.Join(".venv", "bin"), filepath.Join("venv", "bin")); ok {
+		return tool, true
+	}
+	tool, err := exec.LookPath(name)
+	return tool, err == nil
+}
+
+// localTool looks for a tool in the given directories of dir and its parents
+func localTool(dir, name string, binDirs ...string) (string, bool) {
+	var candidates []string
+	for _, bin := range binDirs {
+		candidates = append(candidates, filepath.Join(bin, name))
+	}
+	path := findUp(dir, candidates...)
+	return path, path != ""
+}
+
+// findUp returns the first of names found in dir or its parents, or ""
+func findUp(dir string, names ...string) string {
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// existingDir returns the absolute path of the nearest existing directory
+// holding fileName, which may not have been created yet
+func existingDir(fileName string) string {
+	dir, err := filepath.Abs(filepath.Dir(fileName))
+	if err != nil {
+		return "."
+	}
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
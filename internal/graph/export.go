@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Level selects whether an export shows files or packages
+type Level string
+
+const (
+	LevelFile    Level = "file"
+	LevelPackage Level = "package"
+)
+
+// Edges returns the graph's edges at level: file imports, or the imports
+// between package directories
+func (g *Graph) Edges(level Level) map[string][]string {
+	if level == LevelPackage {
+		return g.PackageEdges()
+	}
+	edges := make(map[string][]string)
+	for _, rel := range g.Paths() {
+		if deps := g.Dependencies(rel); len(deps) > 0 {
+			edges[rel] = deps
+		}
+	}
+	return edges
+}
+
+// nodes returns the nodes at level, sorted
+func (g *Graph) nodes(level Level) []string {
+	if level == LevelPackage {
+		return g.Packages()
+	}
+	return g.Paths()
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Nodes in an import
+// cycle are highlighted.
+func (g *Graph) WriteDOT(w io.Writer, level Level) error {
+	inCycle := make(map[string]bool)
+	if level == LevelPackage {
+		for _, cycle := range g.Cycles() {
+			for _, p := range cycle {
+				inCycle[p] = true
+			}
+		}
+	}
+
+	edges := g.Edges(level)
+	fmt.Fprintln(w, "digraph imports {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, fontname=\"Helvetica\", fontsize=10];")
+	for _, node := range g.nodes(level) {
+		attrs := ""
+		if inCycle[node] {
+			attrs = " [color=red, fontcolor=red]"
+		}
+		fmt.Fprintf(w, "  %s%s;\n", strconv.Quote(node), attrs)
+	}
+	for _, from := range g.nodes(level) {
+		for _, to := range edges[from] {
+			attrs := ""
+			if inCycle[from] && inCycle[to] {
+				attrs = " [color=red]"
+			}
+			fmt.Fprintf(w, "  %s -> %s%s;\n", strconv.Quote(from), strconv.Quote(to), attrs)
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// jsonGraph is the JSON form of a graph
+type jsonGraph struct {
+	Level  Level               `json:"level"`
+	Nodes  []string            `json:"nodes"`
+	Edges  map[string][]string `json:"edges"`
+	Cycles [][]string          `json:"cycles,omitempty"`
+	Files  []*File             `json:"files,omitempty"`
+}
+
+// WriteJSON writes the nodes and edges at level. The file level also
+// includes every file's imports with their line numbers.
+func (g *Graph) WriteJSON(w io.Writer, level Level) error {
+	out := jsonGraph{
+		Level:  level,
+		Nodes:  g.nodes(level),
+		Edges:  g.Edges(level),
+		Cycles: g.Cycles(),
+	}
+	if level == LevelFile {
+		for _, rel := range g.Paths() {
+			out.Files = append(out.Files, g.Files[rel])
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
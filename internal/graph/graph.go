@@ -0,0 +1,342 @@
+// Package graph builds the import graph of a project: which files import
+// which, and the package-level edges between directories. Imports are read
+// without type checking: Go with go/parser, JavaScript and TypeScript from
+// import and require, Python from import statements and PHP from use
+// statements resolved through namespace declarations.
+package graph
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snowsoft/codeweaver/internal/symbols"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/snowsoft/codeweaver/internal/walker"
+)
+
+// maxFileSize is the largest file whose imports are read
+const maxFileSize = 1024 * 1024
+
+// File is a source file in the graph
+type File struct {
+	Path     string   `json:"path"` // slash path relative to the root
+	Language string   `json:"language"`
+	Package  string   `json:"package"` // directory of the file, "." for the root
+	Imports  []Import `json:"imports,omitempty"`
+}
+
+// Graph is the import graph of the files under Root
+type Graph struct {
+	Root  string
+	Files map[string]*File
+
+	dependents map[string][]string
+	symbols    map[string][]symbols.Symbol // cache for Symbols
+}
+
+// Build reads the imports of every Go, JavaScript, TypeScript, Python and
+// PHP file under root that is not ignored, and resolves them to files
+func Build(root string) (*Graph, error) {
+	rels, err := walker.Files(root, walker.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{Root: root, Files: make(map[string]*File)}
+	r := newResolver()
+	contents := make(map[string]string)
+
+	for _, rel := range rels {
+		if path.Base(rel) == "go.mod" {
+			r.addModule(path.Dir(rel), filepath.Join(root, filepath.FromSlash(rel)))
+			continue
+		}
+		language := utils.DetectLanguage(rel)
+		if !resolvable(language) {
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if info, err := os.Stat(p); err != nil || info.Size() > maxFileSize {
+			continue
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+
+		g.Files[rel] = &File{Path: rel, Language: language, Package: path.Dir(rel)}
+		contents[rel] = string(content)
+		r.addFile(rel, language, contents[rel])
+	}
+
+	for rel, f := range g.Files {
+		imports := ParseImports(f.Language, contents[rel])
+		for i := range imports {
+			imports[i].Targets = r.resolve(f, imports[i].Spec)
+		}
+		f.Imports = imports
+	}
+
+	g.index()
+	return g, nil
+}
+
+// resolvable reports whether Build resolves imports for language
+func resolvable(language string) bool {
+	switch language {
+	case "go", "javascript", "typescript", "python", "php":
+		return true
+	}
+	return false
+}
+
+// index builds the reverse edges
+func (g *Graph) index() {
+	g.dependents = make(map[string][]string)
+	for _, rel := range g.Paths() {
+		for _, dep := range g.Dependencies(rel) {
+			g.dependents[dep] = append(g.dependents[dep], rel)
+		}
+	}
+}
+
+// Paths returns the paths of the files in the graph, sorted
+func (g *Graph) Paths() []string {
+	paths := make([]string, 0, len(g.Files))
+	for rel := range g.Files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Dependencies returns the project files the file at rel imports, sorted
+func (g *Graph) Dependencies(rel string) []string {
+	f, ok := g.Files[rel]
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var deps []string
+	for _, imp := range f.Imports {
+		for _, target := range imp.Targets {
+			if target != rel && !seen[target] {
+				seen[target] = true
+				deps = append(deps, target)
+			}
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// Dependents returns the files that import target directly. target is a
+// file or a package directory; for a directory, importers inside it are
+// left out.
+func (g *Graph) Dependents(target string) []string {
+	seen := make(map[string]bool)
+	for _, rel := range g.members(target) {
+		for _, dep := range g.dependents[rel] {
+			seen[dep] = true
+		}
+	}
+	return g.without(seen, target)
+}
+
+// TransitiveDependents returns every file that imports target directly or
+// through other files
+func (g *Graph) TransitiveDependents(target string) []string {
+	seen := make(map[string]bool)
+	queue := g.members(target)
+	for len(queue) > 0 {
+		rel := queue[0]
+		queue = queue[1:]
+		for _, dep := range g.dependents[rel] {
+			if !seen[dep] {
+				seen[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return g.without(seen, target)
+}
+
+// members returns the files target stands for: the file itself, or the
+// files directly inside a package directory
+func (g *Graph) members(target string) []string {
+	target = cleanTarget(target)
+	if _, ok := g.Files[target]; ok {
+		return []string{target}
+	}
+	var files []string
+	for _, rel := range g.Paths() {
+		if g.Files[rel].Package == target {
+			files = append(files, rel)
+		}
+	}
+	return files
+}
+
+// without returns the sorted keys of set, leaving out target's own files
+func (g *Graph) without(set map[string]bool, target string) []string {
+	for _, rel := range g.members(target) {
+		delete(set, rel)
+	}
+	files := make([]string, 0, len(set))
+	for rel := range set {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Contains reports whether target is a file or package in the graph
+func (g *Graph) Contains(target string) bool {
+	return len(g.members(target)) > 0
+}
+
+func cleanTarget(target string) string {
+	target = path.Clean(filepath.ToSlash(target))
+	return strings.TrimPrefix(target, "./")
+}
+
+// Packages returns the package directories in the graph, sorted
+func (g *Graph) Packages() []string {
+	seen := make(map[string]bool)
+	for _, f := range g.Files {
+		seen[f.Package] = true
+	}
+	packages := make([]string, 0, len(seen))
+	for p := range seen {
+		packages = append(packages, p)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// PackageEdges returns, for every package, the other packages its files
+// import, sorted
+func (g *Graph) PackageEdges() map[string][]string {
+	sets := make(map[string]map[string]bool)
+	for rel, f := range g.Files {
+		for _, dep := range g.Dependencies(rel) {
+			to := g.Files[dep].Package
+			if to == f.Package {
+				continue
+			}
+			if sets[f.Package] == nil {
+				sets[f.Package] = make(map[string]bool)
+			}
+			sets[f.Package][to] = true
+		}
+	}
+
+	edges := make(map[string][]string, len(sets))
+	for from, set := range sets {
+		for to := range set {
+			edges[from] = append(edges[from], to)
+		}
+		sort.Strings(edges[from])
+	}
+	return edges
+}
+
+// Cycles returns the groups of packages that import each other, each
+// sorted, largest first
+func (g *Graph) Cycles() [][]string {
+	edges := g.PackageEdges()
+
+	// Tarjan's strongly connected components
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	next := 0
+
+	var visit func(p string)
+	visit = func(p string) {
+		index[p], low[p] = next, next
+		next++
+		stack = append(stack, p)
+		onStack[p] = true
+
+		for _, q := range edges[p] {
+			if _, ok := index[q]; !ok {
+				visit(q)
+				low[p] = min(low[p], low[q])
+			} else if onStack[q] {
+				low[p] = min(low[p], index[q])
+			}
+		}
+
+		if low[p] == index[p] {
+			var component []string
+			for {
+				q := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[q] = false
+				component = append(component, q)
+				if q == p {
+					break
+				}
+			}
+			if len(component) > 1 {
+				sort.Strings(component)
+				cycles = append(cycles, component)
+			}
+		}
+	}
+	for _, p := range g.Packages() {
+		if _, ok := index[p]; !ok {
+			visit(p)
+		}
+	}
+
+	sort.SliceStable(cycles, func(i, j int) bool { return len(cycles[i]) > len(cycles[j]) })
+	return cycles
+}
+
+// MostImported returns up to n files with the most direct dependents,
+// most imported first
+func (g *Graph) MostImported(n int) []string {
+	var files []string
+	for rel, deps := range g.dependents {
+		if len(deps) > 0 {
+			files = append(files, rel)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		a, b := len(g.dependents[files[i]]), len(g.dependents[files[j]])
+		if a != b {
+			return a > b
+		}
+		return files[i] < files[j]
+	})
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files
+}
+
+// readModulePath returns the module path declared in a go.mod file
+func readModulePath(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
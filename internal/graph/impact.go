@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/snowsoft/codeweaver/internal/symbols"
+)
+
+// Target is a file, package directory or symbol a change touches
+type Target struct {
+	Path   string `json:"path"`             // file or package directory, relative to the root
+	Symbol string `json:"symbol,omitempty"` // qualified symbol declared in Path, e.g. "Config.Load"
+}
+
+func (t Target) String() string {
+	if t.Symbol != "" {
+		return t.Path + "#" + t.Symbol
+	}
+	return t.Path
+}
+
+// Affected is a file a change may affect
+type Affected struct {
+	Path     string `json:"path"`
+	Distance int    `json:"distance"` // 0 for targets, 1 for direct importers or references, more for indirect ones
+	Reason   string `json:"reason"`
+	Lines    []int  `json:"lines,omitempty"` // lines referring to a changed symbol
+	Test     bool   `json:"test,omitempty"`
+}
+
+// testFile matches the usual test file names of the supported languages
+var testFile = regexp.MustCompile(`(?i)(_test\.go|(^|/)test_[^/]*\.py|_test\.py|\.(test|spec)\.[jt]sx?|Test\.php|(^|/)(tests?|__tests__|spec)/)`)
+
+// IsTest reports whether the file at rel looks like a test
+func IsTest(rel string) bool {
+	return testFile.MatchString(rel)
+}
+
+// Symbols returns the symbols declared in the file at rel, or nil when the
+// file is not in the graph or its language has no symbol parser
+func (g *Graph) Symbols(rel string) []symbols.Symbol {
+	if syms, ok := g.symbols[rel]; ok {
+		return syms
+	}
+	f, ok := g.Files[rel]
+	if !ok || !symbols.Supported(f.Language) {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(g.Root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil
+	}
+	syms, _ := symbols.Extract(rel, content)
+	if g.symbols == nil {
+		g.symbols = make(map[string][]symbols.Symbol)
+	}
+	g.symbols[rel] = syms
+	return syms
+}
+
+// FindSymbol returns the files declaring a symbol called name, qualified
+// ("Config.Load") or bare
+func (g *Graph) FindSymbol(name string) []Target {
+	var targets []Target
+	for _, rel := range g.Paths() {
+		if s, ok := symbols.Find(g.Symbols(rel), name); ok {
+			targets = append(targets, Target{Path: rel, Symbol: s.Name})
+		}
+	}
+	return targets
+}
+
+// Impact returns the files a change to targets may affect, nearest first.
+// Files importing a target file or package are affected; for a symbol,
+// only files that refer to it by name, and the files importing those.
+// maxDepth limits how far importers are followed; 0 means no limit.
+func (g *Graph) Impact(targets []Target, maxDepth int) ([]Affected, error) {
+	affected := make(map[string]*Affected)
+	add := func(rel string, distance int, reason string) bool {
+		if a, ok := affected[rel]; ok && a.Distance <= distance {
+			return false
+		}
+		affected[rel] = &Affected{Path: rel, Distance: distance, Reason: reason, Test: IsTest(rel)}
+		return true
+	}
+
+	var frontier []string
+	for _, t := range targets {
+		files := g.members(t.Path)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s is not a source file or package in %s", t.Path, g.Root)
+		}
+
+		if t.Symbol == "" {
+			for _, rel := range files {
+				add(rel, 0, "changed")
+				frontier = append(frontier, rel)
+			}
+			continue
+		}
+
+		var rel string
+		var s symbols.Symbol
+		for _, file := range files {
+			if found, ok := symbols.Find(g.Symbols(file), t.Symbol); ok {
+				rel, s = file, found
+				break
+			}
+		}
+		if rel == "" {
+			return nil, fmt.Errorf("%s does not declare %s", t.Path, t.Symbol)
+		}
+		add(rel, 0, "declares "+s.Name)
+
+		for _, ref := range g.references(rel, s) {
+			if add(ref.Path, 1, "refers to "+s.Name) {
+				affected[ref.Path].Lines = ref.Lines
+				frontier = append(frontier, ref.Path)
+			} else if a := affected[ref.Path]; a.Distance == 0 {
+				a.Lines = ref.Lines
+			}
+		}
+	}
+
+	// Importers of everything affected so far, breadth first
+	for len(frontier) > 0 {
+		var next []string
+		for _, rel := range frontier {
+			distance := affected[rel].Distance + 1
+			if maxDepth > 0 && distance > maxDepth {
+				continue
+			}
+			for _, dep := range g.Dependents(rel) {
+				if add(dep, distance, "imports "+rel) {
+					next = append(next, dep)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	list := make([]Affected, 0, len(affected))
+	for _, a := range affected {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Distance != list[j].Distance {
+			return list[i].Distance < list[j].Distance
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list, nil
+}
+
+// reference is a file that mentions a symbol, and where
+type reference struct {
+	Path  string
+	Lines []int
+}
+
+// references finds the code lines mentioning s outside its declaration, in
+// the file declaring it, the rest of its package and the files that import it
+// directly or indirectly
+func (g *Graph) references(rel string, s symbols.Symbol) []reference {
+	name := s.Name
+	if s.Parent != "" {
+		name = strings.TrimPrefix(name, s.Parent+".")
+	}
+	pattern := `\b` + regexp.QuoteMeta(name) + `\b`
+	if s.Parent != "" {
+		// Members are reached through a value or the class
+		pattern = `(?:\.|->|::)` + regexp.QuoteMeta(name) + `\b`
+	}
+	re := regexp.MustCompile(pattern)
+
+	candidates := append(g.members(path.Dir(rel)), g.TransitiveDependents(rel)...)
+	seen := make(map[string]bool)
+	var refs []reference
+	for _, file := range candidates {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		content, err := os.ReadFile(filepath.Join(g.Root, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		var lines []int
+		for i, line := range strings.Split(string(content), "\n") {
+			n := i + 1
+			if file == rel && n >= s.StartLine && n <= s.EndLine {
+				continue
+			}
+			if re.MatchString(line) && !lineComment.MatchString(line) {
+				lines = append(lines, n)
+			}
+		}
+		if len(lines) > 0 {
+			refs = append(refs, reference{Path: file, Lines: lines})
+		}
+	}
+	return refs
+}
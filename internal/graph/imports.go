@@ -0,0 +1,209 @@
+package graph
+
+import (
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Import is a module, package or file named by an import statement
+type Import struct {
+	Spec    string   `json:"spec"`              // as written: "./user", "app.models", "App\Models\User"
+	Line    int      `json:"line"`              // 1-based
+	Targets []string `json:"targets,omitempty"` // project files it resolves to; empty for external imports
+}
+
+var (
+	jsImport     = regexp.MustCompile(`(?:\bfrom\s*|\bimport\s*\(?\s*|\brequire\s*\(\s*)['"]([^'"]+)['"]`)
+	pyFromImport = regexp.MustCompile(`^\s*from\s+(\.*[\w.]*)\s+import\s+\(?\s*([\w\s,*]+)`)
+	pyImport     = regexp.MustCompile(`^\s*import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)`)
+	phpUse       = regexp.MustCompile(`^\s*use\s+(?:function\s+|const\s+)?\\?([\w\\]+)(?:\s*\\?\{([^}]*)\})?`)
+	phpInclude   = regexp.MustCompile(`\b(?:require|include)(?:_once)?\s*\(?\s*(__DIR__\s*\.\s*)?['"]([^'"]+)['"]`)
+	phpNamespace = regexp.MustCompile(`(?m)^\s*namespace\s+([\w\\]+)\s*[;{]`)
+	phpDeclare   = regexp.MustCompile(`(?m)^\s*(?:(?:abstract|final|readonly)\s+)*(?:class|interface|trait|enum)\s+(\w+)`)
+	javaImport   = regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.]+(?:\.\*)?)\s*;`)
+	rustImport   = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+([\w:]+)`)
+	rustModule   = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)\s*;`)
+	lineComment  = regexp.MustCompile(`^\s*(?://|#|\*|/\*)`)
+)
+
+// ParseImports returns the imports of a source file in the given language,
+// as named by utils.DetectLanguage. Specs are returned as written; Build
+// resolves them to files.
+func ParseImports(language, content string) []Import {
+	switch language {
+	case "go":
+		return goImports(content)
+	case "javascript", "typescript":
+		return matchLines(content, func(line string) []string {
+			var specs []string
+			for _, m := range jsImport.FindAllStringSubmatch(line, -1) {
+				specs = append(specs, m[1])
+			}
+			return specs
+		})
+	case "python":
+		return matchLines(content, pythonSpecs)
+	case "php":
+		return phpImports(content)
+	case "java", "kotlin", "scala":
+		return matchLines(content, func(line string) []string {
+			if m := javaImport.FindStringSubmatch(line); m != nil {
+				return []string{m[1]}
+			}
+			return nil
+		})
+	case "rust":
+		return matchLines(content, func(line string) []string {
+			if m := rustImport.FindStringSubmatch(line); m != nil {
+				return []string{m[1]}
+			}
+			if m := rustModule.FindStringSubmatch(line); m != nil {
+				return []string{"self::" + m[1]}
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// matchLines applies match to every line that is not a comment
+func matchLines(content string, match func(line string) []string) []Import {
+	var imports []Import
+	for i, line := range strings.Split(content, "\n") {
+		if lineComment.MatchString(line) {
+			continue
+		}
+		for _, spec := range match(line) {
+			imports = append(imports, Import{Spec: spec, Line: i + 1})
+		}
+	}
+	return imports
+}
+
+// goImports reads the import declarations with go/parser
+func goImports(content string) []Import {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ImportsOnly)
+	if file == nil {
+		return nil
+	}
+	_ = err // a syntax error after the imports does not matter here
+
+	var imports []Import
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		imports = append(imports, Import{Spec: p, Line: fset.Position(spec.Pos()).Line})
+	}
+	return imports
+}
+
+// pythonSpecs returns the modules a Python import line names. For
+// "from pkg import a, b" it returns pkg.a and pkg.b, since a and b may be
+// submodules; resolution falls back to pkg when they are not.
+func pythonSpecs(line string) []string {
+	if m := pyFromImport.FindStringSubmatch(line); m != nil {
+		module := m[1]
+		var specs []string
+		for _, name := range strings.Split(m[2], ",") {
+			name = firstWord(name)
+			if name == "" || name == "*" {
+				continue
+			}
+			if strings.HasSuffix(module, ".") {
+				specs = append(specs, module+name)
+			} else {
+				specs = append(specs, module+"."+name)
+			}
+		}
+		if len(specs) == 0 {
+			specs = append(specs, module)
+		}
+		return specs
+	}
+	if m := pyImport.FindStringSubmatch(line); m != nil {
+		var specs []string
+		for _, part := range strings.Split(m[1], ",") {
+			if name := firstWord(part); name != "" {
+				specs = append(specs, name)
+			}
+		}
+		return specs
+	}
+	return nil
+}
+
+// firstWord returns the first word of s, dropping an "as" alias, or "" when
+// s is blank, as after the trailing comma of an import list
+func firstWord(s string) string {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// phpImports returns use statements as fully qualified names, and included
+// files as paths. Uses inside a class body import traits, relative to the
+// file's namespace.
+func phpImports(content string) []Import {
+	namespace := ""
+	if m := phpNamespace.FindStringSubmatch(content); m != nil {
+		namespace = m[1]
+	}
+
+	var imports []Import
+	depth := 0
+	for i, line := range strings.Split(content, "\n") {
+		if lineComment.MatchString(line) {
+			continue
+		}
+		if m := phpUse.FindStringSubmatch(line); m != nil {
+			name := strings.TrimSuffix(m[1], `\`)
+			// A use inside a class body is a trait, named relative to the namespace
+			if depth > 0 && namespace != "" && !strings.HasPrefix(strings.TrimSpace(line), `use \`) && !strings.Contains(name, `\`) {
+				name = namespace + `\` + name
+			}
+			if m[2] != "" {
+				for _, member := range strings.Split(m[2], ",") {
+					if member = firstWord(member); member != "" {
+						imports = append(imports, Import{Spec: name + `\` + member, Line: i + 1})
+					}
+				}
+			} else {
+				imports = append(imports, Import{Spec: name, Line: i + 1})
+			}
+		}
+		for _, m := range phpInclude.FindAllStringSubmatch(line, -1) {
+			spec := m[2]
+			// __DIR__ . "/x.php" is relative to the file, not the root
+			if m[1] != "" {
+				spec = "." + spec
+			}
+			imports = append(imports, Import{Spec: spec, Line: i + 1})
+		}
+		// Braced namespaces do not count as class bodies
+		if !phpNamespace.MatchString(line) {
+			depth += strings.Count(line, "{") - strings.Count(line, "}")
+		}
+	}
+	return imports
+}
+
+// phpClasses returns the fully qualified names of the classes, interfaces,
+// traits and enums a PHP file declares
+func phpClasses(content string) []string {
+	namespace := ""
+	if m := phpNamespace.FindStringSubmatch(content); m != nil {
+		namespace = m[1] + `\`
+	}
+	var names []string
+	for _, m := range phpDeclare.FindAllStringSubmatch(content, -1) {
+		names = append(names, namespace+m[1])
+	}
+	return names
+}
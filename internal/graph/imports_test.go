@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		want     []string
+	}{
+		{
+			name:     "python parenthesised import over several lines",
+			language: "python",
+			content:  "from typing import (Any,\n    Dict)\n",
+			want:     []string{"typing.Any"},
+		},
+		{
+			name:     "python trailing comma",
+			language: "python",
+			content:  "from pkg import (a, b,)\n",
+			want:     []string{"pkg.a", "pkg.b"},
+		},
+		{
+			name:     "python aliases",
+			language: "python",
+			content:  "import os.path as p, sys\nfrom . import models as m\n",
+			want:     []string{"os.path", "sys", ".models"},
+		},
+		{
+			name:     "php group use with a trailing comma",
+			language: "php",
+			content:  "<?php\nuse App\\Models\\{User, Post,};\n",
+			want:     []string{`App\Models\User`, `App\Models\Post`},
+		},
+		{
+			name:     "php group use with a blank member",
+			language: "php",
+			content:  "<?php\nuse App\\Models\\{User,\t};\n",
+			want:     []string{`App\Models\User`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, imp := range ParseImports(tt.language, tt.content) {
+				got = append(got, imp.Spec)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseImports() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
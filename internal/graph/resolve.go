@@ -0,0 +1,206 @@
+package graph
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// jsExtensions are tried, in order, for extensionless JS/TS imports
+var jsExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".vue", ".svelte"}
+
+// resolver maps import specs to the project files they name
+type resolver struct {
+	modules  map[string]string   // Go module path -> module directory
+	files    map[string]bool     // every file in the graph
+	packages map[string][]string // directory -> non-test Go files
+	classes  map[string]string   // lower-cased PHP FQCN -> file
+}
+
+func newResolver() *resolver {
+	return &resolver{
+		modules:  make(map[string]string),
+		files:    make(map[string]bool),
+		packages: make(map[string][]string),
+		classes:  make(map[string]string),
+	}
+}
+
+// addModule records the Go module declared by the go.mod in dir
+func (r *resolver) addModule(dir, goMod string) {
+	if module := readModulePath(goMod); module != "" {
+		r.modules[module] = dir
+	}
+}
+
+// addFile records what other files can import rel by
+func (r *resolver) addFile(rel, language, content string) {
+	r.files[rel] = true
+	switch language {
+	case "go":
+		if !strings.HasSuffix(rel, "_test.go") {
+			dir := path.Dir(rel)
+			r.packages[dir] = append(r.packages[dir], rel)
+		}
+	case "php":
+		for _, class := range phpClasses(content) {
+			r.classes[strings.ToLower(class)] = rel
+		}
+	}
+}
+
+// resolve returns the files f's import of spec refers to, or nil when the
+// import is external or cannot be found
+func (r *resolver) resolve(f *File, spec string) []string {
+	switch f.Language {
+	case "go":
+		return r.resolveGo(spec)
+	case "javascript", "typescript":
+		return r.resolveJS(f.Package, spec)
+	case "python":
+		return r.resolvePython(f.Package, spec)
+	case "php":
+		return r.resolvePHP(f.Package, spec)
+	}
+	return nil
+}
+
+// resolveGo maps an import path inside a module of the project to the
+// package's files. The longest matching module wins, for nested modules.
+func (r *resolver) resolveGo(spec string) []string {
+	best := ""
+	for module := range r.modules {
+		if (spec == module || strings.HasPrefix(spec, module+"/")) && len(module) > len(best) {
+			best = module
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	dir := path.Join(r.modules[best], strings.TrimPrefix(spec, best))
+	files := append([]string(nil), r.packages[dir]...)
+	sort.Strings(files)
+	return files
+}
+
+// resolveJS resolves relative imports: the file itself, with one of the
+// usual extensions, or the index file of a directory. Package imports are
+// external.
+func (r *resolver) resolveJS(dir, spec string) []string {
+	if !strings.HasPrefix(spec, ".") && !strings.HasPrefix(spec, "/") {
+		return nil
+	}
+	spec, _, _ = strings.Cut(spec, "?")
+	p := path.Join(dir, spec)
+	if strings.HasPrefix(spec, "/") {
+		p = strings.TrimPrefix(path.Clean(spec), "/")
+	}
+
+	candidates := []string{p}
+	// TypeScript sources are imported by their compiled name under ESM
+	if ext := path.Ext(p); ext == ".js" || ext == ".jsx" || ext == ".mjs" || ext == ".cjs" {
+		base := strings.TrimSuffix(p, ext)
+		candidates = append(candidates, base+".ts", base+".tsx", base+".mts", base+".cts")
+	}
+	for _, ext := range jsExtensions {
+		candidates = append(candidates, p+ext)
+	}
+	for _, ext := range jsExtensions {
+		candidates = append(candidates, path.Join(p, "index"+ext))
+	}
+	return r.first(candidates)
+}
+
+// resolvePython resolves a dotted module against the importing file's
+// package for relative imports, and against the root and src/ otherwise.
+// For "from pkg import name" the spec is pkg.name; when name is not a
+// module, pkg itself is the target.
+func (r *resolver) resolvePython(dir, spec string) []string {
+	trimmed := strings.TrimLeft(spec, ".")
+	dots := len(spec) - len(trimmed)
+
+	var bases []string
+	if dots > 0 {
+		base := dir
+		for i := 1; i < dots; i++ {
+			base = path.Dir(base)
+		}
+		bases = []string{base}
+	} else {
+		bases = []string{".", "src", r.pythonRoot(dir), dir}
+	}
+
+	var module []string
+	if trimmed != "" {
+		module = strings.Split(trimmed, ".")
+	}
+	for {
+		for _, base := range bases {
+			p := path.Join(append([]string{base}, module...)...)
+			candidates := []string{path.Join(p, "__init__.py")}
+			if len(module) > 0 {
+				candidates = append([]string{p + ".py"}, candidates...)
+			}
+			if files := r.first(candidates); files != nil {
+				return files
+			}
+		}
+		// Absolute imports stop at the top-level module; relative ones
+		// fall back to the package they are relative to
+		if len(module) == 0 || (len(module) == 1 && dots == 0) {
+			break
+		}
+		module = module[:len(module)-1]
+	}
+	return nil
+}
+
+// pythonRoot returns the directory above the outermost package containing
+// dir, which absolute imports are relative to
+func (r *resolver) pythonRoot(dir string) string {
+	for dir != "." && r.files[path.Join(dir, "__init__.py")] {
+		dir = path.Dir(dir)
+	}
+	return dir
+}
+
+// resolvePHP maps a use statement to the file declaring the class, falling
+// back to PSR-4 style paths, and an include to the file it names
+func (r *resolver) resolvePHP(dir, spec string) []string {
+	if strings.Contains(spec, "/") || strings.HasSuffix(spec, ".php") {
+		p := path.Join(dir, spec)
+		if strings.HasPrefix(spec, "/") {
+			p = strings.TrimPrefix(path.Clean(spec), "/")
+		}
+		return r.first([]string{p})
+	}
+
+	if rel, ok := r.classes[strings.ToLower(spec)]; ok {
+		return []string{rel}
+	}
+
+	// App\Models\User -> app/Models/User.php, src/Models/User.php, ...
+	parts := strings.Split(spec, `\`)
+	var candidates []string
+	for i := 0; i < len(parts) && i < 2; i++ {
+		rest := strings.Join(parts[i:], "/") + ".php"
+		for _, base := range []string{".", "src", "app", "lib"} {
+			candidates = append(candidates, path.Join(base, rest))
+		}
+		if i > 0 {
+			candidates = append(candidates, path.Join(strings.ToLower(parts[0]), rest))
+		}
+	}
+	return r.first(candidates)
+}
+
+// first returns the first candidate that is a file in the graph
+func (r *resolver) first(candidates []string) []string {
+	for _, c := range candidates {
+		c = path.Clean(c)
+		if r.files[c] {
+			return []string{c}
+		}
+	}
+	return nil
+}
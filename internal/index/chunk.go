@@ -0,0 +1,167 @@
+package index
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/snowsoft/codeweaver/internal/symbols"
+)
+
+// DefaultChunkLines is the window size used when no size is configured
+const DefaultChunkLines = 60
+
+// Chunk is a piece of a source file that is embedded on its own
+type Chunk struct {
+	Path      string // slash path relative to the index root
+	Language  string
+	StartLine int    // 1-based, inclusive
+	EndLine   int    // 1-based, inclusive
+	Symbol    string // declared name, empty for windows and file headers
+	Content   string
+}
+
+var (
+	// declaration matches a top-level declaration and captures its name
+	declaration = regexp.MustCompile(`^(?:export\s+|pub(?:\(crate\))?\s+|public\s+|private\s+|protected\s+|internal\s+|abstract\s+|final\s+|static\s+|async\s+|default\s+|unsafe\s+)*(?:func|type|class|interface|trait|struct|enum|impl|fn|def|function|module|namespace)\b\s*(?:\([^)]*\)\s*)?\*?([A-Za-z_$][\w$]*)`)
+	// leading matches comment and decorator lines that belong to the next declaration
+	leading = regexp.MustCompile(`^\s*(?://|#|/\*|\*|@|"""|''')`)
+)
+
+// Split cuts content into chunks at top-level declarations, and at the
+// members of long classes. Declarations longer than twice chunkLines, and
+// files without declarations, are cut into overlapping windows of
+// chunkLines lines.
+func Split(path, language, content string, chunkLines int) []Chunk {
+	if chunkLines <= 0 {
+		chunkLines = DefaultChunkLines
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+		return nil
+	}
+
+	var starts []start
+	if symbols.Supported(language) {
+		starts = symbolStarts(path, content, len(lines), chunkLines)
+	} else {
+		starts = declarationStarts(lines)
+	}
+
+	if len(starts) == 0 {
+		return windows(path, language, lines, 0, len(lines), "", chunkLines)
+	}
+
+	var chunks []Chunk
+	if starts[0].line > 0 {
+		chunks = append(chunks, windows(path, language, lines, 0, starts[0].line, "", chunkLines)...)
+	}
+	for i, s := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1].line
+		}
+		chunks = append(chunks, windows(path, language, lines, s.line, end, s.symbol, chunkLines)...)
+	}
+	return chunks
+}
+
+// start is where a chunk begins, 0-based
+type start struct {
+	line   int
+	symbol string
+}
+
+// symbolStarts starts a chunk at each top-level symbol, and at the members
+// of classes too long to embed as one chunk
+func symbolStarts(path, content string, lineCount, chunkLines int) []start {
+	syms, err := symbols.Extract(path, []byte(content))
+	if err != nil {
+		return nil
+	}
+
+	var starts []start
+	var long []symbols.Symbol // containers split at their members
+	for _, s := range syms {
+		if s.StartLine > lineCount {
+			continue
+		}
+		inLong := false
+		for _, c := range long {
+			if s.Parent == c.Name && s.StartLine <= c.EndLine {
+				inLong = true
+			}
+		}
+		if s.Parent != "" && !inLong {
+			continue
+		}
+		// A nested symbol overlapping the previous chunk start is merged into it
+		if len(starts) > 0 && s.StartLine-1 <= starts[len(starts)-1].line {
+			continue
+		}
+		starts = append(starts, start{line: s.StartLine - 1, symbol: s.Name})
+		if s.Parent == "" && s.EndLine-s.StartLine > 2*chunkLines {
+			long = append(long, s)
+		}
+	}
+	return starts
+}
+
+// declarationStarts finds declarations with a regular expression, for
+// languages the symbols package does not parse
+func declarationStarts(lines []string) []start {
+	var starts []start
+	for i, line := range lines {
+		m := declaration.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		first := i
+		for first > 0 && leading.MatchString(lines[first-1]) {
+			first--
+		}
+		if len(starts) > 0 && first <= starts[len(starts)-1].line {
+			first = i
+		}
+		starts = append(starts, start{line: first, symbol: m[1]})
+	}
+	return starts
+}
+
+// windows returns lines[from:to] as one chunk, or as overlapping windows
+// when it is longer than twice size. Blank-only ranges yield nothing.
+func windows(path, language string, lines []string, from, to int, symbol string, size int) []Chunk {
+	for to > from && strings.TrimSpace(lines[to-1]) == "" {
+		to--
+	}
+	if to <= from {
+		return nil
+	}
+
+	step := size
+	if to-from > 2*size {
+		step = size - size/6 // overlap windows so no boundary splits a thought
+	} else {
+		size, step = to-from, to-from
+	}
+
+	var chunks []Chunk
+	for i := from; i < to; i += step {
+		end := i + size
+		if end > to {
+			end = to
+		}
+		chunks = append(chunks, Chunk{
+			Path:      path,
+			Language:  language,
+			StartLine: i + 1,
+			EndLine:   end,
+			Symbol:    symbol,
+			Content:   strings.Join(lines[i:end], "\n"),
+		})
+		if end == to {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,438 @@
+// Package index maintains a local semantic index of a project's source
+// files. Files are split into chunks, embedded with an embedding model and
+// stored under .weaver/index keyed by content hash, so updating the index
+// only embeds files that changed.
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/snowsoft/codeweaver/internal/ai"
+	"github.com/snowsoft/codeweaver/internal/utils"
+	"github.com/snowsoft/codeweaver/internal/walker"
+)
+
+// ErrNotIndexed is returned when querying a root that has no index yet
+var ErrNotIndexed = errors.New("no index found, run 'weaver index' first")
+
+const (
+	maxFileSize    = 1024 * 1024 // larger files are not indexed
+	maxEmbedChars  = 6000        // longer chunks are truncated before embedding
+	embedBatchSize = 32
+	saveEvery      = 25 // files embedded between manifest saves
+)
+
+// Index is the semantic index of the files under a root directory
+type Index struct {
+	root     string
+	dir      string
+	manifest manifest
+	objects  map[string]*object // loaded objects by hash
+}
+
+// UpdateOptions configure Update
+type UpdateOptions struct {
+	Model      string // embedding model
+	ChunkLines int    // window size for files without declarations
+	Rebuild    bool   // discard the existing index first
+
+	// Progress, if set, is called after each file is processed
+	Progress func(done, total int, file string)
+}
+
+// Stats summarizes an Update
+type Stats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Chunks    int // chunks embedded
+}
+
+// QueryOptions narrow down a query
+type QueryOptions struct {
+	TopK      int      // number of results, 10 if not set
+	Paths     []string // directories, files or globs to search; all files if empty
+	Languages []string // languages to search; all if empty
+}
+
+// Result is a chunk matching a query
+type Result struct {
+	Chunk
+	Score float64 // cosine similarity to the query
+}
+
+// Open opens the index of the project at root. The index does not have to exist yet.
+func Open(root string) (*Index, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	ix := &Index{
+		root:    absRoot,
+		dir:     filepath.Join(absRoot, filepath.FromSlash(Dir)),
+		objects: make(map[string]*object),
+	}
+	if err := ix.loadManifest(); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// FindRoot returns the nearest directory at or above dir that has an
+// index, or dir itself when none does
+func FindRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for current := absDir; ; {
+		if _, err := os.Stat(filepath.Join(current, filepath.FromSlash(Dir), "manifest.json")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return absDir
+		}
+		current = parent
+	}
+}
+
+// Root returns the indexed directory
+func (ix *Index) Root() string {
+	return ix.root
+}
+
+// Model returns the embedding model the index was built with
+func (ix *Index) Model() string {
+	return ix.manifest.Model
+}
+
+// Files returns the number of indexed files
+func (ix *Index) Files() int {
+	return len(ix.manifest.Files)
+}
+
+// Update brings the index in line with the files under the root: new and
+// changed files are chunked and embedded, deleted files are dropped.
+// Changing the model or chunk size rebuilds the index.
+func (ix *Index) Update(ctx context.Context, embedder ai.Embedder, opts UpdateOptions) (*Stats, error) {
+	if opts.Model == "" {
+		return nil, fmt.Errorf("no embedding model configured")
+	}
+	if opts.ChunkLines <= 0 {
+		opts.ChunkLines = DefaultChunkLines
+	}
+
+	files, err := sourceFiles(ix.root)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Rebuild || ix.manifest.Model != opts.Model || ix.manifest.ChunkLines != opts.ChunkLines {
+		if err := ix.clear(); err != nil {
+			return nil, err
+		}
+		ix.manifest.Model = opts.Model
+		ix.manifest.ChunkLines = opts.ChunkLines
+	}
+	// Save the settings right away so an interrupted run can resume
+	if err := ix.saveManifest(); err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{}
+	seen := make(map[string]bool, len(files))
+	embedded := 0
+
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			ix.saveManifest()
+			return stats, err
+		}
+		seen[file.rel] = true
+
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+
+		previous, indexed := ix.manifest.Files[file.rel]
+		if indexed && previous.Hash == hash && ix.hasObject(hash) {
+			stats.Unchanged++
+		} else {
+			chunks := len(Split(file.rel, file.language, string(content), opts.ChunkLines))
+			// Identical content elsewhere, or from an interrupted run, is reused
+			if !ix.hasObject(hash) {
+				obj, err := ix.embedFile(ctx, embedder, opts, file, string(content))
+				if err != nil {
+					ix.saveManifest()
+					return stats, fmt.Errorf("failed to index %s: %w", file.rel, err)
+				}
+				if err := ix.saveObject(hash, obj); err != nil {
+					return stats, err
+				}
+				stats.Chunks += len(obj.Chunks)
+				embedded++
+			}
+
+			if indexed {
+				stats.Updated++
+			} else {
+				stats.Added++
+			}
+			ix.manifest.Files[file.rel] = fileEntry{Hash: hash, Language: file.language, Chunks: chunks}
+
+			if embedded > 0 && embedded%saveEvery == 0 {
+				if err := ix.saveManifest(); err != nil {
+					return stats, err
+				}
+			}
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(files), file.rel)
+		}
+	}
+
+	for rel := range ix.manifest.Files {
+		if !seen[rel] {
+			delete(ix.manifest.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	if err := ix.saveManifest(); err != nil {
+		return stats, err
+	}
+	ix.collectGarbage()
+	return stats, nil
+}
+
+// sourceFile is a file to index
+type sourceFile struct {
+	path     string
+	rel      string
+	language string
+}
+
+// sourceFiles lists the source files under root that are not ignored
+func sourceFiles(root string) ([]sourceFile, error) {
+	rels, err := walker.Files(root, walker.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []sourceFile
+	for _, rel := range rels {
+		language := utils.DetectLanguage(rel)
+		if language == "unknown" {
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if info, err := os.Stat(p); err != nil || info.Size() > maxFileSize {
+			continue
+		}
+		files = append(files, sourceFile{path: p, rel: rel, language: language})
+	}
+	return files, nil
+}
+
+// embedFile chunks content and embeds the chunks in batches
+func (ix *Index) embedFile(ctx context.Context, embedder ai.Embedder, opts UpdateOptions, file sourceFile, content string) (*object, error) {
+	chunks := Split(file.rel, file.language, content, opts.ChunkLines)
+	obj := &object{Chunks: chunks, Vectors: make([][]float32, 0, len(chunks))}
+
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		inputs := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			inputs = append(inputs, embedText(chunk))
+		}
+		resp, err := embedder.Embed(ctx, ai.EmbedRequest{Model: opts.Model, Input: inputs})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Embeddings) != len(inputs) {
+			return nil, fmt.Errorf("expected %d embeddings for %s, got %d", len(inputs), file.rel, len(resp.Embeddings))
+		}
+		for _, vector := range resp.Embeddings {
+			obj.Vectors = append(obj.Vectors, normalize(vector))
+		}
+	}
+
+	// Paths come from the manifest, since identical files share the object
+	for i := range obj.Chunks {
+		obj.Chunks[i].Path = ""
+	}
+	return obj, nil
+}
+
+// embedText is the text embedded for a chunk
+func embedText(chunk Chunk) string {
+	text := chunk.Content
+	if chunk.Symbol != "" {
+		text = chunk.Symbol + "\n" + text
+	}
+	if len(text) > maxEmbedChars {
+		// Cut at a character boundary, not inside a multi-byte character
+		end := maxEmbedChars
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end]
+	}
+	return text
+}
+
+func (ix *Index) hasObject(hash string) bool {
+	_, err := os.Stat(ix.objectPath(hash))
+	return err == nil
+}
+
+// Query embeds text with the index's model and returns the closest chunks
+func (ix *Index) Query(ctx context.Context, embedder ai.Embedder, text string, opts QueryOptions) ([]Result, error) {
+	if ix.Files() == 0 {
+		return nil, ErrNotIndexed
+	}
+
+	resp, err := embedder.Embed(ctx, ai.EmbedRequest{Model: ix.manifest.Model, Input: []string{text}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(resp.Embeddings) == 0 {
+		return nil, fmt.Errorf("failed to embed query: no embedding returned")
+	}
+	return ix.Search(resp.Embeddings[0], opts)
+}
+
+// Search returns the top-k chunks by cosine similarity to vector
+func (ix *Index) Search(vector []float32, opts QueryOptions) ([]Result, error) {
+	if ix.Files() == 0 {
+		return nil, ErrNotIndexed
+	}
+	if opts.TopK <= 0 {
+		opts.TopK = 10
+	}
+	query := normalize(vector)
+
+	var results []Result
+	for rel, entry := range ix.manifest.Files {
+		if !matchPaths(rel, opts.Paths) || !matchLanguages(entry.Language, opts.Languages) {
+			continue
+		}
+
+		obj, ok := ix.objects[entry.Hash]
+		if !ok {
+			loaded, err := ix.loadObject(entry.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("index is out of date, run 'weaver index': %w", err)
+			}
+			ix.objects[entry.Hash] = loaded
+			obj = loaded
+		}
+		if len(obj.Vectors) != len(obj.Chunks) {
+			return nil, fmt.Errorf("index entry for %s is damaged, run 'weaver index --rebuild'", rel)
+		}
+
+		for i, chunk := range obj.Chunks {
+			if len(obj.Vectors[i]) != len(query) {
+				return nil, fmt.Errorf("index was built with a different model than the query, run 'weaver index --rebuild'")
+			}
+			chunk.Path = rel
+			results = append(results, Result{Chunk: chunk, Score: dot(query, obj.Vectors[i])})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+	if len(results) > opts.TopK {
+		results = results[:opts.TopK]
+	}
+	return results, nil
+}
+
+// matchPaths reports whether rel is one of paths, lies under one of them,
+// or matches one as a glob. A glob without a slash matches the file name.
+func matchPaths(rel string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		p = strings.Trim(path.Clean(filepath.ToSlash(p)), "/")
+		if p == "." || p == "" || rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if !strings.Contains(p, "/") {
+			if ok, _ := path.Match(p, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchLanguages(language string, languages []string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	for _, l := range languages {
+		if strings.EqualFold(l, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize scales v to unit length, so cosine similarity is a dot product
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// word matches identifiers and words in queries and code
+var word = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*`)
+
+// stopWords are left out of lexical queries
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "what": true,
+	"where": true, "when": true, "which": true, "who": true, "how": true, "why": true,
+	"does": true, "did": true, "this": true, "that": true, "with": true, "from": true,
+	"into": true, "there": true, "here": true, "can": true, "should": true, "would": true,
+	"code": true, "file": true, "files": true, "function": true, "use": true, "used": true,
+}
+
+// Lexical searches the source files under root for chunks sharing words
+// with query. It needs no index or embedding model and is used when there
+// is no index. Scores are BM25 weights, not similarities.
+func Lexical(root, query string, opts QueryOptions) ([]Result, error) {
+	if opts.TopK <= 0 {
+		opts.TopK = 10
+	}
+	queryTerms := terms(query)
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+
+	files, err := sourceFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		chunk Chunk
+		tf    map[string]int
+		words int
+	}
+	var chunks []scored
+	df := make(map[string]int)
+	totalWords := 0
+
+	for _, file := range files {
+		if !matchPaths(file.rel, opts.Paths) || !matchLanguages(file.language, opts.Languages) {
+			continue
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+		for _, chunk := range Split(file.rel, file.language, string(content), DefaultChunkLines) {
+			tf := make(map[string]int)
+			words := 0
+			for _, t := range termList(chunk.Path + " " + chunk.Content) {
+				words++
+				if _, ok := queryTerms[t]; ok {
+					tf[t]++
+				}
+			}
+			for t := range tf {
+				df[t]++
+			}
+			chunks = append(chunks, scored{chunk: chunk, tf: tf, words: words})
+			totalWords += words
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	// BM25 with the usual parameters
+	const k1, b = 1.2, 0.75
+	avgWords := float64(totalWords) / float64(len(chunks))
+	n := float64(len(chunks))
+
+	var results []Result
+	for _, c := range chunks {
+		score := 0.0
+		for t, tf := range c.tf {
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			f := float64(tf)
+			score += idf * f * (k1 + 1) / (f + k1*(1-b+b*float64(c.words)/avgWords))
+		}
+		if score > 0 {
+			results = append(results, Result{Chunk: c.chunk, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+	if len(results) > opts.TopK {
+		results = results[:opts.TopK]
+	}
+	return results, nil
+}
+
+// terms splits text into lower-case words, breaking identifiers at
+// camelCase boundaries and dropping stop words and very short words
+func terms(text string) map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, t := range termList(text) {
+		out[t] = struct{}{}
+	}
+	return out
+}
+
+func termList(text string) []string {
+	var out []string
+	for _, w := range word.FindAllString(text, -1) {
+		for _, part := range splitCamel(w) {
+			part = strings.ToLower(part)
+			if len(part) < 3 || stopWords[part] {
+				continue
+			}
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// splitCamel splits "parseHTTPRequest" into "parse", "HTTP" and "Request"
+func splitCamel(s string) []string {
+	var parts []string
+	start := 0
+	runes := []rune(s)
+	for i := 1; i < len(runes); i++ {
+		if (unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])) ||
+			(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])) {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
@@ -0,0 +1,164 @@
+package index
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir is where the index is stored, relative to the indexed root
+const Dir = ".weaver/index"
+
+// formatVersion changes whenever the on-disk layout does
+const formatVersion = 2
+
+// manifest records which files are indexed and with which settings
+type manifest struct {
+	Version    int                  `json:"version"`
+	Model      string               `json:"model"`
+	ChunkLines int                  `json:"chunk_lines"`
+	Files      map[string]fileEntry `json:"files"`
+}
+
+// fileEntry is an indexed file. Its chunks live in the object named by Hash.
+type fileEntry struct {
+	Hash     string `json:"hash"`
+	Language string `json:"language"`
+	Chunks   int    `json:"chunks"`
+}
+
+// object holds the chunks of one file content and their normalized
+// embeddings. Chunk paths are not stored, since identical files share an object.
+type object struct {
+	Chunks  []Chunk
+	Vectors [][]float32
+}
+
+func (ix *Index) manifestPath() string {
+	return filepath.Join(ix.dir, "manifest.json")
+}
+
+func (ix *Index) objectPath(hash string) string {
+	return filepath.Join(ix.dir, "objects", hash[:2], hash+".gob")
+}
+
+// loadManifest reads the manifest, returning an empty one if there is none
+func (ix *Index) loadManifest() error {
+	ix.manifest = manifest{Version: formatVersion, Files: make(map[string]fileEntry)}
+
+	data, err := os.ReadFile(ix.manifestPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", ix.manifestPath(), err)
+	}
+	// An index written in another format is rebuilt from scratch
+	if m.Version != formatVersion || m.Files == nil {
+		return nil
+	}
+	ix.manifest = m
+	return nil
+}
+
+// saveManifest writes the manifest atomically
+func (ix *Index) saveManifest() error {
+	if err := os.MkdirAll(ix.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	// Keep the index out of version control
+	ignore := filepath.Join(ix.dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+
+	data, err := json.MarshalIndent(ix.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	tmp := ix.manifestPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.manifestPath()); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// loadObject reads the chunks stored under hash
+func (ix *Index) loadObject(hash string) (*object, error) {
+	f, err := os.Open(ix.objectPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var obj object
+	if err := gob.NewDecoder(f).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to read index object %s: %w", hash, err)
+	}
+	if len(obj.Chunks) != len(obj.Vectors) {
+		return nil, fmt.Errorf("index object %s is corrupt", hash)
+	}
+	return &obj, nil
+}
+
+// saveObject stores obj under hash
+func (ix *Index) saveObject(hash string, obj *object) error {
+	file := ix.objectPath(hash)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write index object: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(obj); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write index object: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write index object: %w", err)
+	}
+	return os.Rename(tmp, file)
+}
+
+// collectGarbage removes objects that no indexed file refers to
+func (ix *Index) collectGarbage() {
+	live := make(map[string]bool, len(ix.manifest.Files))
+	for _, entry := range ix.manifest.Files {
+		live[entry.Hash+".gob"] = true
+	}
+
+	objects := filepath.Join(ix.dir, "objects")
+	filepath.WalkDir(objects, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if !live[d.Name()] {
+			os.Remove(p)
+		}
+		return nil
+	})
+}
+
+// clear removes every stored object, for a rebuild or a model change
+func (ix *Index) clear() error {
+	ix.manifest.Files = make(map[string]fileEntry)
+	if err := os.RemoveAll(filepath.Join(ix.dir, "objects")); err != nil {
+		return fmt.Errorf("failed to clear index: %w", err)
+	}
+	return nil
+}
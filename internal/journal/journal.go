@@ -0,0 +1,390 @@
+// Package journal records the files weaver writes so that they can be
+// restored. Each command that writes files becomes an operation holding,
+// for every file, the hashes of its content before and after the write and
+// the command line that wrote it. Previous contents are kept by hash, so
+// undoing an operation can put every file back as it was.
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Layout of the journal directory
+const (
+	operationsDir = "journal"
+	objectsDir    = "objects"
+)
+
+// Journal is the record of the changes made to a project
+type Journal struct {
+	root string // project root, which recorded paths are relative to
+	dir  string // directory holding the operations and the saved contents
+}
+
+// Operation is one command's changes
+type Operation struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Undoes  int       `json:"undoes,omitempty"` // the operation this one reverted
+	Files   []Change  `json:"files"`
+
+	journal *Journal
+	mu      sync.Mutex
+}
+
+// Change is what an operation did to one file. An empty hash means the
+// file did not exist.
+type Change struct {
+	Path   string `json:"path"` // relative to the project root, or absolute outside it
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Kind returns A for a created file, D for a removed one and M otherwise
+func (c Change) Kind() string {
+	switch {
+	case c.Before == "":
+		return "A"
+	case c.After == "":
+		return "D"
+	default:
+		return "M"
+	}
+}
+
+// FindRoot returns the project root for dir: the nearest directory that
+// already holds a journal in backupDir, or else a git work tree, or dir
+func FindRoot(dir, backupDir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	if filepath.IsAbs(backupDir) {
+		return absDir
+	}
+
+	gitRoot := ""
+	for current := absDir; ; {
+		if _, err := os.Stat(filepath.Join(current, backupDir, operationsDir)); err == nil {
+			return current
+		}
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil && gitRoot == "" {
+			gitRoot = current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+	if gitRoot != "" {
+		return gitRoot
+	}
+	return absDir
+}
+
+// Open returns the journal of the project at root, kept in dir, which is
+// relative to root unless absolute
+func Open(root, dir string) *Journal {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return &Journal{root: root, dir: dir}
+}
+
+// Dir returns the directory the journal is kept in
+func (j *Journal) Dir() string {
+	return j.dir
+}
+
+// Begin starts an operation for command. It is saved with the first file
+// it records.
+func (j *Journal) Begin(command string) *Operation {
+	return &Operation{Command: command, journal: j}
+}
+
+// WriteFile writes content to path like os.WriteFile, first saving the
+// file's current content and then recording the change. A nil operation
+// only writes the file.
+func (op *Operation) WriteFile(path string, content []byte, perm os.FileMode) error {
+	before, err := op.Backup(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, content, perm); err != nil {
+		return err
+	}
+	return op.Record(path, before, content)
+}
+
+// Backup saves the current content of the file at path before it is
+// replaced by other means than WriteFile, and returns its hash for Record
+func (op *Operation) Backup(path string) (string, error) {
+	if op == nil {
+		return "", nil
+	}
+	before, err := op.journal.save(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return before, nil
+}
+
+// Record records that the file at path, which had the content hashed by
+// Backup, now holds content
+func (op *Operation) Record(path, before string, content []byte) error {
+	if op == nil {
+		return nil
+	}
+	return op.record(path, before, hash(content))
+}
+
+// Remove removes the file at path, first saving its content and then
+// recording the change
+func (op *Operation) Remove(path string) error {
+	before, err := op.Backup(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil || op == nil {
+		return err
+	}
+	return op.record(path, before, "")
+}
+
+// record adds a change to the operation and saves it. A file written twice
+// keeps the content it had before the first write.
+func (op *Operation) record(path, before, after string) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	rel := op.journal.rel(path)
+	found := false
+	for i := range op.Files {
+		if op.Files[i].Path == rel {
+			op.Files[i].After = after
+			found = true
+		}
+	}
+	if !found {
+		op.Files = append(op.Files, Change{Path: rel, Before: before, After: after})
+	}
+	if err := op.journal.persist(op); err != nil {
+		return fmt.Errorf("failed to record %s in the journal: %w", path, err)
+	}
+	return nil
+}
+
+// List returns the journal's operations, newest first
+func (j *Journal) List() ([]*Operation, error) {
+	entries, err := os.ReadDir(filepath.Join(j.dir, operationsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Operation
+	for _, entry := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if op, err := j.Get(id); err == nil {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(a, b int) bool { return ops[a].ID > ops[b].ID })
+	return ops, nil
+}
+
+// Get reads the operation with the given ID
+func (j *Journal) Get(id int) (*Operation, error) {
+	data, err := os.ReadFile(j.operationPath(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no operation %d in %s", id, filepath.Join(j.dir, operationsDir))
+	}
+	if err != nil {
+		return nil, err
+	}
+	op := &Operation{journal: j}
+	if err := json.Unmarshal(data, op); err != nil {
+		return nil, fmt.Errorf("failed to parse operation %d: %w", id, err)
+	}
+	return op, nil
+}
+
+// Modified returns the files of op that no longer have the content it left
+func (j *Journal) Modified(op *Operation) []string {
+	var modified []string
+	for _, c := range op.Files {
+		current, err := hashFile(j.abs(c.Path))
+		if err != nil || current != c.After {
+			modified = append(modified, c.Path)
+		}
+	}
+	return modified
+}
+
+// Undo puts the files op changed back as they were before it, recording
+// that as a new operation for command
+func (j *Journal) Undo(op *Operation, command string) (*Operation, error) {
+	undo := j.Begin(command)
+	undo.Undoes = op.ID
+
+	var errs []error
+	for i := len(op.Files) - 1; i >= 0; i-- {
+		c := op.Files[i]
+		path := j.abs(c.Path)
+		if c.Before == "" {
+			if err := undo.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		content, err := os.ReadFile(j.objectPath(c.Before))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("the content of %s before operation %d is missing: %w", c.Path, op.ID, err))
+			continue
+		}
+		perm := os.FileMode(0644)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		} else if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := undo.WriteFile(path, content, perm); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return undo, errors.Join(errs...)
+}
+
+// save stores the current content of the file at path and returns its
+// hash, or "" when the file does not exist
+func (j *Journal) save(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sum := hash(content)
+	object := j.objectPath(sum)
+	if _, err := os.Stat(object); err == nil {
+		return sum, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(object, content, 0600); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// persist writes op to the journal, giving it the next free ID the first
+// time
+func (j *Journal) persist(op *Operation) error {
+	if op.ID == 0 {
+		if err := j.create(op); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(j.operationPath(op.ID), append(data, '\n'), 0644)
+}
+
+// create claims the next free operation ID for op
+func (j *Journal) create(op *Operation) error {
+	if err := os.MkdirAll(filepath.Join(j.dir, operationsDir), 0755); err != nil {
+		return err
+	}
+	ops, err := j.List()
+	if err != nil {
+		return err
+	}
+	id := 1
+	if len(ops) > 0 {
+		id = ops[0].ID + 1
+	}
+	for ; ; id++ {
+		f, err := os.OpenFile(j.operationPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		op.ID, op.Time = id, time.Now()
+		return f.Close()
+	}
+}
+
+func (j *Journal) operationPath(id int) string {
+	return filepath.Join(j.dir, operationsDir, strconv.Itoa(id)+".json")
+}
+
+func (j *Journal) objectPath(sum string) string {
+	return filepath.Join(j.dir, objectsDir, sum[:2], sum[2:])
+}
+
+// rel returns path as recorded: relative to the root when inside it
+func (j *Journal) rel(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(j.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// abs returns the path of a recorded file
+func (j *Journal) abs(path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(j.root, path)
+}
+
+// hash returns the hex SHA-256 of content
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the hash of the file at path, or "" when it does not exist
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash(content), nil
+}
@@ -0,0 +1,397 @@
+package patch
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// How an edit was located, from the strictest match to the loosest
+const (
+	MatchExact      = "exact"
+	MatchWhitespace = "whitespace" // trailing whitespace ignored
+	MatchIndent     = "indent"     // indentation ignored; the replacement is reindented
+	MatchFuzzy      = "fuzzy"      // similar lines; only the lines the edit changes have to match
+)
+
+// minSimilarity is how similar lines have to be to the search text for a
+// fuzzy match
+const minSimilarity = 0.8
+
+// Applied is an edit that was applied
+type Applied struct {
+	Edit  int    // index in the edits passed to Apply
+	Line  int    // 1-based line where it was applied
+	Match string // MatchExact, MatchWhitespace, MatchIndent or MatchFuzzy
+}
+
+// Failure is an edit that could not be applied
+type Failure struct {
+	Edit   int // index in the edits passed to Apply
+	Reason string
+}
+
+// Result is the content after applying edits
+type Result struct {
+	Content string
+	Applied []Applied
+	Failed  []Failure
+}
+
+// Apply applies edits to content in order. An edit that cannot be located
+// unambiguously is skipped and reported in Failed; the others still apply.
+func Apply(content string, edits []Edit) *Result {
+	result := &Result{Content: content}
+	for i, edit := range edits {
+		updated, line, match, err := apply(result.Content, edit)
+		if err != nil {
+			result.Failed = append(result.Failed, Failure{Edit: i, Reason: err.Error()})
+			continue
+		}
+		result.Content = updated
+		result.Applied = append(result.Applied, Applied{Edit: i, Line: line, Match: match})
+	}
+	return result
+}
+
+// apply applies one edit and returns the new content, the line it was
+// applied at and how it was located
+func apply(content string, edit Edit) (string, int, string, error) {
+	if strings.TrimSpace(edit.Search) == "" {
+		if strings.TrimSpace(content) == "" {
+			return withNewline(edit.Replace, content == "" || strings.HasSuffix(content, "\n")), 1, MatchExact, nil
+		}
+		return "", 0, "", fmt.Errorf("the search text is empty")
+	}
+
+	lines := strings.Split(content, "\n")
+	search := strings.Split(edit.Search, "\n")
+	replace := strings.Split(edit.Replace, "\n")
+	if edit.Replace == "" {
+		replace = nil
+	}
+
+	for _, m := range []struct {
+		name  string
+		equal func(a, b string) bool
+	}{
+		{MatchExact, func(a, b string) bool { return a == b }},
+		{MatchWhitespace, func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") }},
+		{MatchIndent, func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }},
+	} {
+		starts := find(lines, search, m.equal)
+		if len(starts) == 0 {
+			continue
+		}
+		start, err := choose(starts, edit.Line)
+		if err != nil {
+			return "", 0, "", err
+		}
+		with := replace
+		if m.name == MatchIndent {
+			with = reindent(replace, search, lines[start:start+len(search)])
+		}
+		return splice(lines, start, len(search), with), start + 1, m.name, nil
+	}
+
+	windows := similar(lines, search)
+	if len(windows) == 0 {
+		return "", 0, "", fmt.Errorf("the search text was not found")
+	}
+	starts := make([]int, len(windows))
+	for i, w := range windows {
+		starts[i] = w[0]
+	}
+	start, err := choose(starts, edit.Line)
+	if err != nil {
+		return "", 0, "", err
+	}
+	end := windows[slices.Index(starts, start)][1]
+	with, ok := change(lines[start:end], search, replace)
+	if !ok {
+		return "", 0, "", fmt.Errorf("the search text was only found approximately at line %d, and the lines to change differ from it", start+1)
+	}
+	return splice(lines, start, end-start, with), start + 1, MatchFuzzy, nil
+}
+
+// change makes the line changes from search to replace in found, lines of
+// the file similar to search. Lines the edit keeps stay as they are in
+// found. The lines it changes, and a line next to an insertion, have to be
+// in found as in search, indentation aside; otherwise change reports false
+// rather than guess.
+func change(found, search, replace []string) ([]string, bool) {
+	// Where each search line is in found, or -1
+	at := make([]int, len(search))
+	for i := range at {
+		at[i] = -1
+	}
+	for _, m := range common(search, found, func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }) {
+		at[m[0]] = m[1]
+	}
+
+	var out []string
+	next := 0 // the first line of found not copied to out yet
+	s, r := 0, 0
+	kept := append(common(search, replace, func(a, b string) bool { return a == b }), [2]int{len(search), len(replace)})
+	for _, k := range kept {
+		if s < k[0] || r < k[1] {
+			// search[s:k[0]] becomes replace[r:k[1]]
+			var from, to int
+			var was, is []string // search lines and the lines of found they are, to reindent by
+			if s < k[0] {
+				from = at[s]
+				for i := s; i < k[0]; i++ {
+					if at[i] < 0 || at[i] != from+i-s {
+						return nil, false
+					}
+				}
+				to = from + k[0] - s
+				was, is = search[s:k[0]], found[from:to]
+			} else {
+				// An insertion goes after the line before it or before the
+				// line after it; when both are found, they have to be next
+				// to each other
+				before, after := -1, -1
+				if s > 0 {
+					before = at[s-1]
+				}
+				if s < len(search) {
+					after = at[s]
+				}
+				switch {
+				case before >= 0 && after >= 0 && after != before+1:
+					return nil, false
+				case before >= 0:
+					from = before + 1
+					was, is = search[s-1:s], found[before:from]
+				case after >= 0:
+					from = after
+					was, is = search[s:s+1], found[after:after+1]
+				default:
+					return nil, false
+				}
+				to = from
+			}
+			if from < next {
+				return nil, false
+			}
+			out = append(out, found[next:from]...)
+			out = append(out, reindent(replace[r:k[1]], was, is)...)
+			next = to
+		}
+		s, r = k[0]+1, k[1]+1
+	}
+	return append(out, found[next:]...), true
+}
+
+// common returns the index pairs of a longest common subsequence of a and b
+func common(a, b []string, equal func(a, b string) bool) [][2]int {
+	// length[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	length := make([][]int, len(a)+1)
+	for i := range length {
+		length[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if equal(a[i], b[j]) {
+				length[i][j] = length[i+1][j+1] + 1
+			} else {
+				length[i][j] = max(length[i+1][j], length[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case equal(a[i], b[j]):
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case length[i+1][j] >= length[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// find returns the indexes at which search occurs in lines
+func find(lines, search []string, equal func(a, b string) bool) []int {
+	var starts []int
+	for i := 0; i+len(search) <= len(lines); i++ {
+		match := true
+		for j := range search {
+			if !equal(lines[i+j], search[j]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// choose picks the match closest to the expected line. Without one, the
+// match has to be unique.
+func choose(starts []int, line int) (int, error) {
+	if len(starts) == 1 {
+		return starts[0], nil
+	}
+	if line <= 0 {
+		var at []string
+		for _, s := range starts {
+			at = append(at, fmt.Sprint(s+1))
+		}
+		return 0, fmt.Errorf("the search text matches %d places (lines %s); it needs more context", len(starts), strings.Join(at, ", "))
+	}
+	best := starts[0]
+	for _, s := range starts[1:] {
+		if abs(s+1-line) < abs(best+1-line) {
+			best = s
+		}
+	}
+	return best, nil
+}
+
+// similar returns the windows of lines most similar to search, allowing
+// one line more or less, as [start, end) pairs. Windows overlapping an
+// earlier one count as the same place; several places are returned when
+// they are equally similar, so that the caller can reject the ambiguity.
+func similar(lines, search []string) [][2]int {
+	dmp := diffmatchpatch.New()
+	text := strings.Join(search, "\n")
+
+	// Only windows sharing a line with the search text are compared
+	anchors := make(map[string]bool)
+	for _, l := range search {
+		if l = strings.TrimSpace(l); l != "" {
+			anchors[l] = true
+		}
+	}
+
+	type candidate struct {
+		start, end int
+		score      float64
+	}
+	var candidates []candidate
+	bestScore := 0.0
+	for size := len(search) - 1; size <= len(search)+1; size++ {
+		if size < 1 {
+			continue
+		}
+		for i := 0; i+size <= len(lines); i++ {
+			shared := false
+			for _, l := range lines[i : i+size] {
+				if anchors[strings.TrimSpace(l)] {
+					shared = true
+					break
+				}
+			}
+			if !shared {
+				continue
+			}
+
+			window := strings.Join(lines[i:i+size], "\n")
+			distance := dmp.DiffLevenshtein(dmp.DiffMain(text, window, false))
+			longest := len(text)
+			if len(window) > longest {
+				longest = len(window)
+			}
+			score := 1 - float64(distance)/float64(longest)
+			candidates = append(candidates, candidate{i, i + size, score})
+			bestScore = max(bestScore, score)
+		}
+	}
+	if bestScore < minSimilarity {
+		return nil
+	}
+
+	var best []candidate
+	for _, c := range candidates {
+		if c.score == bestScore {
+			best = append(best, c)
+		}
+	}
+	sort.Slice(best, func(a, b int) bool {
+		if best[a].start != best[b].start {
+			return best[a].start < best[b].start
+		}
+		return best[a].end < best[b].end
+	})
+	var windows [][2]int
+	for _, c := range best {
+		if len(windows) > 0 && c.start < windows[len(windows)-1][1] {
+			continue
+		}
+		windows = append(windows, [2]int{c.start, c.end})
+	}
+	return windows
+}
+
+// reindent shifts the replacement by the indentation the file adds to or
+// removes from the search text, judged by the first line where they differ.
+// Where the file indents with different characters, e.g. tabs for spaces,
+// each level of the replacement's indentation is converted.
+func reindent(replace, search, found []string) []string {
+	for i, s := range search {
+		want, have := leading(found[i]), leading(s)
+		if strings.TrimSpace(s) == "" || want == have {
+			continue
+		}
+		out := make([]string, len(replace))
+		for j, r := range replace {
+			switch {
+			case strings.TrimSpace(r) == "":
+				out[j] = r
+			case strings.HasPrefix(want, have):
+				out[j] = want[len(have):] + r
+			case strings.HasPrefix(have, want):
+				out[j] = strings.TrimPrefix(r, have[len(want):])
+			default:
+				levels := 0
+				for strings.HasPrefix(r, have) {
+					r = r[len(have):]
+					levels++
+				}
+				out[j] = strings.Repeat(want, levels) + r
+			}
+		}
+		return out
+	}
+	return replace
+}
+
+// leading returns the indentation of line
+func leading(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// splice replaces n lines at start with the replacement lines
+func splice(lines []string, start, n int, with []string) string {
+	out := make([]string, 0, len(lines)-n+len(with))
+	out = append(out, lines[:start]...)
+	out = append(out, with...)
+	out = append(out, lines[start+n:]...)
+	return strings.Join(out, "\n")
+}
+
+// withNewline ends s with a newline when newline is set
+func withNewline(s string, newline bool) string {
+	if newline && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
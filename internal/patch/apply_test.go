@@ -0,0 +1,140 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edit    Edit
+		want    string // the content after applying, or "" when the edit fails
+		match   string
+		reason  string // part of the failure reason
+	}{
+		{
+			name:    "exact",
+			content: "a\nb\nc\n",
+			edit:    Edit{Search: "b", Replace: "B"},
+			want:    "a\nB\nc\n",
+			match:   MatchExact,
+		},
+		{
+			name:    "trailing whitespace",
+			content: "a  \nb\t\nc\n",
+			edit:    Edit{Search: "a\nb", Replace: "a\nB"},
+			want:    "a\nB\nc\n",
+			match:   MatchWhitespace,
+		},
+		{
+			name:    "indentation, reindented with tabs",
+			content: "func f() {\n\tif x {\n\t\treturn 1\n\t}\n}\n",
+			edit:    Edit{Search: "    if x {\n        return 1\n    }", Replace: "    if x {\n        return 2\n    }"},
+			want:    "func f() {\n\tif x {\n\t\treturn 2\n\t}\n}\n",
+			match:   MatchIndent,
+		},
+		{
+			name:    "fuzzy, typo in a line the edit keeps",
+			content: "func f() {\n\tx := load(1)\n\treturn x\n}\n",
+			edit:    Edit{Search: "func f() {\n\tx := lod(1)\n\treturn x\n}", Replace: "func f() {\n\tx := lod(1)\n\treturn x + 1\n}"},
+			want:    "func f() {\n\tx := load(1)\n\treturn x + 1\n}\n",
+			match:   MatchFuzzy,
+		},
+		{
+			name:    "fuzzy, extra line in the file kept",
+			content: "func total(items []Item) int {\n\tsum := 0\n\tfor _, item := range items {\n\t\t// skip nothing\n\t\tsum += item.Price\n\t}\n\treturn sum\n}\n",
+			edit:    Edit{Search: "func total(items []Item) int {\n\tsum := 0\n\tfor _, item := range items {\n\t\tsum += item.Price\n\t}\n\treturn sum\n}", Replace: "func total(items []Item) int {\n\tsum := 0\n\tfor _, item := range items {\n\t\tsum += item.Price * item.Count\n\t}\n\treturn sum\n}"},
+			want:    "func total(items []Item) int {\n\tsum := 0\n\tfor _, item := range items {\n\t\t// skip nothing\n\t\tsum += item.Price * item.Count\n\t}\n\treturn sum\n}\n",
+			match:   MatchFuzzy,
+		},
+		{
+			name:    "fuzzy, insertion between lines found",
+			content: "func f() {\n\tx := load(1)\n\treturn x\n}\n",
+			edit:    Edit{Search: "func f() {\n\tx := lod(1)\n\treturn x\n}", Replace: "func f() {\n\tx := lod(1)\n\tlog(x)\n\treturn x\n}"},
+			want:    "func f() {\n\tx := load(1)\n\tlog(x)\n\treturn x\n}\n",
+			match:   MatchFuzzy,
+		},
+		{
+			name:    "fuzzy, typo in the line to change",
+			content: "func f() {\n\tx := load(1)\n\treturn x\n}\n",
+			edit:    Edit{Search: "func f() {\n\tx := lod(1)\n\treturn x\n}", Replace: "func f() {\n\tx := load(2)\n\treturn x\n}"},
+			reason:  "the lines to change differ",
+		},
+		{
+			name:    "fuzzy, ambiguous",
+			content: "func a() {\n\tx := load(1)\n\treturn x\n}\n\nfunc b() {\n\tx := load(1)\n\treturn x\n}\n",
+			edit:    Edit{Search: "func c() {\n\tx := lod(1)\n\treturn x\n}", Replace: "func c() {\n\tx := lod(1)\n\treturn x + 1\n}"},
+			reason:  "matches 2 places (lines 1, 6)",
+		},
+		{
+			name:    "fuzzy, ambiguous resolved by the line",
+			content: "func a() {\n\tx := load(1)\n\treturn x\n}\n\nfunc b() {\n\tx := load(1)\n\treturn x\n}\n",
+			edit:    Edit{Search: "func c() {\n\tx := lod(1)\n\treturn x\n}", Replace: "func c() {\n\tx := lod(1)\n\treturn x + 1\n}", Line: 7},
+			want:    "func a() {\n\tx := load(1)\n\treturn x\n}\n\nfunc b() {\n\tx := load(1)\n\treturn x + 1\n}\n",
+			match:   MatchFuzzy,
+		},
+		{
+			name:    "not found",
+			content: "a\nb\nc\n",
+			edit:    Edit{Search: "something else entirely", Replace: "x"},
+			reason:  "not found",
+		},
+		{
+			name:    "exact, ambiguous",
+			content: "x\ny\nx\n",
+			edit:    Edit{Search: "x", Replace: "z"},
+			reason:  "matches 2 places (lines 1, 3)",
+		},
+		{
+			name:    "exact, ambiguous resolved by the line",
+			content: "x\ny\nx\n",
+			edit:    Edit{Search: "x", Replace: "z", Line: 3},
+			want:    "x\ny\nz\n",
+			match:   MatchExact,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Apply(tt.content, []Edit{tt.edit})
+			if tt.reason != "" {
+				if len(result.Failed) != 1 || !strings.Contains(result.Failed[0].Reason, tt.reason) {
+					t.Fatalf("Failed = %+v, want a failure containing %q", result.Failed, tt.reason)
+				}
+				if result.Content != tt.content {
+					t.Errorf("content changed to %q", result.Content)
+				}
+				return
+			}
+			if len(result.Failed) > 0 {
+				t.Fatalf("Failed = %+v", result.Failed)
+			}
+			if result.Content != tt.want {
+				t.Errorf("content = %q, want %q", result.Content, tt.want)
+			}
+			if got := result.Applied[0].Match; got != tt.match {
+				t.Errorf("match = %s, want %s", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestApplyReportsFailures(t *testing.T) {
+	edits := []Edit{
+		{Search: "a", Replace: "A"},
+		{Search: "missing", Replace: "M"},
+		{Search: "c", Replace: "C"},
+	}
+	result := Apply("a\nb\nc\n", edits)
+	if result.Content != "A\nb\nC\n" {
+		t.Errorf("content = %q", result.Content)
+	}
+	if len(result.Applied) != 2 || result.Applied[0].Edit != 0 || result.Applied[1].Edit != 2 {
+		t.Errorf("Applied = %+v", result.Applied)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Edit != 1 {
+		t.Errorf("Failed = %+v", result.Failed)
+	}
+}
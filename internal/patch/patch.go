@@ -0,0 +1,169 @@
+// Package patch applies edits returned by the model to a file instead of
+// replacing the whole file. Edits are search/replace blocks or unified-diff
+// hunks; each is located exactly where possible and fuzzily otherwise, and
+// edits that cannot be located are reported rather than guessed.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Instructions tell the model how to answer with edits. They are meant to
+// be appended to a prompt that shows the current file.
+const Instructions = `Do not return the whole file. Return only the changes, as one or more
+search/replace blocks:
+
+<<<<<<< SEARCH
+lines copied exactly from the current file, with enough context to be unique
+=======
+the lines that replace them
+>>>>>>> REPLACE
+
+Rules:
+- The SEARCH part must match the current file exactly, including indentation and comments
+- Keep each block small: the changed lines plus a few lines of context
+- Use several blocks for changes in different places, in file order
+- An empty REPLACE part deletes the lines
+- Do not add line numbers or explanations inside the blocks`
+
+// Edit is one change to a file: the lines to find and the lines to put in
+// their place
+type Edit struct {
+	Search  string // lines to replace, without a trailing newline
+	Replace string // replacement lines, without a trailing newline
+	Line    int    // 1-based line the edit is expected at, 0 when unknown
+}
+
+// Summary returns the first non-blank line of the search text, for reports
+func (e Edit) Summary() string {
+	for _, line := range strings.Split(e.Search, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return "(empty)"
+}
+
+// ErrNoEdits is returned by Parse when the response holds no edits
+var ErrNoEdits = errors.New("the response contains no search/replace blocks or diff hunks")
+
+var (
+	searchStart = regexp.MustCompile(`^\s*<{5,9} ?SEARCH\s*$`)
+	divider     = regexp.MustCompile(`^\s*={5,9}\s*$`)
+	replaceEnd  = regexp.MustCompile(`^\s*>{5,9} ?REPLACE\s*$`)
+	hunkHeader  = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+)
+
+// Parse reads the search/replace blocks and unified-diff hunks in a
+// response, in order
+func Parse(response string) ([]Edit, error) {
+	lines := strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n")
+
+	var edits []Edit
+	for i := 0; i < len(lines); i++ {
+		switch {
+		case searchStart.MatchString(lines[i]):
+			edit, next, err := parseBlock(lines, i+1)
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, edit)
+			i = next
+		case hunkHeader.MatchString(lines[i]):
+			edit, next := parseHunk(lines, i)
+			if edit.Search != "" || edit.Replace != "" {
+				edits = append(edits, edit)
+			}
+			i = next
+		}
+	}
+
+	if len(edits) == 0 {
+		return nil, ErrNoEdits
+	}
+	return edits, nil
+}
+
+// parseBlock reads a search/replace block starting after its SEARCH marker
+// and returns it with the index of its REPLACE marker
+func parseBlock(lines []string, start int) (Edit, int, error) {
+	var search, replace []string
+	inReplace := false
+	for i := start; i < len(lines); i++ {
+		switch {
+		case !inReplace && divider.MatchString(lines[i]):
+			inReplace = true
+		case replaceEnd.MatchString(lines[i]):
+			if !inReplace {
+				return Edit{}, 0, fmt.Errorf("search/replace block at line %d has no ======= divider", start)
+			}
+			return Edit{Search: strings.Join(search, "\n"), Replace: strings.Join(replace, "\n")}, i, nil
+		case inReplace:
+			replace = append(replace, lines[i])
+		default:
+			search = append(search, lines[i])
+		}
+	}
+	return Edit{}, 0, fmt.Errorf("search/replace block at line %d is not closed with >>>>>>> REPLACE", start)
+}
+
+// parseHunk reads a unified-diff hunk starting at its @@ header and returns
+// it with the index of its last line. The line counts in the header are
+// ignored, as models often get them wrong; the hunk ends at the first line
+// that is not context, an addition or a removal.
+func parseHunk(lines []string, start int) (Edit, int) {
+	m := hunkHeader.FindStringSubmatch(lines[start])
+	line, _ := strconv.Atoi(m[1])
+
+	var search, replace []string
+	end := start
+	for i := start + 1; i < len(lines); i++ {
+		l := lines[i]
+		if l == "" {
+			// Editors and models drop the space of empty context lines, but
+			// a blank line after the last change ends the hunk
+			if isHunkLine(lines, i+1) {
+				search, replace = append(search, ""), append(replace, "")
+				end = i
+				continue
+			}
+			break
+		}
+		if !isHunkLine(lines, i) {
+			break
+		}
+		switch l[0] {
+		case ' ':
+			search, replace = append(search, l[1:]), append(replace, l[1:])
+		case '-':
+			search = append(search, l[1:])
+		case '+':
+			replace = append(replace, l[1:])
+		}
+		end = i
+	}
+
+	return Edit{Search: strings.Join(search, "\n"), Replace: strings.Join(replace, "\n"), Line: line}, end
+}
+
+// isHunkLine reports whether lines[i] is a context, removed or added line
+// of a hunk, or a "\ No newline at end of file" marker. Lines starting with
+// "--- " or "+++ " belong to the hunk too, as removed "-- " or added "++ "
+// lines, unless they are the header of the next file's section.
+func isHunkLine(lines []string, i int) bool {
+	if i >= len(lines) || lines[i] == "" || isFileHeader(lines, i) {
+		return false
+	}
+	l := lines[i]
+	return l[0] == ' ' || l[0] == '-' || l[0] == '+' || l[0] == '\\'
+}
+
+// isFileHeader reports whether lines[i] starts the "--- a/file", "+++ b/file"
+// header of a file section
+func isFileHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
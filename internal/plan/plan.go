@@ -0,0 +1,384 @@
+// Package plan holds feature implementation plans: ordered steps with the
+// files each one creates or modifies, the tests to add, risks and an
+// optional estimate. Plans are stored as JSON, with a Markdown copy for
+// reading, under .weaver/plans so they can be tracked step by step.
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snowsoft/codeweaver/internal/extract"
+)
+
+// Dir is where plans are stored, relative to the project root
+const Dir = ".weaver/plans"
+
+// Step statuses
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusSkipped = "skipped"
+)
+
+// File actions
+const (
+	ActionCreate = "create"
+	ActionModify = "modify"
+	ActionDelete = "delete"
+)
+
+// Plan is a structured implementation plan for a feature
+type Plan struct {
+	ID        string    `json:"id"`
+	Feature   string    `json:"feature"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+	Steps     []Step    `json:"steps"`
+	Tests     Items     `json:"tests,omitempty"`
+	Risks     Items     `json:"risks,omitempty"`
+	Estimate  *Estimate `json:"estimate,omitempty"`
+}
+
+// Step is one ordered unit of work
+type Step struct {
+	Number      int          `json:"number"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	Files       []FileChange `json:"files,omitempty"`
+	Hours       float64      `json:"hours,omitempty"`
+	Status      string       `json:"status"`
+}
+
+// FileChange is a file a step creates, modifies or deletes
+type FileChange struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+// Estimate is the effort for the whole plan
+type Estimate struct {
+	Hours      float64 `json:"hours"`
+	Confidence string  `json:"confidence,omitempty"` // low, medium or high
+	Notes      string  `json:"notes,omitempty"`
+}
+
+// Items is a list of short descriptions. Models sometimes answer with
+// objects instead of strings; their string fields are joined.
+type Items []string
+
+func (items *Items) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, r := range raw {
+		var s string
+		if json.Unmarshal(r, &s) == nil {
+			*items = append(*items, s)
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(r, &fields); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var parts []string
+		for _, k := range keys {
+			if v, ok := fields[k].(string); ok && v != "" {
+				parts = append(parts, v)
+			}
+		}
+		*items = append(*items, strings.Join(parts, ": "))
+	}
+	return nil
+}
+
+// ErrNoPlan is returned by Parse when the response holds no plan
+var ErrNoPlan = errors.New("the response does not contain a plan")
+
+// Parse reads the plan the AI returned as JSON, in a fenced block or bare.
+// Steps are renumbered in order and marked pending.
+func Parse(response string) (*Plan, error) {
+	var lastErr error = ErrNoPlan
+	for _, c := range extract.Objects(response) {
+		var p Plan
+		if err := json.Unmarshal([]byte(c), &p); err != nil {
+			lastErr = fmt.Errorf("failed to parse plan: %w", err)
+			continue
+		}
+		if len(p.Steps) == 0 {
+			continue
+		}
+		p.normalize()
+		return &p, nil
+	}
+	return nil, lastErr
+}
+
+// normalize numbers the steps, fills in statuses and cleans up actions
+func (p *Plan) normalize() {
+	var steps []Step
+	for _, s := range p.Steps {
+		if strings.TrimSpace(s.Title) == "" {
+			continue
+		}
+		s.Number = len(steps) + 1
+		if s.Status == "" {
+			s.Status = StatusPending
+		}
+		for i := range s.Files {
+			s.Files[i].Path = filepath.ToSlash(strings.TrimPrefix(strings.TrimSpace(s.Files[i].Path), "./"))
+			switch action := strings.ToLower(s.Files[i].Action); action {
+			case ActionCreate, ActionDelete:
+				s.Files[i].Action = action
+			default:
+				s.Files[i].Action = ActionModify
+			}
+		}
+		steps = append(steps, s)
+	}
+	p.Steps = steps
+
+	if p.Estimate != nil && p.Estimate.Hours == 0 {
+		for _, s := range p.Steps {
+			p.Estimate.Hours += s.Hours
+		}
+	}
+}
+
+// CheckFiles corrects file actions against the project at root: a file
+// to be created that exists is modified, and one to be modified that does
+// not exist is created
+func (p *Plan) CheckFiles(root string) {
+	for i := range p.Steps {
+		for j, f := range p.Steps[i].Files {
+			info, err := os.Stat(filepath.Join(root, filepath.FromSlash(f.Path)))
+			exists := err == nil && !info.IsDir()
+			switch {
+			case f.Action == ActionCreate && exists:
+				p.Steps[i].Files[j].Action = ActionModify
+			case f.Action == ActionModify && !exists:
+				p.Steps[i].Files[j].Action = ActionCreate
+			}
+		}
+	}
+}
+
+// Files returns every file the plan touches with its first action, in step order
+func (p *Plan) Files() []FileChange {
+	var files []FileChange
+	seen := make(map[string]bool)
+	for _, s := range p.Steps {
+		for _, f := range s.Files {
+			if !seen[f.Path] {
+				seen[f.Path] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// Progress returns how many steps are finished, done or skipped
+func (p *Plan) Progress() int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Status != StatusPending {
+			n++
+		}
+	}
+	return n
+}
+
+// SetStatus changes the status of step number n
+func (p *Plan) SetStatus(n int, status string) error {
+	switch status {
+	case StatusPending, StatusDone, StatusSkipped:
+	default:
+		return fmt.Errorf("unknown status %q (use %s, %s or %s)", status, StatusPending, StatusDone, StatusSkipped)
+	}
+	if n < 1 || n > len(p.Steps) {
+		return fmt.Errorf("plan %s has no step %d", p.ID, n)
+	}
+	p.Steps[n-1].Status = status
+	return nil
+}
+
+// Markdown renders the plan for reading
+func (p *Plan) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Plan: %s\n\n", p.Feature)
+	fmt.Fprintf(&b, "_%s · %s · %d/%d steps finished_\n\n", p.ID, p.CreatedAt.Format("2006-01-02 15:04"), p.Progress(), len(p.Steps))
+	if p.Summary != "" {
+		b.WriteString(p.Summary + "\n\n")
+	}
+
+	b.WriteString("## Steps\n\n")
+	for _, s := range p.Steps {
+		box := " "
+		switch s.Status {
+		case StatusDone:
+			box = "x"
+		case StatusSkipped:
+			box = "-"
+		}
+		fmt.Fprintf(&b, "- [%s] **%d. %s**", box, s.Number, s.Title)
+		if s.Hours > 0 {
+			fmt.Fprintf(&b, " (~%gh)", s.Hours)
+		}
+		b.WriteString("\n")
+		if s.Description != "" {
+			for _, line := range strings.Split(strings.TrimSpace(s.Description), "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+		for _, f := range s.Files {
+			fmt.Fprintf(&b, "  - %s `%s`\n", f.Action, f.Path)
+		}
+	}
+
+	if files := p.Files(); len(files) > 0 {
+		b.WriteString("\n## Files\n\n| File | Action |\n|------|--------|\n")
+		for _, f := range files {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", f.Path, f.Action)
+		}
+	}
+	writeList(&b, "Tests", p.Tests)
+	writeList(&b, "Risks", p.Risks)
+
+	if p.Estimate != nil {
+		b.WriteString("\n## Estimate\n\n")
+		fmt.Fprintf(&b, "~%g hours", p.Estimate.Hours)
+		if p.Estimate.Confidence != "" {
+			fmt.Fprintf(&b, " (%s confidence)", p.Estimate.Confidence)
+		}
+		b.WriteString("\n")
+		if p.Estimate.Notes != "" {
+			b.WriteString("\n" + p.Estimate.Notes + "\n")
+		}
+	}
+	return b.String()
+}
+
+func writeList(b *strings.Builder, title string, items Items) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewID returns an unused plan ID under root made of the date and the
+// first words of the feature
+func NewID(root, feature string, now time.Time) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(feature), "-"), "-")
+	if words := strings.Split(slug, "-"); len(words) > 6 {
+		slug = strings.Join(words[:6], "-")
+	}
+	if slug == "" {
+		slug = "feature"
+	}
+
+	base := now.Format("2006-01-02") + "-" + slug
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(root, Dir, id+".json")); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// Save writes the plan as JSON and Markdown under root and returns the
+// path of the Markdown file
+func Save(root string, p *Plan) (string, error) {
+	dir := filepath.Join(root, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, p.ID+".json"), append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to save plan: %w", err)
+	}
+	md := filepath.Join(dir, p.ID+".md")
+	if err := os.WriteFile(md, []byte(p.Markdown()), 0644); err != nil {
+		return "", fmt.Errorf("failed to save plan: %w", err)
+	}
+	return md, nil
+}
+
+// Load reads the plan with the given ID, or the only plan whose ID contains it
+func Load(root, id string) (*Plan, error) {
+	file := filepath.Join(root, Dir, id+".json")
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		plans, err := List(root)
+		if err != nil {
+			return nil, err
+		}
+		var match []string
+		for _, p := range plans {
+			if strings.Contains(p.ID, id) {
+				match = append(match, p.ID)
+			}
+		}
+		switch len(match) {
+		case 0:
+			return nil, fmt.Errorf("no plan %s in %s", id, filepath.Join(root, Dir))
+		case 1:
+			file = filepath.Join(root, Dir, match[0]+".json")
+		default:
+			return nil, fmt.Errorf("%s matches several plans: %s", id, strings.Join(match, ", "))
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return &p, nil
+}
+
+// List returns the plans saved under root, newest first
+func List(root string) ([]*Plan, error) {
+	files, err := filepath.Glob(filepath.Join(root, Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var plans []*Plan
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var p Plan
+		if json.Unmarshal(data, &p) == nil && p.ID != "" {
+			plans = append(plans, &p)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].CreatedAt.After(plans[j].CreatedAt) })
+	return plans, nil
+}
@@ -0,0 +1,241 @@
+// Package stage collects generated files before any of them is written,
+// then writes them together. Files are staged in a temporary directory;
+// committing first puts every file in a temporary file next to its target
+// and only then renames them into place, restoring the tree if any step
+// fails, so that an interrupted or failed run leaves no partial result.
+package stage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/snowsoft/codeweaver/internal/journal"
+)
+
+// Stage holds files waiting to be written under a root directory
+type Stage struct {
+	root string
+	dir  string // temporary directory holding the staged contents
+
+	mu    sync.Mutex
+	files map[string]os.FileMode // staged paths, relative to root
+	order []string
+}
+
+// New creates an empty stage for files under root
+func New(root string) (*Stage, error) {
+	dir, err := os.MkdirTemp("", "weaver-stage-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return &Stage{root: root, dir: dir, files: make(map[string]os.FileMode)}, nil
+}
+
+// Add stages content for path, which must be relative and stay under the
+// root. Staging a path again replaces its content. Add is safe for
+// concurrent use.
+func (s *Stage) Add(path string, content []byte, perm os.FileMode) error {
+	path = filepath.Clean(filepath.FromSlash(path))
+	if !filepath.IsLocal(path) {
+		return fmt.Errorf("%s is outside the project directory", path)
+	}
+
+	staged := filepath.Join(s.dir, path)
+	if err := os.MkdirAll(filepath.Dir(staged), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(staged, content, 0600); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[path]; !ok {
+		s.order = append(s.order, path)
+	}
+	s.files[path] = perm
+	return nil
+}
+
+// Len returns the number of staged files
+func (s *Stage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.order)
+}
+
+// Discard removes the staged files without writing them
+func (s *Stage) Discard() error {
+	return os.RemoveAll(s.dir)
+}
+
+// RecordError is returned by Commit when every file was written but the
+// changes could not all be recorded in the journal
+type RecordError struct {
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return "the files were written, but not recorded for undo: " + e.Err.Error()
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// pending is a staged file on its way to its target
+type pending struct {
+	target  string
+	temp    string // the new content, next to the target
+	old     string // where the replaced file was moved, if there was one
+	placed  bool   // the new content is at the target
+	before  string // journal hash of the replaced content
+	content []byte
+}
+
+// Commit writes every staged file under the root and records the changes
+// in op, which may be nil. Either all files are written, or the tree is
+// left as it was and the error says why. When the files were written but
+// recording them failed, the error is a *RecordError. The stage is
+// discarded either way.
+func (s *Stage) Commit(op *journal.Operation) (err error) {
+	defer s.Discard()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var files []*pending
+	var dirs []string // directories created, parents first
+	written := false
+	defer func() {
+		if err != nil && !written {
+			rollback(files, dirs)
+		}
+	}()
+
+	// Put every file next to its target, so that nothing remains to be
+	// done but renames
+	for _, path := range s.order {
+		f := &pending{target: filepath.Join(s.root, path)}
+		files = append(files, f)
+
+		if f.content, err = os.ReadFile(filepath.Join(s.dir, path)); err != nil {
+			return err
+		}
+		created, err := mkdirAll(filepath.Dir(f.target))
+		dirs = append(dirs, created...)
+		if err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		perm := s.files[path]
+		if info, err := os.Stat(f.target); err == nil {
+			if info.IsDir() {
+				return fmt.Errorf("%s is a directory", path)
+			}
+			perm = info.Mode().Perm()
+		}
+		if f.before, err = op.Backup(f.target); err != nil {
+			return err
+		}
+		if f.temp, err = writeTemp(f.target, f.content, perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	// Move them into place, keeping the files they replace until all are in
+	for _, f := range files {
+		if _, err := os.Lstat(f.target); err == nil {
+			f.old = f.temp + ".old"
+			if err := os.Rename(f.target, f.old); err != nil {
+				f.old = ""
+				return fmt.Errorf("failed to replace %s: %w", f.target, err)
+			}
+		}
+		if err := os.Rename(f.temp, f.target); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.target, err)
+		}
+		f.placed = true
+	}
+	written = true
+
+	var errs []error
+	for _, f := range files {
+		if f.old != "" {
+			os.Remove(f.old)
+		}
+		errs = append(errs, op.Record(f.target, f.before, f.content))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return &RecordError{Err: err}
+	}
+	return nil
+}
+
+// rollback undoes a commit that failed part way: the new files are
+// removed, the replaced ones put back and the created directories removed
+func rollback(files []*pending, dirs []string) {
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		switch {
+		case f.placed && f.old == "":
+			os.Remove(f.target)
+		case f.temp != "" && !f.placed:
+			os.Remove(f.temp)
+		}
+		if f.old != "" {
+			os.Rename(f.old, f.target)
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// mkdirAll creates dir and its missing parents, returning those it
+// created, parents first
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for current := dir; ; {
+		if _, err := os.Stat(current); err == nil {
+			break
+		}
+		missing = append([]string{current}, missing...)
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	var created []string
+	for _, d := range missing {
+		if err := os.Mkdir(d, 0755); err != nil && !os.IsExist(err) {
+			return created, err
+		}
+		created = append(created, d)
+	}
+	return created, nil
+}
+
+// writeTemp writes content to a new temporary file next to target
+func writeTemp(target string, content []byte, perm os.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".weaver-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
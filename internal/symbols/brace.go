@@ -0,0 +1,393 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// braceLanguage describes a language whose blocks are delimited by braces
+type braceLanguage struct {
+	top     []rule // declarations outside any class
+	members []rule // declarations directly inside a class, trait or impl
+
+	hashComments bool // # starts a comment (PHP)
+	templates    bool // backquoted strings may span lines (JavaScript)
+	lifetimes    bool // ' may start a lifetime rather than a character (Rust)
+}
+
+// rule matches a declaration line. The name is captured by the group
+// called "name"; a group called "kind" holding the keyword decides the
+// kind, otherwise kind is used.
+type rule struct {
+	re          *regexp.Regexp
+	kind        Kind
+	skip        []string // names that are keywords, not declarations
+	constructor bool     // only matches when the name is the enclosing class's
+}
+
+// keywordKinds maps declaration keywords to kinds
+var keywordKinds = map[string]Kind{
+	"class":      KindClass,
+	"record":     KindClass,
+	"interface":  KindInterface,
+	"@interface": KindInterface,
+	"trait":      KindTrait,
+	"enum":       KindEnum,
+	"struct":     KindStruct,
+	"union":      KindStruct,
+	"type":       KindType,
+	"mod":        KindModule,
+	"namespace":  KindModule,
+	"module":     KindModule,
+	"impl":       "impl",
+}
+
+// statementKeywords look like calls in member position
+var statementKeywords = []string{"if", "for", "foreach", "while", "switch", "catch", "return", "function", "new", "else", "throw", "do", "try", "synchronized", "super", "this"}
+
+const jsModifiers = `(?:(?:export|default|declare|abstract|async)\s+)*`
+
+var jsTop = []rule{
+	{re: regexp.MustCompile(`^\s*` + jsModifiers + `function\s*\*?\s*(?P<name>[\w$]+)`), kind: KindFunction},
+	{re: regexp.MustCompile(`^\s*` + jsModifiers + `(?P<kind>class|interface|enum|namespace|module)\s+(?P<name>[\w$.]+)`)},
+	{re: regexp.MustCompile(`^\s*` + jsModifiers + `const\s+(?P<kind>enum)\s+(?P<name>[\w$]+)`)},
+	{re: regexp.MustCompile(`^\s*` + jsModifiers + `(?P<kind>type)\s+(?P<name>[\w$]+)\s*(?:<.*>)?\s*=`)},
+	{re: regexp.MustCompile(`^\s*` + jsModifiers + `(?:const|let|var)\s+(?P<name>[\w$]+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[\w$]+\s*=>)`), kind: KindFunction},
+}
+
+var jsMembers = []rule{
+	{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|readonly|abstract|override|async|get|set|declare)\s+)*\*?(?P<name>#?[\w$]+)\s*\??\s*(?:<[^>]*>)?\s*\(`), kind: KindMethod, skip: statementKeywords},
+	{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|readonly)\s+)*(?P<name>#?[\w$]+)\s*(?::[^=]+)?=\s*(?:async\s+)?\([^)]*\)\s*(?::[^=]+)?=>`), kind: KindMethod},
+}
+
+const phpModifiers = `(?:(?:abstract|final|readonly|public|private|protected|static)\s+)*`
+
+var phpTop = []rule{
+	{re: regexp.MustCompile(`^\s*function\s+&?(?P<name>\w+)`), kind: KindFunction},
+	{re: regexp.MustCompile(`^\s*` + phpModifiers + `(?P<kind>class|interface|trait|enum)\s+(?P<name>\w+)`)},
+	{re: regexp.MustCompile(`^\s*(?P<kind>namespace)\s+(?P<name>[\w\\]+)\s*\{`)},
+}
+
+var phpMembers = []rule{
+	{re: regexp.MustCompile(`^\s*` + phpModifiers + `function\s+&?(?P<name>\w+)`), kind: KindMethod},
+	{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|final)\s+)*const\s+(?:\w+\s+)?(?P<name>[A-Z_][A-Z0-9_]*)\s*=`), kind: KindConstant},
+}
+
+const javaModifiers = `(?:(?:public|private|protected|abstract|final|static|sealed|non-sealed|strictfp|default|synchronized|native|transient|volatile)\s+)*`
+
+var javaTypes = rule{re: regexp.MustCompile(`^\s*` + javaModifiers + `(?P<kind>class|interface|enum|record|@interface)\s+(?P<name>\w+)`)}
+
+var javaTop = []rule{javaTypes}
+
+var javaMembers = []rule{
+	javaTypes,
+	{re: regexp.MustCompile(`^\s*` + javaModifiers + `(?:<[^>]+>\s+)?[\w.$]+(?:<[^()]*>)?(?:\[\])*\s+(?P<name>\w+)\s*\(`), kind: KindMethod, skip: statementKeywords},
+	{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected)\s+)?(?P<name>[A-Z]\w*)\s*\(`), kind: KindMethod, constructor: true},
+}
+
+const rustModifiers = `(?:pub(?:\([^)]*\))?\s+)?(?:default\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?`
+
+var rustFn = rule{re: regexp.MustCompile(`^\s*` + rustModifiers + `fn\s+(?P<name>\w+)`), kind: KindFunction}
+
+var rustTop = []rule{
+	rustFn,
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?(?P<kind>struct|enum|union|trait|type|mod)\s+(?P<name>\w+)`)},
+	{re: regexp.MustCompile(`^\s*(?:unsafe\s+)?(?P<kind>impl)\b(?:\s*<[^{]*?>)?\s+(?:[\w:<>, &']+?\s+for\s+)?&?(?P<name>[\w:]+)`)},
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(?:mut\s+)?(?P<name>[A-Z_][A-Z0-9_]*)\s*:`), kind: KindConstant},
+}
+
+var rustMembers = []rule{
+	{re: rustFn.re, kind: KindMethod},
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?P<kind>type)\s+(?P<name>\w+)`)},
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?const\s+(?P<name>[A-Z_][A-Z0-9_]*)\s*:`), kind: KindConstant},
+}
+
+// braceLanguages are the languages extractBrace handles, by utils.DetectLanguage name
+var braceLanguages = map[string]*braceLanguage{
+	"javascript": {top: jsTop, members: jsMembers, templates: true},
+	"typescript": {top: jsTop, members: jsMembers, templates: true},
+	"php":        {top: phpTop, members: phpMembers, hashComments: true},
+	"java":       {top: javaTop, members: javaMembers},
+	"rust":       {top: rustTop, members: rustMembers, lifetimes: true},
+}
+
+// container is a class-like block whose members are being scanned
+type container struct {
+	name        string
+	depth       int // brace depth of its members
+	end         int // last line, 0-based
+	transparent bool
+}
+
+// extractBrace finds declarations by matching each line against the
+// language's rules, using brace depth to tell top-level declarations from
+// members and to find where each body ends
+func extractBrace(lang *braceLanguage, content string) []Symbol {
+	lines := strings.Split(content, "\n")
+	code := stripCode(lang, lines)
+
+	// depth[i] is the brace depth at the start of line i
+	depth := make([]int, len(lines)+1)
+	for i, line := range code {
+		depth[i+1] = depth[i] + strings.Count(line, "{") - strings.Count(line, "}")
+	}
+
+	var symbols []Symbol
+	var stack []container
+
+	for i := 0; i < len(lines); i++ {
+		for len(stack) > 0 && i > stack[len(stack)-1].end {
+			stack = stack[:len(stack)-1]
+		}
+
+		// Members are only looked for directly inside a container
+		rules, parent := lang.top, ""
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if depth[i] != top.depth {
+				continue
+			}
+			if !top.transparent {
+				rules, parent = lang.members, top.name
+			}
+		} else if depth[i] != 0 {
+			continue
+		}
+
+		name, kind, ok := matchRule(rules, code[i], parent)
+		if !ok {
+			continue
+		}
+
+		bodyLine, bodyCol, end := findBody(code, depth, i)
+		s := Symbol{
+			Name:      name,
+			Kind:      kind,
+			Signature: signature(lines, i, bodyLine, bodyCol),
+			StartLine: i + 1,
+			EndLine:   end + 1,
+		}
+		s.StartLine, s.Doc = leadingComments(lines, code, i)
+
+		switch kind {
+		case "impl":
+			// An impl block is not a symbol, but its functions are members of the type
+			stack = append(stack, container{name: trimGenerics(name), depth: depth[i] + 1, end: end})
+			continue
+		case KindModule:
+			if bodyLine >= 0 {
+				stack = append(stack, container{depth: depth[i] + 1, end: end, transparent: true})
+			}
+		case KindClass, KindInterface, KindTrait, KindEnum:
+			if bodyLine >= 0 {
+				stack = append(stack, container{name: name, depth: depth[i] + 1, end: end})
+			}
+		}
+
+		if parent != "" {
+			s.Parent = parent
+			s.Name = parent + "." + name
+			if kind == KindFunction {
+				s.Kind = KindMethod
+			}
+		}
+		symbols = append(symbols, s)
+	}
+
+	return symbols
+}
+
+// matchRule returns the name and kind of the first rule matching line
+// inside parent
+func matchRule(rules []rule, line, parent string) (string, Kind, bool) {
+	for _, r := range rules {
+		m := r.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[r.re.SubexpIndex("name")]
+		if contains(r.skip, name) || (r.constructor && name != parent) {
+			continue
+		}
+		kind := r.kind
+		if k := r.re.SubexpIndex("kind"); k >= 0 {
+			kind = keywordKinds[m[k]]
+		}
+		return name, kind, true
+	}
+	return "", "", false
+}
+
+// findBody finds the brace opening the body of the declaration on line
+// start and the line where the body closes. bodyLine is -1 for
+// declarations without a body, which end at their semicolon.
+func findBody(code []string, depth []int, start int) (bodyLine, bodyCol, end int) {
+	parens := 0
+	for i := start; i < len(code) && i < start+20; i++ {
+		for col, r := range code[i] {
+			switch r {
+			case '(', '[':
+				parens++
+			case ')', ']':
+				parens--
+			case ';':
+				if parens <= 0 {
+					return -1, 0, i
+				}
+			case '{':
+				if parens > 0 {
+					continue // a default value or type literal in the parameters
+				}
+				// The body ends where the depth drops back
+				for j := i; j < len(code); j++ {
+					if depth[j+1] <= depth[start] {
+						return i, col, j
+					}
+				}
+				return i, col, len(code) - 1
+			}
+		}
+		// Arrow functions and type aliases without braces end with the statement
+		if i > start && parens <= 0 && strings.TrimSpace(code[i]) == "" {
+			return -1, 0, i - 1
+		}
+	}
+	return -1, 0, start
+}
+
+// signature returns the declaration text from line start up to the body's brace
+func signature(lines []string, start, bodyLine, bodyCol int) string {
+	if bodyLine < 0 {
+		return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(lines[start]), "{"))
+	}
+	var parts []string
+	for i := start; i <= bodyLine; i++ {
+		line := lines[i]
+		if i == bodyLine && bodyCol <= len(line) {
+			line = line[:bodyCol]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// leadingComments returns the first line of the doc comment and
+// attributes above line i, and the comment text
+func leadingComments(lines, code []string, i int) (int, string) {
+	first := i
+	var doc []string
+	for first > 0 {
+		above := strings.TrimSpace(lines[first-1])
+		isComment := above != "" && strings.TrimSpace(code[first-1]) == ""
+		isAttribute := strings.HasPrefix(above, "@") || strings.HasPrefix(above, "#[")
+		if !isComment && !isAttribute {
+			break
+		}
+		if isComment {
+			doc = append([]string{above}, doc...)
+		}
+		first--
+	}
+	return first + 1, cleanDoc(doc)
+}
+
+// stripCode blanks out comments and the contents of string literals, so
+// braces and keywords inside them are not mistaken for code
+func stripCode(lang *braceLanguage, lines []string) []string {
+	out := make([]string, len(lines))
+	inBlock := false  // inside /* */
+	var inString rune // quote of a string spanning lines
+
+	for n, line := range lines {
+		var b strings.Builder
+		runes := []rune(line)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			next := rune(0)
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+
+			switch {
+			case inBlock:
+				if r == '*' && next == '/' {
+					inBlock = false
+					b.WriteString("  ")
+					i++
+				} else {
+					blank(&b, r)
+				}
+			case inString != 0:
+				if r == '\\' {
+					blank(&b, r)
+					if next != 0 {
+						blank(&b, next)
+						i++
+					}
+				} else if r == inString {
+					inString = 0
+					b.WriteRune(r)
+				} else {
+					blank(&b, r)
+				}
+			case r == '/' && next == '/', lang.hashComments && r == '#' && next != '[':
+				for _, rest := range runes[i:] {
+					blank(&b, rest)
+				}
+				i = len(runes)
+			case r == '/' && next == '*':
+				inBlock = true
+				b.WriteString("  ")
+				i++
+			case r == '"', r == '`' && lang.templates:
+				inString = r
+				b.WriteRune(r)
+			case r == '\'':
+				// In Rust, 'a is a lifetime unless it closes like a character
+				if lang.lifetimes && !(i+2 < len(runes) && (runes[i+2] == '\'' || next == '\\')) {
+					b.WriteRune(r)
+					continue
+				}
+				inString = r
+				b.WriteRune(r)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		// Only backquoted strings continue on the next line
+		if inString != 0 && inString != '`' {
+			inString = 0
+		}
+		out[n] = b.String()
+	}
+	return out
+}
+
+// blank writes spaces as wide as r in UTF-8, so columns in the stripped
+// code match the source
+func blank(b *strings.Builder, r rune) {
+	b.WriteString(strings.Repeat(" ", utf8.RuneLen(r)))
+}
+
+// trimGenerics removes type parameters and the module path from a type name
+func trimGenerics(name string) string {
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		name = name[i+2:]
+	}
+	if i := strings.Index(name, "<"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
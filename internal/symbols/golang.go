@@ -0,0 +1,141 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// extractGo parses Go source. Files with syntax errors still yield the
+// declarations the parser could recover.
+func extractGo(content []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil {
+		return nil, err
+	}
+
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+	source := func(from, to token.Pos) string {
+		start, end := fset.Position(from).Offset, fset.Position(to).Offset
+		if start < 0 || end > len(content) || start > end {
+			return ""
+		}
+		return string(content[start:end])
+	}
+	start := func(doc *ast.CommentGroup, pos token.Pos) int {
+		if doc != nil {
+			return line(doc.Pos())
+		}
+		return line(pos)
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			s := Symbol{
+				Name:      d.Name.Name,
+				Kind:      KindFunction,
+				Doc:       d.Doc.Text(),
+				StartLine: start(d.Doc, d.Pos()),
+				EndLine:   line(d.End()),
+			}
+			if d.Body != nil {
+				s.Signature = strings.TrimSpace(source(d.Pos(), d.Body.Lbrace))
+			} else {
+				s.Signature = strings.TrimSpace(source(d.Pos(), d.End()))
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				s.Kind = KindMethod
+				s.Parent = receiverType(d.Recv.List[0].Type)
+				s.Name = s.Parent + "." + s.Name
+			}
+			symbols = append(symbols, s)
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				// A lone spec's doc comment is on the declaration
+				var doc *ast.CommentGroup
+				if !d.Lparen.IsValid() {
+					doc = d.Doc
+				}
+
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					if sp.Doc != nil {
+						doc = sp.Doc
+					}
+					kind := KindType
+					switch sp.Type.(type) {
+					case *ast.StructType:
+						kind = KindStruct
+					case *ast.InterfaceType:
+						kind = KindInterface
+					}
+					from, to := sp.Pos(), sp.End()
+					if !d.Lparen.IsValid() {
+						from, to = d.Pos(), d.End()
+					}
+					signature := source(from, to)
+					if d.Lparen.IsValid() {
+						signature = "type " + signature
+					}
+					symbols = append(symbols, Symbol{
+						Name:      sp.Name.Name,
+						Kind:      kind,
+						Signature: collapse(signature, " { ... }"),
+						Doc:       doc.Text(),
+						StartLine: start(doc, from),
+						EndLine:   line(to),
+					})
+
+				case *ast.ValueSpec:
+					if sp.Doc != nil {
+						doc = sp.Doc
+					}
+					kind := KindVariable
+					keyword := "var "
+					if d.Tok == token.CONST {
+						kind, keyword = KindConstant, "const "
+					}
+					signature := firstLine(source(sp.Pos(), sp.End()))
+					for _, name := range sp.Names {
+						if name.Name == "_" {
+							continue
+						}
+						symbols = append(symbols, Symbol{
+							Name:      name.Name,
+							Kind:      kind,
+							Signature: keyword + signature,
+							Doc:       doc.Text(),
+							StartLine: start(doc, sp.Pos()),
+							EndLine:   line(sp.End()),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return symbols, nil
+}
+
+// receiverType returns the type name of a method receiver, without
+// pointer and type parameters
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.ParenExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
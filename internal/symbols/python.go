@@ -0,0 +1,221 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	pyDef   = regexp.MustCompile(`^(\s*)(?:async\s+)?def\s+(\w+)`)
+	pyClass = regexp.MustCompile(`^(\s*)class\s+(\w+)`)
+)
+
+// pyScope is a class or function whose body has not ended yet
+type pyScope struct {
+	indent int
+	symbol int // index into symbols, -1 for scopes that are not recorded
+	class  string
+}
+
+// extractPython finds classes, their methods and module-level functions by
+// indentation. Functions nested in functions are left out.
+func extractPython(content string) []Symbol {
+	lines := strings.Split(content, "\n")
+	var symbols []Symbol
+	var stack []pyScope
+	inString := ""
+	depth := 0    // open brackets; lines inside them continue the previous line
+	lastCode := 0 // last line with code, 1-based
+
+	closeScopes := func(indent int) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			if i := stack[len(stack)-1].symbol; i >= 0 {
+				symbols[i].EndLine = lastCode
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Lines inside a multi-line string belong to the enclosing scope
+		if inString != "" {
+			if strings.Count(line, inString)%2 == 1 {
+				inString = ""
+			}
+			lastCode = i + 1
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if depth > 0 {
+			depth = max(0, depth+bracketDelta(line))
+			lastCode = i + 1
+			continue
+		}
+
+		indent := indentation(line)
+		closeScopes(indent)
+		lastCode = i + 1
+		depth = max(0, bracketDelta(line))
+
+		for _, quote := range []string{`"""`, `'''`} {
+			if strings.Count(line, quote)%2 == 1 {
+				inString = quote
+			}
+		}
+
+		m := pyDef.FindStringSubmatch(line)
+		kind := KindFunction
+		if m == nil {
+			m = pyClass.FindStringSubmatch(line)
+			kind = KindClass
+		}
+		if m == nil {
+			continue
+		}
+
+		// Only module-level declarations and class members are recorded
+		parent, recorded := "", true
+		for _, scope := range stack {
+			if scope.class == "" {
+				recorded = false
+			}
+			parent = scope.class
+		}
+		if !recorded {
+			stack = append(stack, pyScope{indent: indent, symbol: -1})
+			continue
+		}
+
+		s := Symbol{Name: m[2], Kind: kind, StartLine: i + 1, EndLine: i + 1}
+		if parent != "" {
+			s.Parent = parent
+			s.Name = parent + "." + s.Name
+			if kind == KindFunction {
+				s.Kind = KindMethod
+			}
+		}
+
+		// Decorators and comments directly above
+		first := i
+		var comments []string
+		for first > 0 {
+			above := strings.TrimSpace(lines[first-1])
+			if strings.HasPrefix(above, "@") {
+				comments = nil
+			} else if strings.HasPrefix(above, "#") {
+				comments = append([]string{above}, comments...)
+			} else {
+				break
+			}
+			first--
+		}
+		s.StartLine = first + 1
+
+		end := pySignatureEnd(lines, i)
+		var signature []string
+		for _, l := range lines[i : end+1] {
+			signature = append(signature, strings.TrimSpace(l))
+		}
+		s.Signature = strings.Join(signature, " ")
+		s.Doc = pyDocstring(lines, end)
+		if s.Doc == "" {
+			s.Doc = cleanDoc(comments)
+		}
+
+		symbols = append(symbols, s)
+		scope := pyScope{indent: indent, symbol: len(symbols) - 1}
+		if kind == KindClass {
+			scope.class = m[2]
+		}
+		stack = append(stack, scope)
+	}
+	closeScopes(0)
+
+	return symbols
+}
+
+// bracketDelta returns how many brackets line opens, minus those it closes
+func bracketDelta(line string) int {
+	code, _, _ := strings.Cut(line, "#")
+	return strings.Count(code, "(") + strings.Count(code, "[") + strings.Count(code, "{") -
+		strings.Count(code, ")") - strings.Count(code, "]") - strings.Count(code, "}")
+}
+
+// pySignatureEnd returns the line on which the def or class starting at
+// line start ends with a colon
+func pySignatureEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines) && i < start+20; i++ {
+		code, _, _ := strings.Cut(lines[i], "#")
+		depth += bracketDelta(code)
+		if depth <= 0 && strings.HasSuffix(strings.TrimSpace(code), ":") {
+			return i
+		}
+	}
+	return start
+}
+
+// pyDocstring returns the docstring that follows the signature ending on line end
+func pyDocstring(lines []string, end int) string {
+	next := end + 1
+	for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+		next++
+	}
+	if next >= len(lines) {
+		return ""
+	}
+
+	first := strings.TrimSpace(lines[next])
+	first = strings.TrimLeft(first, "rRuUbB")
+	var quote string
+	for _, q := range []string{`"""`, `'''`, `"`, `'`} {
+		if strings.HasPrefix(first, q) {
+			quote = q
+			break
+		}
+	}
+	if quote == "" {
+		return ""
+	}
+
+	body := first[len(quote):]
+	if i := strings.Index(body, quote); i >= 0 {
+		return strings.TrimSpace(body[:i])
+	}
+	if len(quote) == 1 {
+		return ""
+	}
+
+	doc := []string{body}
+	for _, l := range lines[next+1:] {
+		if i := strings.Index(l, quote); i >= 0 {
+			doc = append(doc, l[:i])
+			break
+		}
+		doc = append(doc, l)
+	}
+	for i := range doc {
+		doc[i] = strings.TrimSpace(doc[i])
+	}
+	return strings.TrimSpace(strings.Join(doc, "\n"))
+}
+
+// indentation returns the width of the leading whitespace, counting tabs as 4
+func indentation(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
@@ -0,0 +1,153 @@
+// Package symbols extracts outlines of source files: the functions, types
+// and methods they declare, with signatures, doc comments and line ranges.
+// Go files are parsed with go/parser; Python, JavaScript, TypeScript, PHP,
+// Java and Rust use lightweight line-based parsers.
+package symbols
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snowsoft/codeweaver/internal/utils"
+)
+
+// Kind is the kind of a declared symbol
+type Kind string
+
+const (
+	KindFunction  Kind = "function"
+	KindMethod    Kind = "method"
+	KindClass     Kind = "class"
+	KindInterface Kind = "interface"
+	KindTrait     Kind = "trait"
+	KindStruct    Kind = "struct"
+	KindEnum      Kind = "enum"
+	KindType      Kind = "type"
+	KindConstant  Kind = "constant"
+	KindVariable  Kind = "variable"
+	KindModule    Kind = "module"
+)
+
+// Symbol is a declaration in a source file
+type Symbol struct {
+	Name      string // qualified with the parent for members, e.g. "Client.Generate"
+	Kind      Kind
+	Parent    string // enclosing type or class, empty for top-level symbols
+	Signature string // the declaration without its body
+	Doc       string // doc comment text without comment markers
+	StartLine int    // 1-based, including the doc comment and decorators
+	EndLine   int    // 1-based, inclusive
+}
+
+// Supported reports whether symbols can be extracted for language, as
+// returned by utils.DetectLanguage
+func Supported(language string) bool {
+	switch language {
+	case "go", "python":
+		return true
+	}
+	_, ok := braceLanguages[language]
+	return ok
+}
+
+// Extract returns the symbols declared in content, in source order. The
+// language is detected from fileName.
+func Extract(fileName string, content []byte) ([]Symbol, error) {
+	language := utils.DetectLanguage(fileName)
+	switch language {
+	case "go":
+		return extractGo(content)
+	case "python":
+		return extractPython(string(content)), nil
+	}
+	if lang, ok := braceLanguages[language]; ok {
+		return extractBrace(lang, string(content)), nil
+	}
+	return nil, fmt.Errorf("symbols: unsupported language %s", language)
+}
+
+// Find returns the symbol called name. name may be qualified ("Type.Method")
+// or, when it is unambiguous, a member's bare name.
+func Find(symbols []Symbol, name string) (Symbol, bool) {
+	var match []Symbol
+	for _, s := range symbols {
+		if s.Name == name {
+			return s, true
+		}
+		if s.Parent != "" && strings.TrimPrefix(s.Name, s.Parent+".") == name {
+			match = append(match, s)
+		}
+	}
+	if len(match) == 1 {
+		return match[0], true
+	}
+	return Symbol{}, false
+}
+
+// Outline renders symbols compactly: each signature, preceded by the first
+// sentence of its doc comment, with members indented under their parent
+func Outline(language string, symbols []Symbol) string {
+	marker := "//"
+	if language == "python" {
+		marker = "#"
+	}
+
+	var b strings.Builder
+	for _, s := range symbols {
+		indent := ""
+		if s.Parent != "" && language != "go" {
+			indent = "    "
+		}
+		if doc := summary(s.Doc); doc != "" {
+			fmt.Fprintf(&b, "%s%s %s\n", indent, marker, doc)
+		}
+		for _, line := range strings.Split(s.Signature, "\n") {
+			fmt.Fprintf(&b, "%s%s\n", indent, line)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// summary returns the first sentence of a doc comment on one line
+func summary(doc string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(doc), "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if i := strings.Index(paragraph, ". "); i >= 0 {
+		paragraph = paragraph[:i+1]
+	}
+	return paragraph
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+// maxSignatureLines is the longest declaration kept whole in a signature,
+// so short structs and interfaces show their fields and methods
+const maxSignatureLines = 12
+
+// collapse shortens a multi-line declaration to its first line when it is
+// longer than maxSignatureLines
+func collapse(signature, elided string) string {
+	signature = strings.TrimRight(signature, " \t\n")
+	lines := strings.Split(signature, "\n")
+	if len(lines) <= maxSignatureLines {
+		return signature
+	}
+	return strings.TrimRight(lines[0], " \t{") + elided
+}
+
+// cleanDoc strips comment markers from the lines of a doc comment
+func cleanDoc(lines []string) string {
+	var out []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		for _, marker := range []string{"///", "//!", "//", "/**", "/*", "*/", "#", "*"} {
+			line = strings.TrimPrefix(line, marker)
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "*/"))
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
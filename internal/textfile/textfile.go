@@ -0,0 +1,221 @@
+// Package textfile reads and writes text files while keeping the way they
+// are stored: permissions, encoding, byte order mark, line endings and
+// final newline. Content is handed to the rest of weaver as UTF-8 with LF
+// line endings and put back in the file's own style when written.
+package textfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings weaver reads and writes. Other encodings are refused rather
+// than guessed, as transcoding them wrongly would corrupt the file.
+const (
+	UTF8    = "UTF-8"
+	UTF16LE = "UTF-16LE"
+	UTF16BE = "UTF-16BE"
+)
+
+// DefaultMode is the mode of new files
+const DefaultMode os.FileMode = 0644
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// ErrBinary is returned for files that do not hold text
+var ErrBinary = errors.New("the file is binary")
+
+// Style is how a text file is stored
+type Style struct {
+	Mode         os.FileMode
+	Encoding     string
+	BOM          bool
+	CRLF         bool
+	FinalNewline bool
+}
+
+// Default returns the style of new files: UTF-8 without a BOM, LF line
+// endings and a final newline
+func Default(mode os.FileMode) Style {
+	if mode == 0 {
+		mode = DefaultMode
+	}
+	return Style{Mode: mode, Encoding: UTF8, FinalNewline: true}
+}
+
+// Read reads the text file at path, returning its content as UTF-8 with LF
+// line endings, and its style
+func Read(path string) (string, Style, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", Style{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", Style{}, err
+	}
+	text, style, err := Decode(data)
+	if err != nil {
+		return "", Style{}, err
+	}
+	style.Mode = info.Mode().Perm()
+	return text, style, nil
+}
+
+// StyleOf returns the style of the file at path, or the default style with
+// mode when it does not exist
+func StyleOf(path string, mode os.FileMode) (Style, error) {
+	_, style, err := Read(path)
+	if os.IsNotExist(err) {
+		return Default(mode), nil
+	}
+	return style, err
+}
+
+// Decode detects the style of data and returns it as UTF-8 text with LF
+// line endings. UTF-16 is transcoded; data that is neither UTF-8 nor
+// UTF-16 is refused.
+func Decode(data []byte) (string, Style, error) {
+	style := Style{Encoding: UTF8}
+
+	var text string
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		style.BOM = true
+		data = data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		style.Encoding, style.BOM = UTF16LE, true
+	case bytes.HasPrefix(data, bomUTF16BE):
+		style.Encoding, style.BOM = UTF16BE, true
+	case bytes.IndexByte(data, 0) >= 0:
+		style.Encoding = guessUTF16(data)
+		if style.Encoding == "" {
+			return "", style, ErrBinary
+		}
+	}
+
+	if style.Encoding == UTF8 {
+		if err := checkUTF8(data); err != nil {
+			return "", style, err
+		}
+		text = string(data)
+	} else {
+		if style.BOM {
+			data = data[2:]
+		}
+		var err error
+		if text, err = decodeUTF16(data, style.Encoding); err != nil {
+			return "", style, err
+		}
+	}
+
+	crlf := strings.Count(text, "\r\n")
+	style.CRLF = crlf > 0 && crlf >= strings.Count(text, "\n")-crlf
+	style.FinalNewline = text == "" || strings.HasSuffix(text, "\n")
+	return strings.ReplaceAll(text, "\r\n", "\n"), style, nil
+}
+
+// Encode returns text in the given style. Line endings in text may be LF
+// or CRLF; the final newline is added or removed to match the style.
+func Encode(text string, style Style) []byte {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if style.FinalNewline {
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+	} else {
+		text = strings.TrimRight(text, "\n")
+	}
+	if style.CRLF {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+
+	switch style.Encoding {
+	case UTF16LE, UTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		bom := bomUTF16LE
+		if style.Encoding == UTF16BE {
+			order, bom = binary.BigEndian, bomUTF16BE
+		}
+		units := utf16.Encode([]rune(text))
+		out := make([]byte, 0, 2*len(units)+2)
+		if style.BOM {
+			out = append(out, bom...)
+		}
+		for _, u := range units {
+			out = order.AppendUint16(out, u)
+		}
+		return out
+	default:
+		if style.BOM {
+			return append(append([]byte{}, bomUTF8...), text...)
+		}
+		return []byte(text)
+	}
+}
+
+// checkUTF8 reports the first invalid byte of data, if any
+func checkUTF8(data []byte) error {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return fmt.Errorf("not UTF-8 text (invalid byte 0x%02x at offset %d); convert it to UTF-8 first", data[i], i)
+		}
+		i += size
+	}
+	return nil
+}
+
+// guessUTF16 recognises UTF-16 without a byte order mark from the zero
+// bytes of mostly ASCII text, which fall on every other byte. It returns ""
+// when data does not look like UTF-16.
+func guessUTF16(data []byte) string {
+	if len(data)%2 != 0 {
+		return ""
+	}
+	var even, odd int
+	for i, b := range data {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	pairs := len(data) / 2
+	switch {
+	case even == 0 && odd*2 >= pairs:
+		return UTF16LE
+	case odd == 0 && even*2 >= pairs:
+		return UTF16BE
+	}
+	return ""
+}
+
+// decodeUTF16 transcodes UTF-16 data to UTF-8
+func decodeUTF16(data []byte, encoding string) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("not %s text: odd number of bytes", encoding)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if encoding == UTF16BE {
+		order = binary.BigEndian
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units)), nil
+}
@@ -19,6 +19,7 @@ const (
     ActionAccept Action = iota
     ActionDecline
     ActionEdit
+    ActionReview
 )
 
 // ConfirmAction asks for a yes/no confirmation
@@ -41,6 +42,7 @@ func AskForAction() Action {
         Message: "What would you like to do?",
         Options: []string{
             "Accept changes",
+            "Review each change",
             "Decline changes",
             "Edit manually",
         },
@@ -54,6 +56,8 @@ func AskForAction() Action {
     switch action {
     case "Accept changes":
         return ActionAccept
+    case "Review each change":
+        return ActionReview
     case "Edit manually":
         return ActionEdit
     default:
@@ -76,8 +80,19 @@ func AskForInput(message string) string {
 
 // OpenEditor opens the system editor for manual editing
 func OpenEditor(content string) (string, error) {
+    return OpenEditorFor("", content)
+}
+
+// OpenEditorFor opens content in the system editor in a temporary file
+// named like fileName, so the editor can highlight it, and returns the
+// edited content
+func OpenEditorFor(fileName, content string) (string, error) {
     // Create temporary file
-    tmpFile, err := os.CreateTemp("", "weaver-edit-*.txt")
+    ext := filepath.Ext(fileName)
+    if ext == "" {
+        ext = ".txt"
+    }
+    tmpFile, err := os.CreateTemp("", "weaver-edit-*"+ext)
     if err != nil {
         return "", fmt.Errorf("failed to create temp file: %w", err)
     }
@@ -90,30 +105,15 @@ func OpenEditor(content string) (string, error) {
     }
     tmpFile.Close()
     
-    // Determine editor
-    editor := os.Getenv("EDITOR")
-    if editor == "" {
-        editor = "nano" // fallback
-        if _, err := exec.LookPath("code"); err == nil {
-            editor = "code"
-        } else if _, err := exec.LookPath("vim"); err == nil {
-            editor = "vim"
-        }
-    }
-    
     // Open editor
-    var cmd *exec.Cmd
-    if filepath.Base(editor) == "code" {
-        cmd = exec.Command(editor, "--wait", tmpFile.Name())
-    } else {
-        cmd = exec.Command(editor, tmpFile.Name())
-    }
+    args := append(editorCommand(), tmpFile.Name())
+    cmd := exec.Command(args[0], args[1:]...)
     cmd.Stdin = os.Stdin
     cmd.Stdout = os.Stdout
     cmd.Stderr = os.Stderr
     
     if err := cmd.Run(); err != nil {
-        return "", fmt.Errorf("failed to run editor: %w", err)
+        return "", fmt.Errorf("failed to run editor %s: %w", args[0], err)
     }
     
     // Read edited content
@@ -125,6 +125,48 @@ func OpenEditor(content string) (string, error) {
     return string(editedContent), nil
 }
 
+// waitFlags are the flags GUI editors need to block until the file is closed
+var waitFlags = map[string]string{
+    "code":          "--wait",
+    "code-insiders": "--wait",
+    "codium":        "--wait",
+    "subl":          "--wait",
+    "zed":           "--wait",
+}
+
+// editorCommand returns the editor to run from $VISUAL or $EDITOR, falling
+// back to code, vim or nano, with a wait flag added when the editor needs one
+func editorCommand() []string {
+    editor := strings.TrimSpace(os.Getenv("VISUAL"))
+    if editor == "" {
+        editor = strings.TrimSpace(os.Getenv("EDITOR"))
+    }
+    if editor == "" {
+        editor = "nano" // fallback
+        if _, err := exec.LookPath("code"); err == nil {
+            editor = "code"
+        } else if _, err := exec.LookPath("vim"); err == nil {
+            editor = "vim"
+        }
+    }
+    
+    // $EDITOR may hold arguments, e.g. "subl -n"
+    args := strings.Fields(editor)
+    name := strings.TrimSuffix(strings.ToLower(filepath.Base(args[0])), ".exe")
+    if flag, ok := waitFlags[name]; ok {
+        hasFlag := false
+        for _, arg := range args[1:] {
+            if arg == flag || arg == "-w" {
+                hasFlag = true
+            }
+        }
+        if !hasFlag {
+            args = append(args, flag)
+        }
+    }
+    return args
+}
+
 // ShowProgress displays a progress bar
 func ShowProgress(title string, total int) *pterm.ProgressbarPrinter {
     bar, err := pterm.DefaultProgressbar.
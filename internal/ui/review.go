@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pterm/pterm"
+	"github.com/snowsoft/codeweaver/internal/diff"
+)
+
+// ReviewResult is the outcome of reviewing changes hunk by hunk
+type ReviewResult struct {
+	Content  string // the original with the accepted hunks applied
+	Hunks    int    // hunks reviewed, counting the pieces of split hunks
+	Accepted int
+	Edited   int // accepted hunks that were edited first
+	Rejected int
+}
+
+// Changed reports whether any hunk was accepted
+func (r *ReviewResult) Changed() bool {
+	return r.Accepted > 0
+}
+
+// Decisions on a hunk
+const (
+	undecided = iota
+	accepted
+	rejected
+)
+
+const hunkHelp = `y - apply this hunk
+n - do not apply this hunk
+e - edit this hunk in your editor, then apply it
+s - split this hunk into smaller hunks
+a - apply this hunk and all the remaining ones
+d - do not apply this hunk or any of the remaining ones
+q - quit; do not apply the remaining hunks
+k - go back to the previous hunk
+? - show this help`
+
+const hunkEditHeader = `# Edit the hunk below, then save and close the editor.
+# To keep a line marked '-', make it start with ' ' instead.
+# To leave out a line marked '+', delete it.
+# Lines starting with # are ignored.
+`
+
+// ReviewHunks shows the changes from original to proposed one hunk at a
+// time and asks which to apply, like git add -p. Hunks that were not
+// accepted keep the original lines.
+func ReviewHunks(fileName, original, proposed string) *ReviewResult {
+	hunks := diff.Hunks(original, proposed, diff.DefaultContext)
+	decisions := make([]int, len(hunks))
+	edited := make([]bool, len(hunks))
+
+	decideRest := func(from, decision int) {
+		for j := from; j < len(hunks); j++ {
+			if decisions[j] == undecided {
+				decisions[j] = decision
+			}
+		}
+	}
+
+	pterm.DefaultSection.Printf("Reviewing %s: %d hunks\n", fileName, len(hunks))
+	for i := 0; i < len(hunks); {
+		printHunk(hunks[i])
+
+		options := "y,n,e,"
+		if hunks[i].CanSplit() {
+			options += "s,"
+		}
+		options += "a,d,q,k,?"
+		var answer string
+		err := survey.AskOne(&survey.Input{
+			Message: fmt.Sprintf("(%d/%d) Apply this hunk [%s]", i+1, len(hunks), options),
+		}, &answer)
+		if err != nil {
+			answer = "q"
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y":
+			decisions[i] = accepted
+			i++
+		case "n":
+			decisions[i] = rejected
+			i++
+		case "a":
+			decisions[i] = accepted
+			decideRest(i+1, accepted)
+			i = len(hunks)
+		case "d":
+			decisions[i] = rejected
+			decideRest(i+1, rejected)
+			i = len(hunks)
+		case "q":
+			decideRest(i, rejected)
+			i = len(hunks)
+		case "k":
+			if i == 0 {
+				pterm.Warning.Println("This is the first hunk")
+				continue
+			}
+			i--
+		case "s":
+			pieces := hunks[i].Split()
+			if len(pieces) == 1 {
+				pterm.Warning.Println("This hunk cannot be split")
+				continue
+			}
+			pterm.Info.Printf("Split into %d hunks\n", len(pieces))
+			hunks = append(hunks[:i], append(pieces, hunks[i+1:]...)...)
+			decisions = append(decisions[:i], append(make([]int, len(pieces)), decisions[i+1:]...)...)
+			edited = append(edited[:i], append(make([]bool, len(pieces)), edited[i+1:]...)...)
+		case "e":
+			text, err := OpenEditor(hunkEditHeader + hunks[i].String())
+			if err != nil {
+				pterm.Warning.Printf("Could not edit the hunk: %v\n", err)
+				continue
+			}
+			hunk, err := hunks[i].ParseEdit(text)
+			if err != nil {
+				pterm.Warning.Printf("The edited hunk was not used: %v\n", err)
+				continue
+			}
+			edited[i] = hunk.String() != hunks[i].String()
+			hunks[i] = hunk
+			decisions[i] = accepted
+			i++
+		default:
+			fmt.Println(hunkHelp)
+		}
+	}
+
+	result := &ReviewResult{Hunks: len(hunks)}
+	var apply []diff.Hunk
+	for i, h := range hunks {
+		if decisions[i] == accepted {
+			apply = append(apply, h)
+			result.Accepted++
+			if edited[i] {
+				result.Edited++
+			}
+		} else {
+			result.Rejected++
+		}
+	}
+	result.Content = diff.Apply(original, apply)
+
+	summary := fmt.Sprintf("%s: %d of %d hunks accepted", fileName, result.Accepted, result.Hunks)
+	if result.Edited > 0 {
+		summary += fmt.Sprintf(" (%d edited)", result.Edited)
+	}
+	summary += fmt.Sprintf(", %d rejected", result.Rejected)
+	pterm.Info.Println(summary)
+	return result
+}
+
+// printHunk shows a hunk with the removed and added lines in the colors
+// from the config
+func printHunk(h diff.Hunk) {
+	fmt.Println()
+	fmt.Print(diff.NewViewer().RenderHunk(h))
+}
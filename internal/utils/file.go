@@ -7,6 +7,9 @@ import (
     "os"
     "path/filepath"
     "strings"
+
+    "github.com/snowsoft/codeweaver/internal/textfile"
+    "github.com/snowsoft/codeweaver/internal/walker"
 )
 
 // ReadFile reads the contents of a file
@@ -18,9 +21,14 @@ func ReadFile(path string) (string, error) {
     return string(content), nil
 }
 
-// WriteFile writes content to a file with secure permissions
+// WriteFile writes content to a file, keeping the mode, encoding, line
+// endings and final newline of the file it replaces. New files get mode 0644.
 func WriteFile(path string, content string) error {
-    return os.WriteFile(path, []byte(content), 0600)
+    style, err := textfile.StyleOf(path, textfile.DefaultMode)
+    if err != nil {
+        return fmt.Errorf("refusing to overwrite %s: %w", path, err)
+    }
+    return os.WriteFile(path, textfile.Encode(content, style), style.Mode)
 }
 
 // FileExists checks if a file exists
@@ -93,61 +101,20 @@ func DetectLanguage(fileName string) string {
     return "unknown"
 }
 
-// GetDirectoryTree returns a tree representation of a directory
+// GetDirectoryTree returns a tree representation of a directory. Files
+// ignored by .gitignore or .weaverignore, binaries and generated files are left out.
 func GetDirectoryTree(root string, maxDepth int) (string, error) {
     var tree strings.Builder
+    tree.WriteString(filepath.Base(root) + "/\n")
     
-    err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-        if err != nil {
-            return err
-        }
-        
-        // Calculate depth
-        relPath, err := filepath.Rel(root, path)
-        if err != nil {
-            return err
-        }
-        depth := strings.Count(relPath, string(filepath.Separator))
-        
-        // Skip if too deep
-        if depth > maxDepth {
-            if info.IsDir() {
-                return filepath.SkipDir
-            }
-            return nil
-        }
-        
-        // Skip hidden files and directories
-        if strings.HasPrefix(filepath.Base(path), ".") && path != root {
-            if info.IsDir() {
-                return filepath.SkipDir
-            }
-            return nil
-        }
-        
-        // Skip common ignore directories
-        ignoreDirs := []string{"node_modules", "__pycache__", "vendor", ".git", "dist", "build"}
-        for _, ignore := range ignoreDirs {
-            if strings.Contains(path, ignore) {
-                if info.IsDir() {
-                    return filepath.SkipDir
-                }
-                return nil
-            }
-        }
-        
+    err := walker.Walk(root, walker.Options{MaxDepth: maxDepth + 1}, func(entry walker.Entry) error {
         // Build tree line
-        indent := strings.Repeat("  ", depth)
-        prefix := "├── "
-        if depth == 0 {
-            prefix = ""
-        }
-        
-        if info.IsDir() {
-            tree.WriteString(fmt.Sprintf("%s%s%s/\n", indent, prefix, filepath.Base(path)))
-        } else {
-            tree.WriteString(fmt.Sprintf("%s%s%s\n", indent, prefix, filepath.Base(path)))
+        indent := strings.Repeat("  ", entry.Depth-1)
+        name := entry.Info.Name()
+        if entry.Info.IsDir() {
+            name += "/"
         }
+        tree.WriteString(fmt.Sprintf("%s├── %s\n", indent, name))
         
         return nil
     })
@@ -0,0 +1,168 @@
+// Package validate checks generated code for syntax errors with each
+// language's own parser or compiler
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxErrors is how many error lines are kept from a checker's output
+const maxErrors = 10
+
+// timeout bounds a single external checker run
+const timeout = 30 * time.Second
+
+// Result is the outcome of checking a file
+type Result struct {
+	Checker string   // what checked the file, e.g. "go/parser" or "node --check"
+	Errors  []string // lines of the checker's error output, empty when the file is valid
+	Skipped string   // why the file was not checked, if it was not
+}
+
+// Failed reports whether the check found errors
+func (r *Result) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+// checker runs an external tool on a file
+type checker struct {
+	name  string   // shown in the results
+	tools []string // commands to try, in order
+	args  []string // arguments before the file
+	// keep selects the output lines that report syntax errors; nil keeps all
+	keep func(line string) bool
+}
+
+// checkers holds the external checkers by file extension
+var checkers = map[string]checker{
+	".py":  {name: "python -m py_compile", tools: []string{"python3", "python"}, args: []string{"-m", "py_compile"}},
+	".js":  node,
+	".mjs": node,
+	".cjs": node,
+	".php": {name: "php -l", tools: []string{"php"}, args: []string{"-l"}},
+	".ts":  typescript,
+	".tsx": typescript,
+}
+
+// node leaves out the stack trace of its own code that follows the error
+var node = checker{
+	name:  "node --check",
+	tools: []string{"node"},
+	args:  []string{"--check"},
+	keep: func(line string) bool {
+		return !strings.HasPrefix(strings.TrimSpace(line), "at ") && !strings.HasPrefix(line, "Node.js ")
+	},
+}
+
+// typescript checks a file on its own, so only syntax errors (TS1xxx) are
+// kept; errors about imports and types depend on the rest of the project
+var typescript = checker{
+	name:  "tsc --noEmit",
+	tools: []string{"tsc", filepath.Join("node_modules", ".bin", "tsc")},
+	args:  []string{"--noEmit", "--skipLibCheck", "--jsx", "preserve"},
+	keep: func(line string) bool {
+		return strings.Contains(line, "error TS1")
+	},
+}
+
+// Check checks content as the source of fileName. Languages without a
+// checker, or whose tool is not installed, are skipped.
+func Check(ctx context.Context, fileName, content string) *Result {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if ext == ".go" {
+		return checkGo(fileName, content)
+	}
+
+	c, ok := checkers[ext]
+	if !ok {
+		return &Result{Skipped: fmt.Sprintf("no syntax check for %s files", ext)}
+	}
+	tool := ""
+	for _, t := range c.tools {
+		if path, err := exec.LookPath(t); err == nil {
+			tool = path
+			break
+		}
+	}
+	if tool == "" {
+		return &Result{Skipped: fmt.Sprintf("%s is not installed", c.tools[0])}
+	}
+	return c.run(ctx, tool, fileName, content)
+}
+
+// checkGo parses Go code in-process
+func checkGo(fileName, content string) *Result {
+	result := &Result{Checker: "go/parser"}
+	_, err := parser.ParseFile(token.NewFileSet(), fileName, content, parser.AllErrors)
+
+	var list scanner.ErrorList
+	switch {
+	case errors.As(err, &list):
+		for _, e := range list {
+			result.add(e.Error())
+		}
+	case err != nil:
+		result.add(err.Error())
+	}
+	return result
+}
+
+// run writes content to a temporary file named like fileName and runs the
+// checker's tool on it
+func (c checker) run(ctx context.Context, tool, fileName, content string) *Result {
+	result := &Result{Checker: c.name}
+
+	dir, err := os.MkdirTemp("", "weaver-check-*")
+	if err != nil {
+		result.Skipped = fmt.Sprintf("could not create a temporary file: %v", err)
+		return result
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, filepath.Base(fileName))
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		result.Skipped = fmt.Sprintf("could not create a temporary file: %v", err)
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, tool, append(c.args, path)...).CombinedOutput()
+	if err == nil {
+		return result
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || ctx.Err() != nil {
+		result.Skipped = fmt.Sprintf("%s did not run: %v", result.Checker, err)
+		return result
+	}
+
+	// Report the errors against the real file name
+	text := strings.ReplaceAll(string(output), path, fileName)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || (c.keep != nil && !c.keep(line)) {
+			continue
+		}
+		result.add(line)
+	}
+	return result
+}
+
+// add records a line of error output, leaving out repeats and lines
+// beyond maxErrors
+func (r *Result) add(err string) {
+	if len(r.Errors) == maxErrors || (len(r.Errors) > 0 && r.Errors[len(r.Errors)-1] == err) {
+		return
+	}
+	r.Errors = append(r.Errors, err)
+}
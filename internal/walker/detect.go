@@ -0,0 +1,79 @@
+package walker
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"regexp"
+)
+
+// sniffSize is how much of a file is read to classify it, the same amount git uses
+const sniffSize = 8000
+
+// generatedNames are file names of lock files and build output
+var generatedNames = []string{
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+	"*.pb.go",
+	"*_pb2.py",
+	"*.pb.cc",
+	"*.pb.h",
+	"*_generated.*",
+	"*.generated.*",
+	"*.g.dart",
+	"*.designer.cs",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"composer.lock",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"poetry.lock",
+	"go.sum",
+}
+
+// generatedMarker matches the header comments code generators write
+var generatedMarker = regexp.MustCompile(`(?i)(code generated .* do not edit|@generated|<auto-generated|this file (is|was) (automatically|auto-) ?generated)`)
+
+// IsBinary reports whether content looks like a binary file: like git, it
+// checks for a NUL byte in the first few kilobytes
+func IsBinary(content []byte) bool {
+	if len(content) > sniffSize {
+		content = content[:sniffSize]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// IsGenerated reports whether the file name belongs to a lock file or
+// generated code, or its leading content carries a "do not edit" marker
+func IsGenerated(name string, content []byte) bool {
+	base := path.Base(name)
+	for _, pattern := range generatedNames {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+
+	if len(content) > sniffSize {
+		content = content[:sniffSize]
+	}
+	return generatedMarker.Match(content)
+}
+
+// sniff reads the start of a file for IsBinary and IsGenerated
+func sniff(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
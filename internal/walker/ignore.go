@@ -0,0 +1,356 @@
+package walker
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Ignore files read in every directory, in increasing priority
+var ignoreFileNames = []string{".gitignore", ".weaverignore"}
+
+// DefaultPatterns are applied before any ignore file, so a .gitignore or
+// .weaverignore can re-include what they exclude (e.g. "!build/")
+var DefaultPatterns = []string{
+	".*",
+	"node_modules/",
+	"vendor/",
+	"dist/",
+	"build/",
+	"__pycache__/",
+}
+
+// pattern is a single gitignore line
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreFile holds the patterns of one ignore file
+type ignoreFile struct {
+	dir      string // slash directory the patterns are relative to, "" for the base
+	patterns []pattern
+}
+
+// match reports whether the file's patterns decide about rel, and if so
+// whether rel is ignored. The last matching pattern wins.
+func (f *ignoreFile) match(rel string, isDir bool) (matched, ignored bool) {
+	if f.dir != "" {
+		if !strings.HasPrefix(rel, f.dir+"/") {
+			return false, false
+		}
+		rel = rel[len(f.dir)+1:]
+	}
+
+	for i := len(f.patterns) - 1; i >= 0; i-- {
+		p := f.patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			return true, !p.negate
+		}
+	}
+	return false, false
+}
+
+// Matcher decides which paths below a root are ignored. It applies
+// DefaultPatterns, the global git excludes file, .git/info/exclude and the
+// .gitignore and .weaverignore files from the repository root down.
+type Matcher struct {
+	root   string          // absolute root paths are relative to
+	prefix string          // root relative to the repository root, "" when they are the same
+	files  []*ignoreFile   // in increasing priority
+	loaded map[string]bool // root-relative directories whose ignore files are loaded
+}
+
+// NewMatcher creates a Matcher for the tree at root. extra patterns are
+// applied after DefaultPatterns and before any ignore file.
+func NewMatcher(root string, extra ...string) (*Matcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Matcher{root: absRoot, loaded: make(map[string]bool)}
+	m.files = append(m.files, &ignoreFile{patterns: parsePatterns(DefaultPatterns)})
+	if len(extra) > 0 {
+		m.files = append(m.files, &ignoreFile{patterns: parsePatterns(extra)})
+	}
+	m.addFile("", globalExcludesFile())
+
+	// Patterns are relative to the repository root, which may be above root
+	base := absRoot
+	if repo, ok := findRepository(absRoot); ok {
+		base = repo
+		m.addFile("", filepath.Join(repo, ".git", "info", "exclude"))
+	}
+	if prefix, err := filepath.Rel(base, absRoot); err == nil && prefix != "." {
+		m.prefix = filepath.ToSlash(prefix)
+	}
+
+	// Ignore files between the repository root and root
+	dir := ""
+	for _, part := range strings.Split(m.prefix, "/") {
+		if part == "" {
+			break
+		}
+		for _, name := range ignoreFileNames {
+			m.addFile(dir, filepath.Join(base, filepath.FromSlash(dir), name))
+		}
+		dir = path.Join(dir, part)
+	}
+
+	return m, nil
+}
+
+// Ignored reports whether the root-relative slash path rel, or one of its
+// parent directories, is ignored
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	rel = strings.Trim(path.Clean(filepath.ToSlash(rel)), "/")
+	if rel == "." || rel == "" {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	dir := ""
+	for i, part := range parts {
+		m.load(dir)
+		current := path.Join(dir, part)
+		last := i == len(parts)-1
+		if part == ".git" || m.match(current, isDir || !last) {
+			return true
+		}
+		dir = current
+	}
+	return false
+}
+
+// match checks rel itself, assuming its parent directories are not ignored
+// and their ignore files are loaded
+func (m *Matcher) match(rel string, isDir bool) bool {
+	if m.prefix != "" {
+		rel = m.prefix + "/" + rel
+	}
+
+	ignored := false
+	for _, f := range m.files {
+		if matched, ign := f.match(rel, isDir); matched {
+			ignored = ign
+		}
+	}
+	return ignored
+}
+
+// load reads the ignore files of the root-relative directory dir
+func (m *Matcher) load(dir string) {
+	if m.loaded[dir] {
+		return
+	}
+	m.loaded[dir] = true
+
+	baseDir := dir
+	if m.prefix != "" {
+		baseDir = path.Join(m.prefix, dir)
+	}
+	for _, name := range ignoreFileNames {
+		m.addFile(baseDir, filepath.Join(m.root, filepath.FromSlash(dir), name))
+	}
+}
+
+// addFile appends the patterns of the ignore file at file, if it exists
+func (m *Matcher) addFile(dir, file string) {
+	if file == "" {
+		return
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	if patterns := parsePatterns(strings.Split(string(data), "\n")); len(patterns) > 0 {
+		m.files = append(m.files, &ignoreFile{dir: dir, patterns: patterns})
+	}
+}
+
+// parsePatterns compiles gitignore lines, skipping blanks and comments
+func parsePatterns(lines []string) []pattern {
+	var patterns []pattern
+	for _, line := range lines {
+		if p, ok := compilePattern(line); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// compilePattern turns a gitignore line into a regular expression
+func compilePattern(line string) (pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	var p pattern
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	// Patterns with a slash are relative to the ignore file's directory,
+	// others match a name at any depth
+	anchored := strings.Contains(line, "/")
+	expr := globToRegexp(strings.TrimPrefix(line, "/"))
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// globToRegexp translates gitignore glob syntax, including "**"
+func globToRegexp(glob string) string {
+	runes := []rune(glob)
+	var b strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				atStart := i == 0 || runes[i-1] == '/'
+				switch {
+				case atStart && i+2 == len(runes):
+					// "dir/**" matches everything inside dir
+					b.WriteString(".*")
+					i++
+				case atStart && runes[i+2] == '/':
+					// "**/" matches zero or more directories
+					b.WriteString("(?:.*/)?")
+					i += 2
+				default:
+					b.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return b.String()
+}
+
+// findRepository returns the git repository containing dir
+func findRepository(dir string) (string, bool) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// globalExcludesFile returns git's core.excludesFile, falling back to
+// $XDG_CONFIG_HOME/git/ignore
+func globalExcludesFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	// ~/.gitconfig takes precedence over the XDG config
+	for _, config := range []string{filepath.Join(home, ".gitconfig"), filepath.Join(configHome, "git", "config")} {
+		if file := readExcludesFile(config); file != "" {
+			if strings.HasPrefix(file, "~/") {
+				file = filepath.Join(home, file[2:])
+			}
+			return file
+		}
+	}
+	return filepath.Join(configHome, "git", "ignore")
+}
+
+// readExcludesFile reads core.excludesFile from a git config file
+func readExcludesFile(config string) string {
+	f, err := os.Open(config)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inCore := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inCore = strings.EqualFold(strings.Trim(line, "[] \t"), "core")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if inCore && ok && strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+// Package walker enumerates project files the way git sees them: it honors
+// nested .gitignore files, negation, the global excludes file and
+// .weaverignore, and skips binary and generated files
+package walker
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Options control what Walk visits
+type Options struct {
+	MaxDepth         int      // deepest level visited, counting the root's entries as 1; 0 for no limit
+	Patterns         []string // extra gitignore patterns, applied after DefaultPatterns
+	IncludeBinary    bool
+	IncludeGenerated bool
+}
+
+// Entry is a file or directory found by Walk
+type Entry struct {
+	Path  string // root joined with Rel
+	Rel   string // slash path relative to the root
+	Depth int    // 1 for the root's entries
+	Info  fs.FileInfo
+}
+
+// WalkFunc is called for every entry that is not ignored. Returning
+// filepath.SkipDir for a directory skips its contents; for a file it skips
+// the rest of the directory.
+type WalkFunc func(entry Entry) error
+
+// Walk visits the files and directories under root that are not ignored,
+// in lexical order within each directory
+func Walk(root string, opts Options, fn WalkFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("failed to walk %s: not a directory", root)
+	}
+
+	matcher, err := NewMatcher(root, opts.Patterns...)
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+
+	err = walkDir(matcher, opts, root, "", 0, fn)
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walkDir(m *Matcher, opts Options, dir, rel string, depth int, fn WalkFunc) error {
+	m.load(rel)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if rel == "" {
+			return fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+		return nil // unreadable subdirectories are skipped
+	}
+
+	for _, de := range entries {
+		childRel := path.Join(rel, de.Name())
+		isDir := de.IsDir()
+		if de.Name() == ".git" || m.match(childRel, isDir) {
+			continue
+		}
+
+		childPath := filepath.Join(dir, de.Name())
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+
+		if !isDir && !(opts.IncludeBinary && opts.IncludeGenerated) {
+			head, err := sniff(childPath)
+			if err != nil {
+				continue
+			}
+			if (!opts.IncludeBinary && IsBinary(head)) || (!opts.IncludeGenerated && IsGenerated(childRel, head)) {
+				continue
+			}
+		}
+
+		err = fn(Entry{Path: childPath, Rel: childRel, Depth: depth + 1, Info: info})
+		if err == filepath.SkipDir {
+			if isDir {
+				continue
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if isDir && (opts.MaxDepth == 0 || depth+1 < opts.MaxDepth) {
+			if err := walkDir(m, opts, childPath, childRel, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Files returns the slash paths, relative to root, of the files under root
+// that are not ignored, sorted
+func Files(root string, opts Options) ([]string, error) {
+	var files []string
+	err := Walk(root, opts, func(entry Entry) error {
+		if !entry.Info.IsDir() {
+			files = append(files, entry.Rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
@@ -2,15 +2,15 @@
 package main
 
 import (
-    "fmt"
-    "os"
-    
-    "github.com/snowsoft/codeweaver/cmd"
+	"fmt"
+	"os"
+
+	"github.com/snowsoft/codeweaver/internal/cli"
 )
 
 func main() {
-    if err := cmd.Execute(); err != nil {
-        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-        os.Exit(1)
-    }
-}
\ No newline at end of file
+	if err := cli.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -11,11 +11,17 @@ if not exist "%CONFIG_DIR%" mkdir "%CONFIG_DIR%"
 if not exist "%CONFIG_DIR%\config.yaml" (
     (
     echo # Weaver Configuration
-    echo ollama:
-    echo   api_url: "http://localhost:11434"
-    echo   model: "codellama:13b-instruct"
+    echo version: 1
+    echo.
+    echo ai:
+    echo   default_provider: "ollama"
     echo   temperature: 0.7
     echo.
+    echo providers:
+    echo   ollama:
+    echo     api_url: "http://localhost:11434"
+    echo     model: "codellama:13b-instruct"
+    echo.
     echo ui:
     echo   theme: "dark"
     echo   show_spinner: true
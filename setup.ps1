@@ -15,11 +15,17 @@ $configFile = "$configDir\config.yaml"
 if (!(Test-Path $configFile)) {
     @"
 # Weaver Configuration
-ollama:
-  api_url: "http://localhost:11434"
-  model: "codellama:13b-instruct"
+version: 1
+
+ai:
+  default_provider: "ollama"
   temperature: 0.7
 
+providers:
+  ollama:
+    api_url: "http://localhost:11434"
+    model: "codellama:13b-instruct"
+
 ui:
   theme: "dark"
   show_spinner: true
@@ -12,11 +12,17 @@ mkdir -p "$CONFIG_DIR"
 if [ ! -f "$CONFIG_DIR/config.yaml" ]; then
     cat > "$CONFIG_DIR/config.yaml" << 'EOF'
 # Weaver Configuration
-ollama:
-  api_url: "http://localhost:11434"
-  model: "codellama:13b-instruct"
+version: 1
+
+ai:
+  default_provider: "ollama"
   temperature: 0.7
 
+providers:
+  ollama:
+    api_url: "http://localhost:11434"
+    model: "codellama:13b-instruct"
+
 ui:
   theme: "dark"
   show_spinner: true